@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -20,6 +21,37 @@ type OutputWriterSpinner interface {
 	StopSpinner()
 }
 
+// activeSpinners are the spinners started by the process, so that they can be stopped before exiting
+var activeSpinners = struct {
+	sync.Mutex
+	spinners map[*spinner.Spinner]struct{}
+}{spinners: make(map[*spinner.Spinner]struct{})}
+
+// StopActiveSpinners stops the spinners started by the process, e.g. before exiting on a signal, so that the
+// terminal is left in a clean state
+func StopActiveSpinners() {
+	activeSpinners.Lock()
+	defer activeSpinners.Unlock()
+	for s := range activeSpinners.spinners {
+		s.Stop()
+		delete(activeSpinners.spinners, s)
+	}
+}
+
+func startActiveSpinner(s *spinner.Spinner) {
+	activeSpinners.Lock()
+	defer activeSpinners.Unlock()
+	activeSpinners.spinners[s] = struct{}{}
+	s.Start()
+}
+
+func stopActiveSpinner(s *spinner.Spinner) {
+	activeSpinners.Lock()
+	defer activeSpinners.Unlock()
+	delete(activeSpinners.spinners, s)
+	s.Stop()
+}
+
 // outputwriterspinner is our internal implementation.
 type outputwriterspinner struct {
 	outputwriter
@@ -44,7 +76,7 @@ func NewOutputWriterWithSpinner(output io.Writer, outputFormat, spinnerText stri
 		// Start the spinner only if attached to terminal
 		attachedToTerminal := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
 		if startSpinner && attachedToTerminal {
-			ows.spinner.Start()
+			startActiveSpinner(ows.spinner)
 		}
 	}
 	return ows, nil
@@ -53,7 +85,7 @@ func NewOutputWriterWithSpinner(output io.Writer, outputFormat, spinnerText stri
 // RenderWithSpinner will stop spinner and render the output
 func (ows *outputwriterspinner) RenderWithSpinner() {
 	if ows.spinner != nil && ows.spinner.Active() {
-		ows.spinner.Stop()
+		stopActiveSpinner(ows.spinner)
 		fmt.Fprintln(ows.out)
 	}
 	ows.Render()
@@ -62,7 +94,7 @@ func (ows *outputwriterspinner) RenderWithSpinner() {
 // stop spinner
 func (ows *outputwriterspinner) StopSpinner() {
 	if ows.spinner != nil && ows.spinner.Active() {
-		ows.spinner.Stop()
+		stopActiveSpinner(ows.spinner)
 		fmt.Fprintln(ows.out)
 	}
 }
@@ -0,0 +1,30 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package component
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopActiveSpinners(t *testing.T) {
+	assert := assert.New(t)
+
+	s1 := spinner.New(spinner.CharSets[9], 100*time.Millisecond, spinner.WithWriter(io.Discard))
+	s2 := spinner.New(spinner.CharSets[9], 100*time.Millisecond, spinner.WithWriter(io.Discard))
+	startActiveSpinner(s1)
+	startActiveSpinner(s2)
+	assert.Len(activeSpinners.spinners, 2)
+
+	stopActiveSpinner(s1)
+	assert.Len(activeSpinners.spinners, 1)
+
+	StopActiveSpinners()
+	assert.Empty(activeSpinners.spinners)
+	assert.False(s2.Active())
+}
@@ -0,0 +1,251 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/jsonlutils"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
+)
+
+const (
+	// EnvConfigAuditFileKey is the environment variable that overrides the path of the audit file
+	EnvConfigAuditFileKey = "TANZU_CONFIG_AUDIT_FILE"
+	// AuditFileName is the name of the audit file stored under LocalDir
+	AuditFileName = "audit.log"
+
+	// auditRedactedValue replaces the values of secrets in the audit entries
+	auditRedactedValue = "<redacted>"
+)
+
+var (
+	// AuditMaxFileSize is the size in bytes after which the audit file is rotated
+	AuditMaxFileSize int64 = 1 << 20
+	// AuditMaxBackups is the number of rotated audit files that are kept e.g. audit.log.1, audit.log.2
+	AuditMaxBackups = 3
+)
+
+// auditSecretKeyRegex matches the config keys holding secrets, whose values are redacted in the audit entries
+var auditSecretKeyRegex = regexp.MustCompile(`(?i)(token|password|passwd|secret|credential|certdata|apikey|api_key|privatekey|private_key)`)
+
+// auditPluginName is the name of the plugin recorded in the audit entries, set by SetAuditPluginName
+var auditPluginName = filepath.Base(os.Args[0])
+
+// AuditChange is a change of the config recorded in the audit trail.
+// Values are only recorded for scalars, and the values of secrets are redacted.
+type AuditChange struct {
+	// Path addresses the changed node as described by nodeutils.Change e.g. contexts[test-mc].clusterOpts.endpoint
+	Path string               `json:"path" yaml:"path"`
+	Type nodeutils.ChangeType `json:"type" yaml:"type"`
+	Old  string               `json:"old,omitempty" yaml:"old,omitempty"`
+	New  string               `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// AuditEntry records the changes persisted by a call of a config API
+type AuditEntry struct {
+	Timestamp time.Time     `json:"timestamp" yaml:"timestamp"`
+	PID       int           `json:"pid" yaml:"pid"`
+	Plugin    string        `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	API       string        `json:"api,omitempty" yaml:"api,omitempty"`
+	Changes   []AuditChange `json:"changes" yaml:"changes"`
+}
+
+// AuditQueryOptions filters the audit entries returned by GetAuditEntries
+type AuditQueryOptions struct {
+	Plugin string
+	API    string
+	// Path keeps the entries changing the node at the path or nested under it
+	Path  string
+	Since time.Time
+}
+
+type AuditQueryOpts func(options *AuditQueryOptions)
+
+// WithAuditPlugin keeps the audit entries recorded by the plugin
+func WithAuditPlugin(plugin string) AuditQueryOpts {
+	return func(options *AuditQueryOptions) {
+		options.Plugin = plugin
+	}
+}
+
+// WithAuditAPI keeps the audit entries recorded by the config API e.g. SetContext
+func WithAuditAPI(api string) AuditQueryOpts {
+	return func(options *AuditQueryOptions) {
+		options.API = api
+	}
+}
+
+// WithAuditPath keeps the audit entries changing the node at the path or nested under it e.g. contexts[test-mc]
+func WithAuditPath(path string) AuditQueryOpts {
+	return func(options *AuditQueryOptions) {
+		options.Path = path
+	}
+}
+
+// WithAuditSince keeps the audit entries recorded at or after the time
+func WithAuditSince(since time.Time) AuditQueryOpts {
+	return func(options *AuditQueryOptions) {
+		options.Since = since
+	}
+}
+
+// SetAuditPluginName sets the name of the plugin recorded in the audit entries, defaults to the name of the executable
+func SetAuditPluginName(name string) {
+	auditPluginName = name
+}
+
+// AuditFilePath returns the path of the audit file, checking for environment overrides
+func AuditFilePath() (string, error) {
+	if path, ok := os.LookupEnv(EnvConfigAuditFileKey); ok {
+		return path, nil
+	}
+	localDir, err := LocalDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(localDir, AuditFileName), nil
+}
+
+// GetAuditEntries returns the audit entries matching the options, oldest first, including the rotated audit files
+func GetAuditEntries(opts ...AuditQueryOpts) ([]AuditEntry, error) {
+	options := &AuditQueryOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	path, err := AuditFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []AuditEntry
+	err = newAuditFile(path).Read(func(line []byte) {
+		var entry AuditEntry
+		// Skip entries that were partially written
+		if err := json.Unmarshal(line, &entry); err == nil && entry.matches(options) {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (e *AuditEntry) matches(options *AuditQueryOptions) bool {
+	if (options.Plugin != "" && e.Plugin != options.Plugin) ||
+		(options.API != "" && e.API != options.API) ||
+		(!options.Since.IsZero() && e.Timestamp.Before(options.Since)) {
+		return false
+	}
+	if options.Path == "" {
+		return true
+	}
+	for _, change := range e.Changes {
+		if change.Path == options.Path ||
+			strings.HasPrefix(change.Path, options.Path+nodeutils.PatchStrategyKeySeparator) ||
+			strings.HasPrefix(change.Path, options.Path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// recordAudit appends an audit entry of the config API with the changes between the nodes to the audit file.
+// Failing to record the entry does not fail the config update, so errors are only logged.
+func recordAudit(api string, oldNode, newNode *yaml.Node) {
+	changes := nodeutils.Diff(oldNode, newNode)
+	if len(changes) == 0 {
+		return
+	}
+	entry := AuditEntry{
+		Timestamp: time.Now().UTC(),
+		PID:       os.Getpid(),
+		Plugin:    auditPluginName,
+		API:       api,
+		Changes:   make([]AuditChange, 0, len(changes)),
+	}
+	for _, change := range changes {
+		entry.Changes = append(entry.Changes, newAuditChanges(change)...)
+	}
+	if err := appendAuditEntry(&entry); err != nil {
+		log.V(6).Infof("unable to record the config changes in the audit file: %v", err)
+	}
+}
+
+// newAuditChanges returns the audit changes of the change, added or removed collections are recorded as their scalars
+func newAuditChanges(change nodeutils.Change) []AuditChange {
+	var collection *yaml.Node
+	switch change.Type {
+	case nodeutils.ChangeAdded:
+		collection = change.New
+	case nodeutils.ChangeRemoved:
+		collection = change.Old
+	}
+	if collection == nil || collection.Kind == yaml.ScalarNode {
+		return []AuditChange{newAuditChange(change)}
+	}
+	leaves := nodeutils.Leaves(collection, nodeutils.WithPatchStrategyKey(change.Path))
+	if len(leaves) == 0 {
+		return []AuditChange{newAuditChange(change)}
+	}
+	auditChanges := make([]AuditChange, 0, len(leaves))
+	for _, leaf := range leaves {
+		leafChange := nodeutils.Change{Path: leaf.Path, Type: change.Type}
+		if change.Type == nodeutils.ChangeAdded {
+			leafChange.New = leaf.Node
+		} else {
+			leafChange.Old = leaf.Node
+		}
+		auditChanges = append(auditChanges, newAuditChange(leafChange))
+	}
+	return auditChanges
+}
+
+func newAuditChange(change nodeutils.Change) AuditChange {
+	auditChange := AuditChange{Path: change.Path, Type: change.Type}
+	redact := auditSecretKeyRegex.MatchString(auditLastKey(change.Path))
+	auditChange.Old = auditValue(change.Old, redact)
+	auditChange.New = auditValue(change.New, redact)
+	return auditChange
+}
+
+func auditValue(node *yaml.Node, redact bool) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	if redact {
+		return auditRedactedValue
+	}
+	return node.Value
+}
+
+// auditLastKey returns the last mapping key of the path e.g. accessToken for contexts[test].globalOpts.auth.accessToken
+func auditLastKey(path string) string {
+	if index := strings.Index(path, "["); index != -1 && !strings.Contains(path[index:], nodeutils.PatchStrategyKeySeparator) {
+		path = path[:index]
+	}
+	return path[strings.LastIndex(path, nodeutils.PatchStrategyKeySeparator)+1:]
+}
+
+func appendAuditEntry(entry *AuditEntry) error {
+	path, err := AuditFilePath()
+	if err != nil {
+		return err
+	}
+	return newAuditFile(path).Append(entry)
+}
+
+// newAuditFile returns the audit file at the path, rotated as per AuditMaxFileSize and AuditMaxBackups
+func newAuditFile(path string) *jsonlutils.RotatingFile {
+	return &jsonlutils.RotatingFile{Path: path, MaxFileSize: AuditMaxFileSize, MaxBackups: AuditMaxBackups, Name: "audit"}
+}
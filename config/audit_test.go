@@ -0,0 +1,112 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestAuditEntries(t *testing.T) {
+	_, cleanUp := setupTestConfig(t, &CfgTestData{})
+	defer cleanUp()
+	SetAuditPluginName("test-plugin")
+	defer SetAuditPluginName("config.test")
+	start := time.Now().Add(-time.Second)
+
+	ctx := &configtypes.Context{
+		Name:   "test-mc",
+		Target: configtypes.TargetTMC,
+		GlobalOpts: &configtypes.GlobalServer{
+			Endpoint: "test-endpoint",
+			Auth:     configtypes.GlobalServerAuth{AccessToken: "old-token"},
+		},
+	}
+	require.NoError(t, SetContext(ctx, false))
+	ctx.GlobalOpts.Auth.AccessToken = "new-token"
+	require.NoError(t, SetContext(ctx, false))
+	require.NoError(t, SetEnv("FOO", "bar"))
+	// Setting the same value again persists no change and is not recorded
+	require.NoError(t, SetEnv("FOO", "bar"))
+	require.NoError(t, SetConfigMetadataSetting("test-setting", "true"))
+	require.NoError(t, RemoveContext("test-mc"))
+
+	// Each call records one entry, even though SetContext also updates the matching server
+	entries, err := GetAuditEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+	apis := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		assert.Equal(t, os.Getpid(), entry.PID)
+		assert.Equal(t, "test-plugin", entry.Plugin)
+		assert.True(t, entry.Timestamp.After(start))
+		apis = append(apis, entry.API)
+	}
+	assert.Equal(t, []string{"SetContext", "SetContext", "SetEnv", "SetConfigMetadataSetting", "RemoveContext"}, apis)
+
+	assert.Equal(t, []AuditChange{
+		{Path: "contexts[test-mc].name", Type: nodeutils.ChangeAdded, New: "test-mc"},
+		{Path: "contexts[test-mc].target", Type: nodeutils.ChangeAdded, New: "mission-control"},
+		{Path: "contexts[test-mc].globalOpts.endpoint", Type: nodeutils.ChangeAdded, New: "test-endpoint"},
+		{Path: "contexts[test-mc].globalOpts.auth.accessToken", Type: nodeutils.ChangeAdded, New: "<redacted>"},
+		{Path: "servers[test-mc].name", Type: nodeutils.ChangeAdded, New: "test-mc"},
+		{Path: "servers[test-mc].type", Type: nodeutils.ChangeAdded, New: "global"},
+		{Path: "servers[test-mc].globalOpts.endpoint", Type: nodeutils.ChangeAdded, New: "test-endpoint"},
+		{Path: "servers[test-mc].globalOpts.auth.accessToken", Type: nodeutils.ChangeAdded, New: "<redacted>"},
+	}, entries[0].Changes)
+	assert.ElementsMatch(t, []AuditChange{
+		{Path: "contexts[test-mc].globalOpts.auth.accessToken", Type: nodeutils.ChangeModified, Old: "<redacted>", New: "<redacted>"},
+		{Path: "servers[test-mc].globalOpts.auth.accessToken", Type: nodeutils.ChangeModified, Old: "<redacted>", New: "<redacted>"},
+	}, entries[1].Changes)
+	assert.Equal(t, []AuditChange{{Path: "clientOptions.env.FOO", Type: nodeutils.ChangeAdded, New: "bar"}}, entries[2].Changes)
+	assert.Equal(t, []AuditChange{{Path: "configMetadata.settings.test-setting", Type: nodeutils.ChangeAdded, New: "true"}}, entries[3].Changes)
+
+	entries, err = GetAuditEntries(WithAuditPath("contexts[test-mc]"), WithAuditAPI("RemoveContext"), WithAuditPlugin("test-plugin"), WithAuditSince(start))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].Changes, AuditChange{Path: "contexts[test-mc].globalOpts.endpoint", Type: nodeutils.ChangeRemoved, Old: "test-endpoint"})
+
+	entries, err = GetAuditEntries(WithAuditPlugin("other-plugin"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestAuditFileRotation(t *testing.T) {
+	_, cleanUp := setupTestConfig(t, &CfgTestData{})
+	defer cleanUp()
+	maxFileSize, maxBackups := AuditMaxFileSize, AuditMaxBackups
+	defer func() {
+		AuditMaxFileSize, AuditMaxBackups = maxFileSize, maxBackups
+	}()
+	AuditMaxFileSize, AuditMaxBackups = 400, 2
+
+	for _, value := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+		require.NoError(t, SetEnv("FOO", value))
+	}
+	path, err := AuditFilePath()
+	require.NoError(t, err)
+	for _, rotated := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(rotated)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), AuditMaxFileSize)
+	}
+	assert.NoFileExists(t, path+".3")
+
+	// The oldest entries are dropped and the remaining ones are returned in order
+	entries, err := GetAuditEntries()
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Less(t, len(entries), 8)
+	assert.Equal(t, "8", entries[len(entries)-1].Changes[0].New)
+	for i := 1; i < len(entries); i++ {
+		assert.Equal(t, entries[i-1].Changes[0].New, entries[i].Changes[0].Old)
+	}
+}
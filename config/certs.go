@@ -16,13 +16,13 @@ import (
 
 // GetCerts retrieves all the certs
 func GetCerts() ([]*configtypes.Cert, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return getCerts(node)
+	return getCerts(cfg)
 }
 
 // GetCert retrieves the cert configuration by host
@@ -30,12 +30,12 @@ func GetCert(host string) (*configtypes.Cert, error) {
 	if host == "" {
 		return nil, errors.New("host is empty")
 	}
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	return getCert(node, host)
+	return getCert(cfg, host)
 }
 
 // SetCert add or update cert configuration
@@ -46,8 +46,13 @@ func SetCert(c *configtypes.Cert) error {
 	if c.Host == "" {
 		return errors.New("host is empty")
 	}
+	if err := checkPolicyLocked(certPolicyPath(c.Host)); err != nil {
+		return err
+	}
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -59,7 +64,7 @@ func SetCert(c *configtypes.Cert) error {
 		return err
 	}
 	if persist {
-		err = persistConfig(node)
+		err = persistConfig(node, "SetCert")
 		if err != nil {
 			return err
 		}
@@ -72,14 +77,23 @@ func DeleteCert(host string) error {
 	if host == "" {
 		return errors.New("host is empty")
 	}
+	if err := checkPolicyLocked(certPolicyPath(host)); err != nil {
+		return err
+	}
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
-	_, err = getCert(node, host)
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return err
+	}
+	_, err = getCert(cfg, host)
 	if err != nil {
 		return err
 	}
@@ -87,7 +101,7 @@ func DeleteCert(host string) error {
 	if err != nil {
 		return err
 	}
-	return persistConfig(node)
+	return persistConfig(node, "DeleteCert")
 }
 
 // CertExists checks if cert config by host already exists
@@ -100,11 +114,7 @@ func CertExists(host string) (bool, error) {
 }
 
 // Pre-reqs: node != nil
-func getCerts(node *yaml.Node) ([]*configtypes.Cert, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getCerts(cfg *configtypes.ClientConfig) ([]*configtypes.Cert, error) {
 	if cfg.Certs != nil {
 		return cfg.Certs, nil
 	}
@@ -113,11 +123,7 @@ func getCerts(node *yaml.Node) ([]*configtypes.Cert, error) {
 }
 
 // Pre-reqs: node != nil and host != ""
-func getCert(node *yaml.Node, host string) (*configtypes.Cert, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getCert(cfg *configtypes.ClientConfig, host string) (*configtypes.Cert, error) {
 	for _, cert := range cfg.Certs {
 		if cert.Host == host {
 			return cert, nil
@@ -129,10 +135,7 @@ func getCert(node *yaml.Node, host string) (*configtypes.Cert, error) {
 // Pre-reqs: node != nil and cert != nil
 func setCert(node *yaml.Node, cert *configtypes.Cert) (persist bool, err error) {
 	// Get Patch Strategies from config metadata
-	patchStrategies, err := GetConfigMetadataPatchStrategy()
-	if err != nil {
-		patchStrategies = make(map[string]string)
-	}
+	patchStrategies := getPatchStrategies()
 
 	// Convert cert to node
 	newCertNode, err := convertObjectToNode(cert)
@@ -149,33 +152,13 @@ func setCert(node *yaml.Node, cert *configtypes.Cert) (persist bool, err error)
 		return persist, err
 	}
 
-	exists := false
-	var result []*yaml.Node
-	// Skip duplicate for cert
-	for _, certNode := range certsNode.Content {
-		if index := nodeutils.GetNodeIndex(certNode.Content, "host"); index != -1 &&
-			certNode.Content[index].Value == cert.Host {
-			exists = true
-			// replace the nodes as per patch strategy
-			_, err = nodeutils.DeleteNodes(newCertNode.Content[0], certNode, nodeutils.WithPatchStrategyKey(KeyCerts), nodeutils.WithPatchStrategies(patchStrategies))
-			if err != nil {
-				return false, err
-			}
-			persist, err = nodeutils.MergeNodes(newCertNode.Content[0], certNode)
-			if err != nil {
-				return false, err
-			}
-			result = append(result, certNode)
-			continue
-		}
-		result = append(result, certNode)
-	}
-	if !exists {
-		result = append(result, newCertNode.Content[0])
-		persist = true
+	// Upsert the cert by host, replacing nodes as per patch strategy
+	newCertsNode := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{newCertNode.Content[0]}}
+	patchStrategyOpts := sequencePatchStrategyOpts(KeyCerts, patchStrategies)
+	if _, err = nodeutils.DeleteNodes(newCertsNode, certsNode, patchStrategyOpts...); err != nil {
+		return false, err
 	}
-	certsNode.Content = result
-	return persist, err
+	return nodeutils.MergeNodes(newCertsNode, certsNode, patchStrategyOpts...)
 }
 
 func removeCert(node *yaml.Node, host string) error {
@@ -15,30 +15,61 @@ import (
 
 // GetCLIDiscoverySources retrieves cli discovery sources
 func GetCLIDiscoverySources() ([]configtypes.PluginDiscovery, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return getCLIDiscoverySources(node)
+	return getCLIDiscoverySources(cfg)
+}
+
+// CLIDiscoverySourceName is the name of a cli discovery source along with its type e.g. oci
+type CLIDiscoverySourceName struct {
+	Name string
+	Type string
+}
+
+// GetCLIDiscoverySourceNames retrieves the names of the cli discovery sources along with their type, in the order
+// of the config. Sources of different types may have the same name.
+func GetCLIDiscoverySourceNames() ([]CLIDiscoverySourceName, error) {
+	discoverySources, err := GetCLIDiscoverySources()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]CLIDiscoverySourceName, 0, len(discoverySources))
+	for _, discoverySource := range discoverySources {
+		// Skip invalid discovery sources
+		if discoverySourceType, discoverySourceName, err := getDiscoverySourceTypeAndName(discoverySource); err == nil {
+			names = append(names, CLIDiscoverySourceName{Name: discoverySourceName, Type: discoverySourceType})
+		}
+	}
+	return names, nil
 }
 
 // GetCLIDiscoverySource retrieves cli discovery source by name assuming that there should only be one source with the name, returns the first match
 func GetCLIDiscoverySource(name string) (*configtypes.PluginDiscovery, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return getCLIDiscoverySource(node, name)
+	return getCLIDiscoverySource(cfg, name)
 }
 
 // SetCLIDiscoverySources Add/Update array of cli discovery sources to the yaml node
 func SetCLIDiscoverySources(discoverySources []configtypes.PluginDiscovery) (err error) {
+	for _, discoverySource := range discoverySources {
+		if err := checkDiscoverySourcePolicyLocked(discoverySource); err != nil {
+			return err
+		}
+	}
+
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -53,7 +84,7 @@ func SetCLIDiscoverySources(discoverySources []configtypes.PluginDiscovery) (err
 		}
 		// Persist the config node to the file
 		if persist {
-			err = persistConfig(node)
+			err = persistConfig(node, "SetCLIDiscoverySources")
 			if err != nil {
 				return err
 			}
@@ -64,8 +95,14 @@ func SetCLIDiscoverySources(discoverySources []configtypes.PluginDiscovery) (err
 
 // SetCLIDiscoverySource add or update a cli discoverySource
 func SetCLIDiscoverySource(discoverySource configtypes.PluginDiscovery) (err error) {
+	if err := checkDiscoverySourcePolicyLocked(discoverySource); err != nil {
+		return err
+	}
+
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -80,7 +117,7 @@ func SetCLIDiscoverySource(discoverySource configtypes.PluginDiscovery) (err err
 
 	// Persist the config node to the file
 	if persist {
-		return persistConfig(node)
+		return persistConfig(node, "SetCLIDiscoverySource")
 	}
 
 	return err
@@ -88,8 +125,14 @@ func SetCLIDiscoverySource(discoverySource configtypes.PluginDiscovery) (err err
 
 // DeleteCLIDiscoverySource delete cli discoverySource by name
 func DeleteCLIDiscoverySource(name string) error {
+	if err := checkDiscoverySourceNamePolicyLocked(name); err != nil {
+		return err
+	}
+
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -103,30 +146,22 @@ func DeleteCLIDiscoverySource(name string) error {
 	}
 
 	// Persist the config node to the file
-	return persistConfig(node)
+	return persistConfig(node, "DeleteCLIDiscoverySource")
 }
 
-func getCLIDiscoverySources(node *yaml.Node) ([]configtypes.PluginDiscovery, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getCLIDiscoverySources(cfg *configtypes.ClientConfig) ([]configtypes.PluginDiscovery, error) {
 	if cfg.CoreCliOptions != nil && cfg.CoreCliOptions.DiscoverySources != nil {
 		return cfg.CoreCliOptions.DiscoverySources, nil
 	}
 	return nil, errors.New("cli discovery sources not found")
 }
 
-func getCLIDiscoverySource(node *yaml.Node, name string) (*configtypes.PluginDiscovery, error) {
+func getCLIDiscoverySource(cfg *configtypes.ClientConfig, name string) (*configtypes.PluginDiscovery, error) {
 	// check if context name is empty
 	if name == "" {
 		return nil, errors.New("discovery source name cannot be empty")
 	}
 
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
 	if cfg.CoreCliOptions != nil && cfg.CoreCliOptions.DiscoverySources != nil {
 		for _, discoverySource := range cfg.CoreCliOptions.DiscoverySources {
 			_, discoverySourceName, err := getDiscoverySourceTypeAndName(discoverySource)
@@ -143,11 +178,8 @@ func getCLIDiscoverySource(node *yaml.Node, name string) (*configtypes.PluginDis
 
 // setCLIDiscoverySource Add/Update cli discovery source in the yaml node
 func setCLIDiscoverySource(node *yaml.Node, discoverySource configtypes.PluginDiscovery) (persist bool, err error) {
-	// Retrieve the patch strategies from config metadata
-	patchStrategies, err := GetConfigMetadataPatchStrategy()
-	if err != nil {
-		patchStrategies = make(map[string]string)
-	}
+	// Get Patch Strategies from config metadata
+	patchStrategies := getPatchStrategies()
 
 	// Find the cli discovery sources node
 	keys := []nodeutils.Key{
@@ -176,7 +208,11 @@ func deleteCLIDiscoverySource(node *yaml.Node, name string) error {
 	}
 
 	// Get matching cli discovery source from the yaml node
-	discoverySource, err := getCLIDiscoverySource(node, name)
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return err
+	}
+	discoverySource, err := getCLIDiscoverySource(cfg, name)
 	if err != nil {
 		return err
 	}
@@ -197,7 +233,7 @@ func deleteCLIDiscoverySource(node *yaml.Node, name string) error {
 		}
 		result = append(result, discoverySourceNode)
 	}
-	cliDiscoverySourcesNode.Style = 0
+	nodeutils.ResetEmptyCollectionStyle(cliDiscoverySourcesNode)
 	cliDiscoverySourcesNode.Content = result
 	return nil
 }
@@ -4,6 +4,7 @@
 package config
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,6 +51,40 @@ func TestGetCLIDiscoverySources(t *testing.T) {
 	}
 }
 
+func TestGetCLIDiscoverySourceNames(t *testing.T) {
+	// Setup config test data
+	_, cleanUp := setupTestConfig(t, &CfgTestData{})
+
+	defer func() {
+		cleanUp()
+	}()
+
+	_, err := GetCLIDiscoverySourceNames()
+	assert.ErrorContains(t, err, "cli discovery sources not found")
+
+	// The setters replace sources by name, but the config files may hold sources of different types with the same name
+	err = os.WriteFile(os.Getenv(EnvConfigNextGenKey), []byte(`cli:
+  discoverySources:
+    - oci:
+        name: default
+        image: image
+    - local:
+        name: local
+        path: path
+    - local:
+        name: default
+        path: path
+`), 0644)
+	assert.NoError(t, err)
+	names, err := GetCLIDiscoverySourceNames()
+	assert.NoError(t, err)
+	assert.Equal(t, []CLIDiscoverySourceName{
+		{Name: "default", Type: DiscoveryTypeOCI},
+		{Name: "local", Type: DiscoveryTypeLocal},
+		{Name: "default", Type: DiscoveryTypeLocal},
+	}, names)
+}
+
 func TestGetCLIDiscoverySource(t *testing.T) {
 	// Setup config test data
 	_, cleanUp := setupTestConfig(t, &CfgTestData{})
@@ -8,16 +8,17 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
 )
 
 // GetEdition retrieves ClientOptions Edition
 func GetEdition() (string, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return "", err
 	}
-	return getEdition(node)
+	return getEdition(cfg)
 }
 
 // SetEdition adds or updates edition value
@@ -27,19 +28,27 @@ func SetEdition(val string) (err error) {
 		return errors.New("value cannot be empty")
 	}
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
 
+	// Delete the cli options replaced as per the patch strategies
+	replaced, err := applyValuePatchStrategies(node, []string{KeyClientOptions, KeyCLI, KeyEdition}, val)
+	if err != nil {
+		return err
+	}
+
 	// Add or Update edition in the yaml node
 	persist := setEdition(node, val)
 
 	// Persist the config node to the file
-	if persist {
-		return persistConfig(node)
+	if persist || replaced {
+		return persistConfig(node, "SetEdition")
 	}
 	return err
 }
@@ -53,11 +62,7 @@ func setEdition(node *yaml.Node, val string) (persist bool) {
 	return persist
 }
 
-func getEdition(node *yaml.Node) (string, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return "", err
-	}
+func getEdition(cfg *configtypes.ClientConfig) (string, error) {
 	if cfg != nil && cfg.ClientOptions != nil && cfg.ClientOptions.CLI != nil {
 		//nolint:staticcheck
 		return string(cfg.ClientOptions.CLI.Edition), nil
@@ -8,6 +8,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
 )
 
 // EULAStatus is the user's EULA acceptance status
@@ -24,39 +25,47 @@ const (
 
 // GetCEIPOptIn retrieves ClientOptions ceipOptIn
 func GetCEIPOptIn() (string, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return "", err
 	}
-	return getCEIPOptIn(node)
+	return getCEIPOptIn(cfg)
 }
 
 // SetCEIPOptIn adds or updates ceipOptIn value
 func SetCEIPOptIn(val string) (err error) {
+	if err := checkPolicyLocked(KeyCLI + "." + KeyCEIPOptIn); err != nil {
+		return err
+	}
+
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
 
+	// Delete the cli options replaced as per the patch strategies
+	replaced, err := applyValuePatchStrategies(node, []string{KeyCLI, KeyCEIPOptIn}, val)
+	if err != nil {
+		return err
+	}
+
 	// Add or Update ceipOptIn in the yaml node
 	persist := setCLIOptionsString(node, KeyCEIPOptIn, val)
 
 	// Persist the config node to the file
-	if persist {
-		return persistConfig(node)
+	if persist || replaced {
+		return persistConfig(node, "SetCEIPOptIn")
 	}
 	return err
 }
 
-func getCEIPOptIn(node *yaml.Node) (string, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return "", err
-	}
+func getCEIPOptIn(cfg *configtypes.ClientConfig) (string, error) {
 	if cfg != nil && cfg.CoreCliOptions != nil {
 		return cfg.CoreCliOptions.CEIPOptIn, nil
 	}
@@ -65,12 +74,12 @@ func getCEIPOptIn(node *yaml.Node) (string, error) {
 
 // GetEULAStatus retrieves EULA status
 func GetEULAStatus() (EULAStatus, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return "", err
 	}
-	return getEULAStatus(node)
+	return getEULAStatus(cfg)
 }
 
 // SetEULAStatus adds or updates the EULA status
@@ -78,30 +87,37 @@ func SetEULAStatus(val EULAStatus) (err error) {
 	if val != EULAStatusShown && val != EULAStatusUnset && val != EULAStatusAccepted {
 		return errors.New("invalid eula status")
 	}
+	if err := checkPolicyLocked(KeyCLI + "." + KeyEULAStatus); err != nil {
+		return err
+	}
 
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
 
+	// Delete the cli options replaced as per the patch strategies
+	replaced, err := applyValuePatchStrategies(node, []string{KeyCLI, KeyEULAStatus}, string(val))
+	if err != nil {
+		return err
+	}
+
 	// Add or update EULA acceptance status in the yaml node
 	persist := setCLIOptionsString(node, KeyEULAStatus, string(val))
 
 	// Persist the config node to the file
-	if persist {
-		return persistConfig(node)
+	if persist || replaced {
+		return persistConfig(node, "SetEULAStatus")
 	}
 	return err
 }
 
-func getEULAStatus(node *yaml.Node) (EULAStatus, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return "", err
-	}
+func getEULAStatus(cfg *configtypes.ClientConfig) (EULAStatus, error) {
 	if cfg != nil && cfg.CoreCliOptions != nil {
 		if cfg.CoreCliOptions.EULAStatus == "" {
 			return EULAStatusUnset, nil
@@ -15,30 +15,32 @@ import (
 
 // GetCLIRepositories retrieves cli repositories
 func GetCLIRepositories() ([]configtypes.PluginRepository, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return getCLIRepositories(node)
+	return getCLIRepositories(cfg)
 }
 
 // GetCLIRepository retrieves cli repository by name
 func GetCLIRepository(name string) (*configtypes.PluginRepository, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return getCLIRepository(node, name)
+	return getCLIRepository(cfg, name)
 }
 
 // SetCLIRepository add or update a repository
 func SetCLIRepository(repository configtypes.PluginRepository) (err error) {
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -53,7 +55,7 @@ func SetCLIRepository(repository configtypes.PluginRepository) (err error) {
 
 	// Persist the config node to the file
 	if persist {
-		err = persistConfig(node)
+		err = persistConfig(node, "SetCLIRepository")
 		if err != nil {
 			return err
 		}
@@ -65,7 +67,9 @@ func SetCLIRepository(repository configtypes.PluginRepository) (err error) {
 // DeleteCLIRepository delete a cli repository by name
 func DeleteCLIRepository(name string) error {
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -79,25 +83,17 @@ func DeleteCLIRepository(name string) error {
 	}
 
 	// Persist the config node to the file
-	return persistConfig(node)
+	return persistConfig(node, "DeleteCLIRepository")
 }
 
-func getCLIRepositories(node *yaml.Node) ([]configtypes.PluginRepository, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getCLIRepositories(cfg *configtypes.ClientConfig) ([]configtypes.PluginRepository, error) {
 	if cfg.ClientOptions != nil && cfg.ClientOptions.CLI != nil && cfg.ClientOptions.CLI.Repositories != nil {
 		return cfg.ClientOptions.CLI.Repositories, nil
 	}
 	return nil, errors.New("cli repositories not found")
 }
 
-func getCLIRepository(node *yaml.Node, name string) (*configtypes.PluginRepository, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getCLIRepository(cfg *configtypes.ClientConfig, name string) (*configtypes.PluginRepository, error) {
 	if cfg.ClientOptions != nil && cfg.ClientOptions.CLI != nil && cfg.ClientOptions.CLI.Repositories != nil {
 		for _, repository := range cfg.ClientOptions.CLI.Repositories {
 			_, repositoryName := getRepositoryTypeAndName(repository)
@@ -120,11 +116,8 @@ func setCLIRepositories(node *yaml.Node, repos []configtypes.PluginRepository) (
 }
 
 func setCLIRepository(node *yaml.Node, repository configtypes.PluginRepository) (persist bool, err error) {
-	// Retrieve the patch strategies from config metadata
-	patchStrategies, err := GetConfigMetadataPatchStrategy()
-	if err != nil {
-		patchStrategies = make(map[string]string)
-	}
+	// Get Patch Strategies from config metadata
+	patchStrategies := getPatchStrategies()
 
 	// Find the cli repositories node in the yaml node
 	keys := []nodeutils.Key{
@@ -153,7 +146,11 @@ func deleteCLIRepository(node *yaml.Node, name string) error {
 		return nil
 	}
 
-	repository, err := getCLIRepository(node, name)
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return err
+	}
+	repository, err := getCLIRepository(cfg, name)
 	if err != nil {
 		return err
 	}
@@ -171,7 +168,7 @@ func deleteCLIRepository(node *yaml.Node, name string) error {
 		}
 		result = append(result, repositoryNode)
 	}
-	cliRepositoriesNode.Style = 0
+	nodeutils.ResetEmptyCollectionStyle(cliRepositoriesNode)
 	cliRepositoriesNode.Content = result
 	return nil
 }
@@ -218,7 +215,7 @@ func setRepository(repositoriesNode *yaml.Node, repository configtypes.PluginRep
 		result = append(result, newNode.Content[0])
 		persist = true
 	}
-	repositoriesNode.Style = 0
+	nodeutils.ResetEmptyCollectionStyle(repositoriesNode)
 	repositoriesNode.Content = result
 	return persist, err
 }
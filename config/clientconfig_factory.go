@@ -9,16 +9,26 @@
 package config
 
 import (
-	"os"
-
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
 	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
 )
 
-// getClientConfigNode retrieves the multi config from the local directory with file lock
-func getClientConfigNode() (*yaml.Node, error) {
+// getDecodedClientConfig retrieves the config read by the getters, merged from the system, user, project and
+// policy config layers so that the getters return the values pinned by the policy config, decoded.
+// It is served from the cache while the files it was read from are unchanged, so that the getters only take the
+// config lock, read and decode the files again once they changed.
+func getDecodedClientConfig() (*configtypes.ClientConfig, error) {
+	entry, err := getCachedClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return copyClientConfig(entry.cfg), nil
+}
+
+// getUserConfigNode retrieves the multi config from the local directory with file lock
+func getUserConfigNode() (*yaml.Node, error) {
 	useUnifiedConfig, err := UseUnifiedConfig()
 	if err != nil {
 		useUnifiedConfig = false
@@ -30,8 +40,14 @@ func getClientConfigNode() (*yaml.Node, error) {
 	return getMultiConfig()
 }
 
-// getClientConfigNodeNoLock retrieves the multi config from the local directory without acquiring the lock
-func getClientConfigNodeNoLock() (*yaml.Node, error) {
+// getClientConfigNodeNoLock retrieves the multi config from the local directory without acquiring the lock.
+// The first config read by the holder of the lock is kept as the snapshot persistConfig merges concurrent changes against.
+func getClientConfigNodeNoLock() (node *yaml.Node, err error) {
+	defer func() {
+		if err == nil {
+			configSnapshot.record(node)
+		}
+	}()
 	// Check config migration feature flag
 	useUnifiedConfig, err := UseUnifiedConfig()
 	if err != nil {
@@ -47,7 +63,9 @@ func getClientConfigNodeNoLock() (*yaml.Node, error) {
 // getClientConfig retrieves the config from the local directory with file lock
 func getClientConfig() (*yaml.Node, error) {
 	// Acquire tanzu config lock
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return nil, err
+	}
 	defer ReleaseTanzuConfigLock()
 	return getClientConfigNoLock()
 }
@@ -58,21 +76,17 @@ func getClientConfigNoLock() (*yaml.Node, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "getClientConfigNodeNoLock: failed getting client config path")
 	}
-	bytes, err := os.ReadFile(cfgPath)
-	if err != nil || len(bytes) == 0 {
-		node, err := newClientConfigNode()
+	node, err := readConfigFileNode(cfgPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "getClientConfigNodeNoLock: failed to construct struct from config data")
+	}
+	if node == nil {
+		node, err = newClientConfigNode()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create new client config")
 		}
-		return node, nil
-	}
-	var node yaml.Node
-	err = yaml.Unmarshal(bytes, &node)
-	if err != nil {
-		return nil, errors.Wrap(err, "getClientConfigNodeNoLock: failed to construct struct from config data")
 	}
-	node.Content[0].Style = 0
-	return &node, nil
+	return node, nil
 }
 
 // newClientConfigNode create and return new client config node
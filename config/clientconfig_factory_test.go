@@ -20,7 +20,7 @@ func TestClientConfigNodeUpdateInParallel(t *testing.T) {
 	}
 
 	addServer := func(mcName string) error {
-		_, err := getClientConfigNode()
+		_, err := getUserConfigNode()
 		if err != nil {
 			return err
 		}
@@ -37,7 +37,7 @@ func TestClientConfigNodeUpdateInParallel(t *testing.T) {
 		if err != nil {
 			return err
 		}
-		_, err = getClientConfigNode()
+		_, err = getUserConfigNode()
 		return err
 	}
 	// Run the parallel tests of reading and updating the configuration file
@@ -62,7 +62,7 @@ func TestClientConfigNodeUpdateInParallel(t *testing.T) {
 			}
 			_ = group.Wait()
 			// Make sure that the configuration file is not corrupted
-			node, err := getClientConfigNode()
+			node, err := getUserConfigNode()
 			assert.Nil(t, err)
 			// Make sure all expected servers are added to the knownServers list
 			assert.Equal(t, parallelExecutionCounter, len(node.Content[0].Content[5].Content))
@@ -16,9 +16,9 @@ const (
 	ConfigName = "config.yaml"
 )
 
-// ClientConfigPath returns the tanzu config path, checking for environment overrides.
+// ClientConfigPath returns the tanzu config path of the active profile, checking for environment overrides.
 func ClientConfigPath() (path string, err error) {
-	return configPath(LocalDir)
+	return configPath(ProfileLocalDir)
 }
 
 // configPath constructs the full config path, checking for environment overrides.
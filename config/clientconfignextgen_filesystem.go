@@ -31,7 +31,7 @@ func clientConfigNextGenPath(localDirGetter func() (string, error)) (path string
 	return
 }
 
-// ClientConfigNextGenPath retrieved config-alt file path
+// ClientConfigNextGenPath retrieved config-alt file path of the active profile
 func ClientConfigNextGenPath() (path string, err error) {
-	return clientConfigNextGenPath(LocalDir)
+	return clientConfigNextGenPath(ProfileLocalDir)
 }
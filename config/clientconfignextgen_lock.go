@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/juju/fslock"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -19,8 +20,6 @@ const (
 	DefaultConfigNextGenLockTimeout = 10 * time.Minute
 )
 
-var cfgNextGenLockFile string
-
 // cfgNextGenLock used as a static lock variable that stores fslock
 // This is used for interprocess locking of the config file
 var cfgNextGenLock *fslock.Lock
@@ -29,27 +28,32 @@ var cfgNextGenLock *fslock.Lock
 // within the existing process trying to acquire the lock
 var cfgNextGenMutex sync.Mutex
 
-// AcquireTanzuConfigNextGenLock tries to acquire lock to update tanzu config file with timeout
+// AcquireTanzuConfigNextGenLock tries to acquire lock to update tanzu config file with timeout.
+// It panics if the lock cannot be acquired.
 func AcquireTanzuConfigNextGenLock() {
-	var err error
+	if err := acquireTanzuConfigNextGenLock(); err != nil {
+		panic(err.Error())
+	}
+}
 
-	if cfgNextGenLockFile == "" {
-		path, err := ClientConfigNextGenPath()
-		if err != nil {
-			panic(fmt.Sprintf("cannot get config path while acquiring lock on tanzu config file, reason: %v", err))
-		}
-		cfgNextGenLockFile = filepath.Join(filepath.Dir(path), LocalTanzuConfigNextGenFileLock)
+// acquireTanzuConfigNextGenLock acquires the lock of the tanzu config file of the active profile, whose path is resolved on every
+// acquisition
+func acquireTanzuConfigNextGenLock() error {
+	path, err := ClientConfigNextGenPath()
+	if err != nil {
+		return errors.Wrap(err, "cannot get config path while acquiring lock on tanzu config file")
 	}
 
 	// using fslock to handle interprocess locking
-	lock, err := getFileLockWithTimeOut(cfgNextGenLockFile, DefaultConfigNextGenLockTimeout)
+	lock, err := getFileLockWithTimeOut(filepath.Join(filepath.Dir(path), LocalTanzuConfigNextGenFileLock), DefaultConfigNextGenLockTimeout)
 	if err != nil {
-		panic(fmt.Sprintf("cannot acquire lock for tanzu config file, reason: %v", err))
+		return errors.Wrap(err, "cannot acquire lock for tanzu config file")
 	}
 
-	// Lock the mutex to prevent concurrent calls to acquire and configure the tanzuConfigLock
+	// Lock the mutex to prevent concurrent calls to acquire and configure the cfgNextGenLock
 	cfgNextGenMutex.Lock()
 	cfgNextGenLock = lock
+	return nil
 }
 
 // ReleaseTanzuConfigNextGenLock releases the lock if the tanzuConfigLock was acquired
@@ -0,0 +1,303 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"reflect"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+// configFileCache caches the parsed config files of the process so that getters do not parse unchanged files again
+var configFileCache = &fileNodeCache{enabled: true, entries: make(map[string]*fileNodeCacheEntry)}
+
+// fileNodeCache caches parsed yaml files by path. Entries are valid as long as the file has the same
+// size and modification time and is the same file, as per os.SameFile, and are dropped on local writes.
+// It also caches the config read by the getters, merged from the config layers and decoded, which is valid
+// as long as all the files it was read from are unchanged.
+// It is safe for concurrent use, and returns copies so that callers may modify the nodes and configs.
+type fileNodeCache struct {
+	mutex        sync.Mutex
+	enabled      bool
+	entries      map[string]*fileNodeCacheEntry
+	clientConfig *clientConfigCacheEntry
+}
+
+type fileNodeCacheEntry struct {
+	info os.FileInfo
+	node *yaml.Node
+}
+
+// clientConfigCacheEntry is the merged config node along with the decoded config, and the versions of the
+// files they were read from. Entries are never modified once cached.
+type clientConfigCacheEntry struct {
+	files []fileVersion
+	node  *yaml.Node
+	cfg   *configtypes.ClientConfig
+}
+
+// fileVersion identifies the version of a file, info is nil if the file does not exist
+type fileVersion struct {
+	path string
+	info os.FileInfo
+}
+
+// readConfigFileNode returns the parsed config file, or nil if the file is missing, unreadable or empty
+func readConfigFileNode(path string) (*yaml.Node, error) {
+	return configFileCache.read(path)
+}
+
+// invalidateConfigFileNode drops the cached config file, to be called after writing the file
+func invalidateConfigFileNode(path string) {
+	configFileCache.invalidate(path)
+}
+
+// getCachedClientConfig returns the config read by the getters, merged from the config layers, along with the
+// decoded config. The entry is shared and must not be modified.
+func getCachedClientConfig() (*clientConfigCacheEntry, error) {
+	return configFileCache.readClientConfig(clientConfigFilePaths, func() (*yaml.Node, error) {
+		return getLayeredConfigNode(ConfigLayers)
+	})
+}
+
+// clientConfigFilePaths returns the paths of the files the config read by the getters depends on
+func clientConfigFilePaths() ([]string, error) {
+	cfgPath, err := ClientConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	cfgNextGenPath, err := ClientConfigNextGenPath()
+	if err != nil {
+		return nil, err
+	}
+	// The metadata config tells whether the unified config is used
+	metadataPath, err := CfgMetadataFilePath()
+	if err != nil {
+		return nil, err
+	}
+	projectPath, _, err := ProjectConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return []string{SystemConfigPath(), cfgPath, cfgNextGenPath, metadataPath, projectPath, PolicyConfigPath()}, nil
+}
+
+func (c *fileNodeCache) readClientConfig(paths func() ([]string, error), read func() (*yaml.Node, error)) (*clientConfigCacheEntry, error) {
+	filePaths, err := paths()
+	if err != nil {
+		return nil, err
+	}
+	files := statFiles(filePaths)
+	if entry := c.getClientConfig(files); entry != nil {
+		return entry, nil
+	}
+
+	node, err := read()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return nil, err
+	}
+	entry := &clientConfigCacheEntry{files: files, node: node, cfg: cfg}
+
+	// The files may have been written since they were checked, only cache the config if they are unchanged
+	if sameFileVersions(files, statFiles(filePaths)) {
+		c.putClientConfig(entry)
+	}
+	return entry, nil
+}
+
+func (c *fileNodeCache) getClientConfig(files []fileVersion) *clientConfigCacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.enabled || c.clientConfig == nil {
+		return nil
+	}
+	if !sameFileVersions(c.clientConfig.files, files) {
+		c.clientConfig = nil
+		return nil
+	}
+	return c.clientConfig
+}
+
+func (c *fileNodeCache) putClientConfig(entry *clientConfigCacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.enabled {
+		c.clientConfig = entry
+	}
+}
+
+func (c *fileNodeCache) read(path string) (*yaml.Node, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		c.invalidate(path)
+		return nil, nil
+	}
+	if node := c.get(path, info); node != nil {
+		return node, nil
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil || len(bytes) == 0 {
+		return nil, nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(bytes, &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+	nodeutils.ResetEmptyCollectionStyle(node.Content[0])
+	nodeutils.AttachFootCommentToDocument(&node)
+
+	// The file may have been written since it was checked, only cache it if it is unchanged
+	if latest, err := os.Stat(path); err == nil && sameFileVersion(info, latest) {
+		c.put(path, info, &node)
+	}
+	return nodeutils.CopyNode(&node), nil
+}
+
+func (c *fileNodeCache) get(path string, info os.FileInfo) *yaml.Node {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[path]
+	if !c.enabled || !ok {
+		return nil
+	}
+	if !sameFileVersion(entry.info, info) {
+		delete(c.entries, path)
+		return nil
+	}
+	return nodeutils.CopyNode(entry.node)
+}
+
+func (c *fileNodeCache) put(path string, info os.FileInfo, node *yaml.Node) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.enabled {
+		c.entries[path] = &fileNodeCacheEntry{info: info, node: node}
+	}
+}
+
+func (c *fileNodeCache) invalidate(path string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, path)
+	if c.clientConfig != nil {
+		for _, file := range c.clientConfig.files {
+			if file.path == path {
+				c.clientConfig = nil
+				break
+			}
+		}
+	}
+}
+
+// setEnabled turns the cache on or off, dropping all entries
+func (c *fileNodeCache) setEnabled(enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.enabled = enabled
+	c.entries = make(map[string]*fileNodeCacheEntry)
+	c.clientConfig = nil
+}
+
+func sameFileVersion(info1, info2 os.FileInfo) bool {
+	return info1.Size() == info2.Size() && info1.ModTime().Equal(info2.ModTime()) && os.SameFile(info1, info2)
+}
+
+// statFiles returns the versions of the files, skipping empty paths
+func statFiles(paths []string) []fileVersion {
+	files := make([]fileVersion, 0, len(paths))
+	for _, path := range paths {
+		file := fileVersion{path: path}
+		if path != "" {
+			if info, err := os.Stat(path); err == nil {
+				file.info = info
+			}
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+func sameFileVersions(files1, files2 []fileVersion) bool {
+	if len(files1) != len(files2) {
+		return false
+	}
+	for i := range files1 {
+		if files1[i].path != files2[i].path || (files1[i].info == nil) != (files2[i].info == nil) {
+			return false
+		}
+		if files1[i].info != nil && !sameFileVersion(files1[i].info, files2[i].info) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyClientConfig returns a deep copy of the config so that callers may modify it
+func copyClientConfig(cfg *configtypes.ClientConfig) *configtypes.ClientConfig {
+	return deepCopyValue(reflect.ValueOf(cfg)).Interface().(*configtypes.ClientConfig)
+}
+
+// deepCopyValue copies the pointers, slices, maps and exported struct fields of the value recursively.
+// Unexported struct fields, such as the ones of time.Time, are copied as is.
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopyValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopyValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopyValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestConfigFileCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigName)
+	cache := &fileNodeCache{enabled: true, entries: make(map[string]*fileNodeCacheEntry)}
+
+	node, err := cache.read(path)
+	assert.NoError(t, err)
+	assert.Nil(t, node)
+
+	require.NoError(t, os.WriteFile(path, []byte("clientOptions:\n  env:\n    FOO: bar\n"), 0644))
+	node, err = cache.read(path)
+	require.NoError(t, err)
+	require.Len(t, cache.entries, 1)
+
+	// Callers get copies that they may modify
+	node.Content[0].Content[1].Content[1].Content[1].Value = "modified"
+	node, err = cache.read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", node.Content[0].Content[1].Content[1].Content[1].Value)
+
+	// Entries of files changed by other processes are dropped
+	require.NoError(t, os.WriteFile(path, []byte("clientOptions:\n  env:\n    FOO: baz\n"), 0644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	node, err = cache.read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "baz", node.Content[0].Content[1].Content[1].Content[1].Value)
+
+	// Files replaced by renaming another file over them are detected even with the same size and time
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	replacement := path + ".tmp"
+	require.NoError(t, os.WriteFile(replacement, []byte("clientOptions:\n  env:\n    FOO: qux\n"), 0644))
+	require.NoError(t, os.Chtimes(replacement, info.ModTime(), info.ModTime()))
+	require.NoError(t, os.Rename(replacement, path))
+	node, err = cache.read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "qux", node.Content[0].Content[1].Content[1].Content[1].Value)
+
+	_, err = cache.read(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("clientOptions: [\n"), 0644))
+	_, err = cache.read(path)
+	assert.Error(t, err)
+	assert.Empty(t, cache.entries)
+}
+
+func TestConfigFileCacheInvalidatedOnWrite(t *testing.T) {
+	_, cleanUp := setupTestConfig(t, &CfgTestData{})
+	defer cleanUp()
+
+	// Writes that keep the file size and time are seen as the cache is dropped on local writes
+	for _, value := range []string{"a", "b", "c"} {
+		require.NoError(t, SetEnv("FOO", value))
+		env, err := GetEnv("FOO")
+		require.NoError(t, err)
+		assert.Equal(t, value, env)
+	}
+}
+
+func TestClientConfigCache(t *testing.T) {
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfgNextGen: setupBenchmarkConfigNextGen()})
+	defer cleanUp()
+	t.Setenv(EnvPolicyConfigKey, filepath.Join(t.TempDir(), PolicyConfigName))
+
+	cfg, err := GetClientConfig()
+	require.NoError(t, err)
+	entry := configFileCache.clientConfig
+	require.NotNil(t, entry)
+
+	// Callers get deep copies of the decoded config, which is not decoded again
+	cfg.ClientOptions.Env["FOO"] = "modified"
+	cfg.KnownContexts[0].ClusterOpts.Endpoint = "modified"
+	env, err := GetEnv("FOO")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", env)
+	ctx, err := GetContext("test-mc")
+	require.NoError(t, err)
+	assert.Equal(t, "test-endpoint", ctx.ClusterOpts.Endpoint)
+	assert.Same(t, entry, configFileCache.clientConfig)
+
+	// Changes of any of the config layers are seen
+	require.NoError(t, os.WriteFile(os.Getenv(EnvPolicyConfigKey), []byte("clientOptions:\n  env:\n    FOO: pinned\n"), 0644))
+	env, err = GetEnv("FOO")
+	require.NoError(t, err)
+	assert.Equal(t, "pinned", env)
+	assert.NotSame(t, entry, configFileCache.clientConfig)
+}
+
+func TestConfigFileCacheConcurrentAccess(t *testing.T) {
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfgNextGen: setupBenchmarkConfigNextGen()})
+	defer cleanUp()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ctx, err := GetCurrentContext(configtypes.TargetK8s)
+				assert.NoError(t, err)
+				assert.Equal(t, "test-mc", ctx.Name)
+				ctx.Name = "modified"
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func setupBenchmarkConfigNextGen() string {
+	return `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      isManagementCluster: true
+      endpoint: test-endpoint
+      path: test-path
+      context: test-context
+    discoverySources:
+      - oci:
+          name: test
+          image: test-image
+  - name: test-tmc
+    target: mission-control
+    globalOpts:
+      endpoint: test-tmc-endpoint
+currentContext:
+  kubernetes: test-mc
+  mission-control: test-tmc
+clientOptions:
+  env:
+    FOO: bar
+  features:
+    global:
+      context-target-v2: "true"
+cli:
+  ceipOptIn: "true"
+  discoverySources:
+    - oci:
+        name: default
+        image: test-image
+`
+}
+
+// benchmarkGetters runs the getters a plugin typically calls in one command
+func benchmarkGetters(b *testing.B, cacheEnabled bool) {
+	files, cleanUp := setupTestConfig(b, &CfgTestData{cfgNextGen: setupBenchmarkConfigNextGen()})
+	defer cleanUp()
+	for _, file := range files {
+		file.Close()
+	}
+	configFileCache.setEnabled(cacheEnabled)
+	defer configFileCache.setEnabled(true)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetCurrentContext(configtypes.TargetK8s); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := GetEnv("FOO"); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := IsFeatureEnabled("global", "context-target-v2"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGettersWithCache(b *testing.B) {
+	benchmarkGetters(b, true)
+}
+
+func BenchmarkGettersWithoutCache(b *testing.B) {
+	benchmarkGetters(b, false)
+}
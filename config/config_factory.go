@@ -6,12 +6,14 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
 	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/collectionutils"
 	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
 )
 
 // LegacyConfigNodeKeys config nodes that goes to config.yaml
@@ -85,8 +87,22 @@ func makeMultiFileCfg(cfgNode, cfgNextGenNode *yaml.Node) (*yaml.Node, error) {
 	return rootCfgNode, nil
 }
 
-// persistConfig write the updated node data to config.yaml and config-ng.yaml based on cfgItems
-func persistConfig(node *yaml.Node) error {
+// persistConfig write the updated node data to config.yaml and config-ng.yaml based on cfgItems.
+// The changes written by others since the config was read under the lock are merged into the node.
+// The changes are recorded in the audit entry of the config API, which should persist them at once.
+func persistConfig(node *yaml.Node, api string) (err error) {
+	// Record the changes in the audit trail once persisted
+	oldNode, _ := getClientConfigNodeNoLock()
+	configSnapshot.merge(node, oldNode)
+	defer func() {
+		if err == nil {
+			configSnapshot.reset(node)
+			if oldNode != nil {
+				recordAudit(api, oldNode, node)
+			}
+		}
+	}()
+
 	// check to persist multi file or to config-ng yaml
 	useUnifiedConfig, err := UseUnifiedConfig()
 	if err != nil {
@@ -172,6 +188,43 @@ func persistConfig(node *yaml.Node) error {
 	return nil
 }
 
+// nodeSnapshot is the node read by the holder of a lock. Processes that do not take the lock, e.g. older versions
+// or editors, may write the file while the lock is held, so their changes are merged into the node persisted by
+// the holder of the lock instead of being overwritten.
+type nodeSnapshot struct {
+	node *yaml.Node
+}
+
+// record keeps the first node read while the lock is held. It is a no-op if the lock is not held.
+func (s *nodeSnapshot) record(node *yaml.Node) {
+	if s != nil && s.node == nil && node != nil {
+		s.node = nodeutils.CopyNode(node)
+	}
+}
+
+// reset keeps the node persisted while the lock is held, so that the following writes merge against it
+func (s *nodeSnapshot) reset(node *yaml.Node) {
+	if s != nil {
+		s.node = nodeutils.CopyNode(node)
+	}
+}
+
+// merge merges the changes made by others between the snapshot and the current node into the node to persist.
+// Conflicting changes are resolved to the node to persist.
+func (s *nodeSnapshot) merge(node, current *yaml.Node) {
+	if s == nil || s.node == nil || current == nil || len(nodeutils.Diff(s.node, current)) == 0 {
+		return
+	}
+	merged, conflicts := nodeutils.ThreeWayMerge(s.node, node, current)
+	if merged == nil {
+		return
+	}
+	for _, conflict := range conflicts {
+		log.Warningf("%s was changed concurrently, overwriting it", conflict.Path)
+	}
+	*node = *merged
+}
+
 // persistNode stores/writes the yaml node to config path specified in CfgOpts
 func persistNode(node *yaml.Node, opts ...CfgOpts) error {
 	configurations := &CfgOptions{}
@@ -183,10 +236,8 @@ func persistNode(node *yaml.Node, opts ...CfgOpts) error {
 		return errors.Wrap(err, "failed to check config path existence")
 	}
 	if !cfgPathExists {
-		localDir, err := LocalDir()
-		if err != nil {
-			return errors.Wrap(err, "could not find local tanzu dir for OS")
-		}
+		// The config path may be under a profile directory of the local tanzu dir
+		localDir := filepath.Dir(configurations.CfgPath)
 		if err := os.MkdirAll(localDir, 0755); err != nil {
 			return errors.Wrap(err, "could not make local tanzu directory")
 		}
@@ -196,6 +247,8 @@ func persistNode(node *yaml.Node, opts ...CfgOpts) error {
 		return errors.Wrap(err, "failed to marshal nodeutils")
 	}
 	err = os.WriteFile(configurations.CfgPath, data, 0644)
+	// Drop the cached config even if the write failed, since the file may have been partially written
+	invalidateConfigFileNode(configurations.CfgPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to write the config to file")
 	}
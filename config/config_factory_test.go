@@ -4,7 +4,9 @@
 package config
 
 import (
+	"flag"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -239,7 +241,7 @@ func TestGetClientConfigWithLockAndWithoutLock(t *testing.T) {
 	}()
 
 	//Actions
-	nodeWithLock, err := getClientConfigNode()
+	nodeWithLock, err := getUserConfigNode()
 	assert.NoError(t, err)
 	//Actions
 	nodeWithoutLocK, err := getClientConfigNodeNoLock()
@@ -278,7 +280,9 @@ func TestGetClientConfigWithLockAndWithoutLock(t *testing.T) {
 			},
 		}
 
-		ctx, err := getContext(node, "test-mc")
+		clientConfig, err := convertNodeToClientConfig(node)
+		assert.NoError(t, err)
+		ctx, err := getContext(clientConfig, "test-mc")
 		assert.NoError(t, err)
 		assert.Equal(t, expectedCtx, ctx)
 
@@ -301,7 +305,7 @@ func TestGetClientConfigWithLockAndWithoutLock(t *testing.T) {
 			},
 		}
 
-		server, err := getServer(node, "test-mc")
+		server, err := getServer(clientConfig, "test-mc")
 		assert.NoError(t, err)
 		assert.Equal(t, expectedServer, server)
 	}
@@ -317,7 +321,7 @@ func TestGetClientConfigWithLockAndMigratedToNewConfig(t *testing.T) {
 	}()
 
 	//Actions
-	node, err := getClientConfigNode()
+	node, err := getUserConfigNode()
 
 	// Assertions
 	assert.NotNil(t, node)
@@ -351,11 +355,13 @@ func TestGetClientConfigWithLockAndMigratedToNewConfig(t *testing.T) {
 	}
 
 	// Migrated To new config hence servers are not in the config-ng.yaml yet.
-	ctx, err := getContext(node, "test-mc")
+	clientConfig, err := convertNodeToClientConfig(node)
+	assert.NoError(t, err)
+	ctx, err := getContext(clientConfig, "test-mc")
 	assert.NoError(t, err)
 	assert.Equal(t, expectedCtx, ctx)
 
-	_, err = getServer(node, "test-mc")
+	_, err = getServer(clientConfig, "test-mc")
 	assert.Equal(t, "could not find server \"test-mc\"", err.Error())
 }
 
@@ -405,11 +411,13 @@ func TestGetClientConfigWithoutLockAndMigratedToNewConfig(t *testing.T) {
 	}
 
 	// Migrated To new config hence servers are not in the config-ng.yaml yet.
-	ctx, err := getContext(node, "test-mc")
+	clientConfig, err := convertNodeToClientConfig(node)
+	assert.NoError(t, err)
+	ctx, err := getContext(clientConfig, "test-mc")
 	assert.NoError(t, err)
 	assert.Equal(t, expectedCtx, ctx)
 
-	_, err = getServer(node, "test-mc")
+	_, err = getServer(clientConfig, "test-mc")
 	assert.Equal(t, "could not find server \"test-mc\"", err.Error())
 }
 
@@ -423,11 +431,11 @@ func TestPersistConfig(t *testing.T) {
 	}()
 
 	// Actions
-	node, err := getClientConfigNode()
+	node, err := getUserConfigNode()
 	assert.NotNil(t, node)
 	assert.NoError(t, err)
 
-	err = persistConfig(node)
+	err = persistConfig(node, "test")
 	assert.NoError(t, err)
 
 	cfgFileData, err := os.ReadFile(cfgTestFiles[0].Name())
@@ -439,6 +447,50 @@ func TestPersistConfig(t *testing.T) {
 	assert.Equal(t, expectedCfgNextGen, string(cfgNextGenFileData))
 }
 
+func TestPersistConfigMergesConcurrentChanges(t *testing.T) {
+	_, cleanUp := setupTestConfig(t, &CfgTestData{})
+	defer cleanUp()
+
+	newContext := func(name, endpoint string) *configtypes.Context {
+		return &configtypes.Context{Name: name, Target: configtypes.TargetTMC, GlobalOpts: &configtypes.GlobalServer{Endpoint: endpoint}}
+	}
+	assert.NoError(t, SetContext(newContext("ctx-a", "endpoint-a"), false))
+
+	// Prepare the config written by a process that does not take the lock, which adds a context and changes another
+	assert.NoError(t, SetContext(newContext("ctx-c", "endpoint-c"), false))
+	assert.NoError(t, SetContext(newContext("ctx-a", "endpoint-a2"), false))
+	cfgNextGenPath, err := ClientConfigNextGenPath()
+	assert.NoError(t, err)
+	concurrentCfgNextGen, err := os.ReadFile(cfgNextGenPath)
+	assert.NoError(t, err)
+	assert.NoError(t, RemoveContext("ctx-c"))
+	assert.NoError(t, SetContext(newContext("ctx-a", "endpoint-a"), false))
+
+	// The config is written by the other process while a context is added under the lock
+	assert.NoError(t, acquireTanzuConfigLock())
+	node, err := getClientConfigNodeNoLock()
+	assert.NoError(t, err)
+	_, err = setContext(node, newContext("ctx-b", "endpoint-b"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(cfgNextGenPath, concurrentCfgNextGen, 0644))
+	err = persistConfig(node, "SetContext")
+	ReleaseTanzuConfigLock()
+	assert.NoError(t, err)
+
+	for name, endpoint := range map[string]string{"ctx-a": "endpoint-a2", "ctx-b": "endpoint-b", "ctx-c": "endpoint-c"} {
+		ctx, err := GetContext(name)
+		assert.NoError(t, err)
+		if assert.NotNil(t, ctx) {
+			assert.Equal(t, endpoint, ctx.GlobalOpts.Endpoint)
+		}
+	}
+
+	// Without concurrent changes, removals are not reverted by the merge
+	assert.NoError(t, RemoveContext("ctx-c"))
+	_, err = GetContext("ctx-c")
+	assert.Error(t, err)
+}
+
 func TestPersistConfigWithMigrateToNewConfig(t *testing.T) {
 	// Setup data
 	cfg, cfgNextGen, _, _ := setupCfgAndCfgNextGenData()
@@ -481,11 +533,11 @@ currentContext:
 	}()
 
 	// Actions
-	node, err := getClientConfigNode()
+	node, err := getUserConfigNode()
 	assert.NotNil(t, node)
 	assert.NoError(t, err)
 
-	err = persistConfig(node)
+	err = persistConfig(node, "test")
 	assert.NoError(t, err)
 
 	cfgFileData, err := os.ReadFile(cfgTestFiles[0].Name())
@@ -1219,3 +1271,65 @@ current:
 		})
 	}
 }
+
+// updateGolden regenerates the golden files of the round trip tests with: go test ./config -run RoundTrip -update
+var updateGolden = flag.Bool("update", false, "update the golden files of the config round trip tests")
+
+func TestConfigRoundTripPreservesFormatting(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "roundtrip", "config-ng.yaml"))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		cfgMetadata string
+		golden      string
+	}{
+		{
+			name:   "multi file config",
+			golden: "config-ng.multi-file.golden.yaml",
+		},
+		{
+			name:        "unified config",
+			cfgMetadata: setupConfigMetadataWithMigrateToNewConfig(),
+			golden:      "config-ng.unified.golden.yaml",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			files, cleanUp := setupTestConfig(t, &CfgTestData{cfgNextGen: string(input), cfgMetadata: tc.cfgMetadata})
+			defer cleanUp()
+
+			// Update existing nodes in place
+			ctx, err := GetContext("test-mc")
+			assert.NoError(t, err)
+			ctx.ClusterOpts.Endpoint = "https://test-mc.example.com"
+			assert.NoError(t, SetContext(ctx, false))
+
+			ctx, err = GetContext("test-tmc")
+			assert.NoError(t, err)
+			ctx.GlobalOpts.Endpoint = "tmc-updated.example.com"
+			assert.NoError(t, SetContext(ctx, false))
+
+			// Add new nodes
+			assert.NoError(t, SetContext(&configtypes.Context{Name: "test-mc2", Target: configtypes.TargetK8s}, true))
+			assert.NoError(t, SetFeature("global", "test-feature", "true"))
+
+			actual, err := os.ReadFile(files[1].Name())
+			assert.NoError(t, err)
+
+			goldenFile := filepath.Join("testdata", "roundtrip", tc.golden)
+			if *updateGolden {
+				assert.NoError(t, os.WriteFile(goldenFile, actual, 0644))
+			}
+			expected, err := os.ReadFile(goldenFile)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+
+			// Persisting the config again is stable
+			assert.NoError(t, SetFeature("global", "test-feature", "true"))
+			actual, err = os.ReadFile(files[1].Name())
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+		})
+	}
+}
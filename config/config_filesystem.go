@@ -6,8 +6,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
 )
 
 const (
@@ -18,6 +21,22 @@ const (
 	//nolint:gosec // Avoid "hardcoded credentials" false positive.
 	// EnvAPITokenKey is the environment variable that overrides the tanzu API token for global auth.
 	EnvAPITokenKey = "TANZU_API_TOKEN"
+
+	// EnvConfigDirKey is the environment variable that overrides the root directory of all tanzu state.
+	// Config files are stored in the directory itself, caches and state in its cache and state subdirectories.
+	EnvConfigDirKey = "TANZU_CONFIG_DIR"
+
+	// EnvConfigUseXDGKey is the environment variable that opts in storing tanzu state under the XDG base directories.
+	// CLIs and plugins built with older runtimes always use the default directories, so the XDG base directories
+	// are only used when opted in.
+	EnvConfigUseXDGKey = "TANZU_CONFIG_USE_XDG"
+
+	// EnvXDGConfigHomeKey is the environment variable of the XDG base directory for config files
+	EnvXDGConfigHomeKey = "XDG_CONFIG_HOME"
+	// EnvXDGCacheHomeKey is the environment variable of the XDG base directory for caches
+	EnvXDGCacheHomeKey = "XDG_CACHE_HOME"
+	// EnvXDGStateHomeKey is the environment variable of the XDG base directory for state e.g. logs
+	EnvXDGStateHomeKey = "XDG_STATE_HOME"
 )
 
 var (
@@ -25,11 +44,112 @@ var (
 	LocalDirName = ".config/tanzu"
 	// TestLocalDirName is the name of the local directory in which tanzu state is stored for testing.
 	TestLocalDirName = ".tanzu-test"
+	// LocalCacheDirName is the name of the local directory in which tanzu caches are stored.
+	LocalCacheDirName = ".cache/tanzu"
+	// LocalStateDirName is the name of the local directory in which tanzu state such as logs is stored.
+	LocalStateDirName = ".local/state/tanzu"
 )
 
-// LocalDir returns the local directory in which tanzu state is stored.
+const (
+	// tanzuDirName is the name of the tanzu directory under the XDG base directories
+	tanzuDirName = "tanzu"
+	// cacheDirName and stateDirName are the subdirectories of TANZU_CONFIG_DIR for caches and state
+	cacheDirName = "cache"
+	stateDirName = "state"
+)
+
+// LocalDir returns the local directory in which tanzu config files are stored.
+// It is TANZU_CONFIG_DIR if set, else $XDG_CONFIG_HOME/tanzu if set and opted in with TANZU_CONFIG_USE_XDG,
+// else $HOME/.config/tanzu
 func LocalDir() (path string, err error) {
-	return localDirPath(LocalDirName)
+	if dir := os.Getenv(EnvConfigDirKey); dir != "" {
+		return dir, nil
+	}
+	return xdgDirPath(EnvXDGConfigHomeKey, LocalDirName)
+}
+
+// LocalCacheDir returns the local directory in which tanzu caches are stored.
+// It is TANZU_CONFIG_DIR/cache if set, else $XDG_CACHE_HOME/tanzu if set and opted in with TANZU_CONFIG_USE_XDG,
+// else $HOME/.cache/tanzu
+func LocalCacheDir() (path string, err error) {
+	if dir := os.Getenv(EnvConfigDirKey); dir != "" {
+		return filepath.Join(dir, cacheDirName), nil
+	}
+	return xdgDirPath(EnvXDGCacheHomeKey, LocalCacheDirName)
+}
+
+// LocalStateDir returns the local directory in which tanzu state such as logs is stored.
+// It is TANZU_CONFIG_DIR/state if set, else $XDG_STATE_HOME/tanzu if set and opted in with TANZU_CONFIG_USE_XDG,
+// else $HOME/.local/state/tanzu
+func LocalStateDir() (path string, err error) {
+	if dir := os.Getenv(EnvConfigDirKey); dir != "" {
+		return filepath.Join(dir, stateDirName), nil
+	}
+	return xdgDirPath(EnvXDGStateHomeKey, LocalStateDirName)
+}
+
+// xdgDirPath returns the tanzu directory under the XDG base directory set in the environment variable if the XDG
+// base directories are opted in, or the directory name under the home directory. Relative XDG base directories
+// are ignored as per the spec.
+func xdgDirPath(xdgEnvKey, dirname string) (path string, err error) {
+	if useXDG, _ := strconv.ParseBool(os.Getenv(EnvConfigUseXDGKey)); !useXDG {
+		return localDirPath(dirname)
+	}
+	if base := os.Getenv(xdgEnvKey); base != "" && filepath.IsAbs(base) {
+		return filepath.Join(base, tanzuDirName), nil
+	}
+	return localDirPath(dirname)
+}
+
+// MigrateLocalDir moves the config files from the default $HOME/.config/tanzu directory when TANZU_CONFIG_DIR or
+// the opted in XDG_CONFIG_HOME point to another directory, so that a single copy of the config remains.
+// This is a no-op if the default directory does not exist or if the configured directory already holds config
+// files. It is an explicit step of the CLI, plugins never migrate the directory.
+func MigrateLocalDir() error {
+	defaultDir, err := localDirPath(LocalDirName)
+	if err != nil {
+		return err
+	}
+	localDir, err := LocalDir()
+	if err != nil {
+		return err
+	}
+	if filepath.Clean(defaultDir) == filepath.Clean(localDir) {
+		return nil
+	}
+	defaultDirExists, err := fileExists(defaultDir)
+	if err != nil || !defaultDirExists {
+		return err
+	}
+	if configured, err := hasConfigFiles(localDir); err != nil || configured {
+		return err
+	}
+
+	// Concurrent invocations must not read the config files while they are copied
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuConfigLock()
+	if configured, err := hasConfigFiles(localDir); err != nil || configured {
+		return err
+	}
+	// The lock files may already exist in the configured directory, existing files are left untouched
+	if err := moveMissingFiles(defaultDir, localDir); err != nil {
+		return errors.Wrapf(err, "failed to migrate configuration from %s to %s", defaultDir, localDir)
+	}
+	log.Infof("Configuration has been moved from %s to %s, the new location set by the environment.", defaultDir, localDir)
+	return nil
+}
+
+// hasConfigFiles returns true if any of the config files exists in the directory
+func hasConfigFiles(dir string) (bool, error) {
+	for _, name := range profileFileNames {
+		exists, err := fileExists(filepath.Join(dir, name))
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
 }
 
 // localDirPath returns the full path of the directory name in which tanzu state is stored.
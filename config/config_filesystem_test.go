@@ -0,0 +1,126 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalDirs(t *testing.T) {
+	home, err := os.UserHomeDir()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		envs      map[string]string
+		configDir string
+		cacheDir  string
+		stateDir  string
+	}{
+		{
+			name:      "defaults",
+			configDir: filepath.Join(home, LocalDirName),
+			cacheDir:  filepath.Join(home, ".cache", "tanzu"),
+			stateDir:  filepath.Join(home, ".local", "state", "tanzu"),
+		},
+		{
+			name: "xdg base directories are ignored unless opted in",
+			envs: map[string]string{
+				EnvXDGConfigHomeKey: "/xdg/config",
+				EnvXDGCacheHomeKey:  "/xdg/cache",
+				EnvXDGStateHomeKey:  "/xdg/state",
+			},
+			configDir: filepath.Join(home, LocalDirName),
+			cacheDir:  filepath.Join(home, ".cache", "tanzu"),
+			stateDir:  filepath.Join(home, ".local", "state", "tanzu"),
+		},
+		{
+			name: "xdg base directories",
+			envs: map[string]string{
+				EnvConfigUseXDGKey:  "true",
+				EnvXDGConfigHomeKey: "/xdg/config",
+				EnvXDGCacheHomeKey:  "/xdg/cache",
+				EnvXDGStateHomeKey:  "/xdg/state",
+			},
+			configDir: "/xdg/config/tanzu",
+			cacheDir:  "/xdg/cache/tanzu",
+			stateDir:  "/xdg/state/tanzu",
+		},
+		{
+			name: "relative xdg base directories are ignored",
+			envs: map[string]string{
+				EnvConfigUseXDGKey:  "true",
+				EnvXDGConfigHomeKey: "xdg/config",
+			},
+			configDir: filepath.Join(home, LocalDirName),
+			cacheDir:  filepath.Join(home, ".cache", "tanzu"),
+			stateDir:  filepath.Join(home, ".local", "state", "tanzu"),
+		},
+		{
+			name: "tanzu config dir overrides xdg base directories",
+			envs: map[string]string{
+				EnvConfigDirKey:     "/tanzu",
+				EnvConfigUseXDGKey:  "true",
+				EnvXDGConfigHomeKey: "/xdg/config",
+				EnvXDGCacheHomeKey:  "/xdg/cache",
+			},
+			configDir: "/tanzu",
+			cacheDir:  "/tanzu/cache",
+			stateDir:  "/tanzu/state",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{EnvConfigDirKey, EnvConfigUseXDGKey, EnvXDGConfigHomeKey, EnvXDGCacheHomeKey, EnvXDGStateHomeKey} {
+				t.Setenv(key, tc.envs[key])
+			}
+			configDir, err := LocalDir()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.configDir, configDir)
+			cacheDir, err := LocalCacheDir()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.cacheDir, cacheDir)
+			stateDir, err := LocalStateDir()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.stateDir, stateDir)
+		})
+	}
+}
+
+func TestMigrateLocalDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvXDGConfigHomeKey, "")
+	defaultDir := filepath.Join(home, LocalDirName)
+	assert.NoError(t, os.MkdirAll(defaultDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(defaultDir, CfgNextGenName), []byte("contexts: []\n"), 0644))
+
+	// No-op when the config dir is the default dir
+	t.Setenv(EnvConfigDirKey, "")
+	assert.NoError(t, MigrateLocalDir())
+
+	// The config dir may only hold lock files
+	configDir := filepath.Join(t.TempDir(), "tanzu")
+	t.Setenv(EnvConfigDirKey, configDir)
+	assert.NoError(t, os.MkdirAll(configDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(configDir, LocalTanzuFileLock), nil, 0644))
+	assert.NoError(t, MigrateLocalDir())
+	data, err := os.ReadFile(filepath.Join(configDir, CfgNextGenName))
+	assert.NoError(t, err)
+	assert.Equal(t, "contexts: []\n", string(data))
+	// The config files are moved, not copied
+	assert.NoFileExists(t, filepath.Join(defaultDir, CfgNextGenName))
+
+	// Existing config dirs are left untouched
+	assert.NoError(t, os.MkdirAll(defaultDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(defaultDir, CfgNextGenName), []byte("contexts: [{name: test}]\n"), 0644))
+	assert.NoError(t, MigrateLocalDir())
+	data, err = os.ReadFile(filepath.Join(configDir, CfgNextGenName))
+	assert.NoError(t, err)
+	assert.Equal(t, "contexts: []\n", string(data))
+}
@@ -16,12 +16,12 @@ import (
 
 // GetContext retrieves the context by name
 func GetContext(name string) (*configtypes.Context, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	return getContext(node, name)
+	return getContext(cfg, name)
 }
 
 // AddContext add or update context and currentContext
@@ -33,8 +33,15 @@ func AddContext(c *configtypes.Context, setCurrent bool) error {
 //
 //nolint:gocyclo
 func SetContext(c *configtypes.Context, setCurrent bool) error {
+	if c != nil {
+		if err := checkPolicyLocked(contextPolicyPath(c.Name), serverPolicyPath(c.Name)); err != nil {
+			return err
+		}
+	}
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -45,55 +52,38 @@ func SetContext(c *configtypes.Context, setCurrent bool) error {
 	if err != nil {
 		return err
 	}
-	if persist {
-		err = persistConfig(node)
-		if err != nil {
-			return err
-		}
-	}
 	// Set current context
 	if setCurrent {
-		persist, err = setCurrentContext(node, c)
+		persistCurrent, err := setCurrentContext(node, c)
 		if err != nil {
 			return err
 		}
-		if persist {
-			err = persistConfig(node)
-			if err != nil {
-				return err
-			}
-		}
+		persist = persist || persistCurrent
 	}
 
 	// Back-fill servers based on contexts
 	s := convertContextToServer(c)
 
 	// Add or update server
-	persist, err = setServer(node, s)
+	persistServer, err := setServer(node, s)
 	if err != nil {
 		return err
 	}
-	if persist {
-		err = persistConfig(node)
-		if err != nil {
-			return err
-		}
-	}
+	persist = persist || persistServer
 
 	// Set current server
 	if setCurrent && s.Type == configtypes.ManagementClusterServerType { //nolint:staticcheck
-		persist, err = setCurrentServer(node, s.Name)
+		persistCurrentServer, err := setCurrentServer(node, s.Name)
 		if err != nil {
 			return err
 		}
-		if persist {
-			err = persistConfig(node)
-			if err != nil {
-				return err
-			}
-		}
+		persist = persist || persistCurrentServer
 	}
-	return err
+	// Persist the context and the server at once so that the call is recorded in one audit entry
+	if persist {
+		return persistConfig(node, "SetContext")
+	}
+	return nil
 }
 
 // DeleteContext delete a context by name
@@ -103,14 +93,30 @@ func DeleteContext(name string) error {
 
 // RemoveContext delete a context by name
 func RemoveContext(name string) error {
+	if err := checkPolicyLocked(contextPolicyPath(name), serverPolicyPath(name)); err != nil {
+		return err
+	}
+	// Contexts set by the other config layers would still be returned by the getters
+	if layer, err := contextConfigLayer(name); err != nil || layer != "" {
+		if err == nil {
+			err = errors.Errorf("context %v is set by the %s config and cannot be removed", name, layer)
+		}
+		return err
+	}
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
-	ctx, err := getContext(node, name)
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return err
+	}
+	ctx, err := getContext(cfg, name)
 	if err != nil {
 		return err
 	}
@@ -130,7 +136,7 @@ func RemoveContext(name string) error {
 	if err != nil {
 		return err
 	}
-	return persistConfig(node)
+	return persistConfig(node, "RemoveContext")
 }
 
 // ContextExists checks if context by name already exists
@@ -141,21 +147,22 @@ func ContextExists(name string) (bool, error) {
 
 // GetCurrentContext retrieves the current context for the specified target
 func GetCurrentContext(target configtypes.Target) (c *configtypes.Context, err error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	return getCurrentContext(node, target)
+	return getCurrentContext(cfg, target)
 }
 
 // GetAllCurrentContextsMap returns all current context per Target
 func GetAllCurrentContextsMap() (map[configtypes.Target]*configtypes.Context, error) {
-	node, err := getClientConfigNodeNoLock()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	return getAllCurrentContextsMap(node)
+	return getAllCurrentContextsMap(cfg)
 }
 
 // GetAllCurrentContextsList returns all current context names as list
@@ -174,52 +181,56 @@ func GetAllCurrentContextsList() ([]string, error) {
 // SetCurrentContext sets the current context to the specified name if context is present
 func SetCurrentContext(name string) error {
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
 
-	ctx, err := getContext(node, name)
+	cfg, err := convertNodeToClientConfig(node)
 	if err != nil {
 		return err
 	}
-	persist, err := setCurrentContext(node, ctx)
+	ctx, err := getContext(cfg, name)
 	if err != nil {
 		return err
 	}
-	if persist {
-		err = persistConfig(node)
-		if err != nil {
-			return err
-		}
+	persist, err := setCurrentContext(node, ctx)
+	if err != nil {
+		return err
 	}
 	if ctx.Target == configtypes.TargetK8s {
-		persist, err = setCurrentServer(node, name)
+		persistServer, err := setCurrentServer(node, name)
 		if err != nil {
 			return err
 		}
-		if persist {
-			err = persistConfig(node)
-			if err != nil {
-				return err
-			}
-		}
+		persist = persist || persistServer
 	}
-	return err
+	if persist {
+		return persistConfig(node, "SetCurrentContext")
+	}
+	return nil
 }
 
 // RemoveCurrentContext removed the current context of specified context type
 func RemoveCurrentContext(target configtypes.Target) error {
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
-	c, err := getCurrentContext(node, target)
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return err
+	}
+	c, err := getCurrentContext(cfg, target)
 	if err != nil {
 		return err
 	}
@@ -231,7 +242,7 @@ func RemoveCurrentContext(target configtypes.Target) error {
 	if err != nil {
 		return err
 	}
-	return persistConfig(node)
+	return persistConfig(node, "RemoveCurrentContext")
 }
 
 // EndpointFromContext retrieved the endpoint from the specified context
@@ -246,16 +257,37 @@ func EndpointFromContext(s *configtypes.Context) (endpoint string, err error) {
 	}
 }
 
-func getContext(node *yaml.Node, name string) (*configtypes.Context, error) {
+// contextConfigLayer returns the config layer other than the user layer that sets the context, or an empty layer if
+// the context is only set by the user layer
+func contextConfigLayer(name string) (ConfigLayer, error) {
+	for _, layer := range ConfigLayers {
+		if layer == ConfigLayerUser {
+			continue
+		}
+		node, err := getConfigLayerNode(layer)
+		if err != nil {
+			return "", err
+		}
+		if node == nil {
+			continue
+		}
+		cfg, err := convertNodeToClientConfig(node)
+		if err != nil {
+			return "", err
+		}
+		if ctx, _ := getContext(cfg, name); ctx != nil {
+			return layer, nil
+		}
+	}
+	return "", nil
+}
+
+func getContext(cfg *configtypes.ClientConfig, name string) (*configtypes.Context, error) {
 	// check if context name is empty
 	if name == "" {
 		return nil, errors.New("context name cannot be empty")
 	}
 
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
 	for _, ctx := range cfg.KnownContexts {
 		if ctx.Name == name {
 			return ctx, nil
@@ -264,19 +296,11 @@ func getContext(node *yaml.Node, name string) (*configtypes.Context, error) {
 	return nil, fmt.Errorf("context %v not found", name)
 }
 
-func getCurrentContext(node *yaml.Node, target configtypes.Target) (*configtypes.Context, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getCurrentContext(cfg *configtypes.ClientConfig, target configtypes.Target) (*configtypes.Context, error) {
 	return cfg.GetCurrentContext(target)
 }
 
-func getAllCurrentContextsMap(node *yaml.Node) (map[configtypes.Target]*configtypes.Context, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getAllCurrentContextsMap(cfg *configtypes.ClientConfig) (map[configtypes.Target]*configtypes.Context, error) {
 	return cfg.GetAllCurrentContextsMap()
 }
 
@@ -297,10 +321,7 @@ func setContext(node *yaml.Node, ctx *configtypes.Context) (persist bool, err er
 	}
 
 	// Get Patch Strategies from config metadata
-	patchStrategies, err := GetConfigMetadataPatchStrategy()
-	if err != nil {
-		patchStrategies = make(map[string]string)
-	}
+	patchStrategies := getPatchStrategies()
 
 	var persistDiscoverySources bool
 
@@ -319,44 +340,29 @@ func setContext(node *yaml.Node, ctx *configtypes.Context) (persist bool, err er
 		return persist, err
 	}
 
-	exists := false
-	var result []*yaml.Node
-	// Skip duplicate for context and server similar logic
-	//nolint:dupl
-	for _, contextNode := range contextsNode.Content {
-		if index := nodeutils.GetNodeIndex(contextNode.Content, "name"); index != -1 &&
-			contextNode.Content[index].Value == ctx.Name {
-			exists = true
-			// replace the nodes as per patch strategy
-			_, err = nodeutils.DeleteNodes(newContextNode.Content[0], contextNode, nodeutils.WithPatchStrategyKey(KeyContexts), nodeutils.WithPatchStrategies(patchStrategies))
-			if err != nil {
-				return false, err
-			}
-			persist, err = nodeutils.MergeNodes(newContextNode.Content[0], contextNode)
-			if err != nil {
-				return false, err
-			}
-			persistDiscoverySources, err = setDiscoverySources(contextNode, ctx.DiscoverySources, nodeutils.WithPatchStrategyKey(fmt.Sprintf("%v.%v", KeyContexts, KeyDiscoverySources)), nodeutils.WithPatchStrategies(patchStrategies))
-			if err != nil {
-				return false, err
-			}
-			// merge the discovery sources to context
-			if persistDiscoverySources {
-				_, err = nodeutils.MergeNodes(newContextNode.Content[0], contextNode)
-				if err != nil {
-					return false, err
-				}
-			}
-			result = append(result, contextNode)
-			continue
-		}
-		result = append(result, contextNode)
+	// Upsert the context by name, replacing nodes as per patch strategy
+	contextNode := nodeutils.FindSequenceItem(contextsNode, ctx.Name, nodeutils.DefaultSequenceKeys[KeyContexts])
+	newContextsNode := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{newContextNode.Content[0]}}
+	patchStrategyOpts := sequencePatchStrategyOpts(KeyContexts, patchStrategies)
+	if _, err = nodeutils.DeleteNodes(newContextsNode, contextsNode, patchStrategyOpts...); err != nil {
+		return false, err
 	}
-	if !exists {
-		result = append(result, newContextNode.Content[0])
-		persist = true
+	persist, err = nodeutils.MergeNodes(newContextsNode, contextsNode, patchStrategyOpts...)
+	if err != nil || contextNode == nil {
+		return persist, err
+	}
+
+	// add or update discovery sources of the existing context
+	persistDiscoverySources, err = setDiscoverySources(contextNode, ctx.DiscoverySources, nodeutils.WithPatchStrategyKey(fmt.Sprintf("%v.%v", KeyContexts, KeyDiscoverySources)), nodeutils.WithPatchStrategies(patchStrategies))
+	if err != nil {
+		return false, err
+	}
+	if persistDiscoverySources {
+		_, err = nodeutils.MergeNodes(newContextNode.Content[0], contextNode)
+		if err != nil {
+			return false, err
+		}
 	}
-	contextsNode.Content = result
 	return persistDiscoverySources || persist, err
 }
 
@@ -372,7 +378,6 @@ func setCurrentContext(node *yaml.Node, ctx *configtypes.Context) (persist bool,
 	if index := nodeutils.GetNodeIndex(currentContextNode.Content, string(ctx.Target)); index != -1 {
 		if currentContextNode.Content[index].Value != ctx.Name {
 			currentContextNode.Content[index].Value = ctx.Name
-			currentContextNode.Content[index].Style = 0
 			persist = true
 		}
 	} else {
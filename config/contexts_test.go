@@ -553,7 +553,7 @@ func TestSetContext(t *testing.T) {
 				},
 			},
 		}
-		err := persistConfig(node)
+		err := persistConfig(node, "test")
 		assert.NoError(t, err)
 	}()
 	defer func() {
@@ -177,6 +177,7 @@ func convertNodeToMetadata(node *yaml.Node) (obj *configtypes.Metadata, err erro
 func convertObjectToNode[
 	T *configtypes.ClientConfig |
 		*configtypes.Metadata |
+		*configtypes.DefaultFeatureFlagsRecord |
 		*configtypes.Server |
 		*configtypes.PluginRepository |
 		*configtypes.Context |
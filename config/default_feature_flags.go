@@ -0,0 +1,180 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+// ApplyDefaultFeatureFlags configures the default feature flags of the specified plugin version that are
+// missing from the config and records which defaults were applied by which plugin version.
+// Defaults recorded for a previous plugin version that are no longer part of the defaults, or whose default value
+// changed, are removed or updated in the config, unless their value has since been changed by the user.
+// It is a no-op if the same defaults were already applied, whatever the plugin version, and the config is only
+// written if a default is missing or changed. The defaults are applied under the config lock.
+func ApplyDefaultFeatureFlags(plugin, version string, defaultFeatureFlags map[string]bool) error {
+	if plugin == "" {
+		return errors.New("plugin cannot be empty")
+	}
+	defaults := make(map[string]string, len(defaultFeatureFlags))
+	for key, value := range defaultFeatureFlags {
+		defaults[key] = strconv.FormatBool(value)
+	}
+
+	// Skip taking the lock when the defaults were already applied, which is the case of most invocations
+	record, err := GetDefaultFeatureFlagsRecord(plugin)
+	if err != nil || defaultFeatureFlagsApplied(record, defaults) {
+		return err
+	}
+
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuConfigLock()
+	// Another invocation may have applied the defaults in the meantime
+	record, err = GetDefaultFeatureFlagsRecord(plugin)
+	if err != nil || defaultFeatureFlagsApplied(record, defaults) {
+		return err
+	}
+	node, err := getClientConfigNodeNoLock()
+	if err != nil {
+		return err
+	}
+	applied, persist, err := applyDefaultFeatureFlags(node, plugin, record, defaultFeatureFlags)
+	if err != nil {
+		return err
+	}
+	if persist {
+		if err := persistConfig(node, "ApplyDefaultFeatureFlags"); err != nil {
+			return err
+		}
+	}
+	return setDefaultFeatureFlagsRecord(plugin, &configtypes.DefaultFeatureFlagsRecord{
+		Version:  version,
+		Flags:    applied,
+		Defaults: defaults,
+	})
+}
+
+// defaultFeatureFlagsApplied checks whether the defaults are the ones recorded, or there are no defaults to apply
+func defaultFeatureFlagsApplied(record *configtypes.DefaultFeatureFlagsRecord, defaults map[string]string) bool {
+	if record == nil {
+		return len(defaults) == 0
+	}
+	return reflect.DeepEqual(record.Defaults, defaults)
+}
+
+// GetDefaultFeatureFlagsRecord returns the default feature flags applied for the specified plugin along with
+// the plugin version that applied them. It returns nil if no defaults were applied for the plugin.
+func GetDefaultFeatureFlagsRecord(plugin string) (*configtypes.DefaultFeatureFlagsRecord, error) {
+	metadata, err := GetMetadata()
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil || metadata.ConfigMetadata == nil || metadata.ConfigMetadata.DefaultFeatureFlags == nil {
+		return nil, nil
+	}
+	return metadata.ConfigMetadata.DefaultFeatureFlags[plugin], nil
+}
+
+// applyDefaultFeatureFlags updates the feature node of the plugin and returns the defaults that are now
+// owned by the record, i.e. the previously recorded defaults still in use and the newly configured ones
+func applyDefaultFeatureFlags(node *yaml.Node, plugin string, record *configtypes.DefaultFeatureFlagsRecord,
+	defaultFeatureFlags map[string]bool) (applied map[string]string, persist bool, err error) {
+	applied = make(map[string]string)
+	keys := []nodeutils.Key{
+		{Name: KeyClientOptions, Type: yaml.MappingNode},
+		{Name: KeyFeatures, Type: yaml.MappingNode},
+		{Name: plugin, Type: yaml.MappingNode},
+	}
+	pluginNode := nodeutils.FindNode(node.Content[0], nodeutils.WithForceCreate(), nodeutils.WithKeys(keys))
+	if pluginNode == nil {
+		return nil, false, nodeutils.ErrNodeNotFound
+	}
+
+	// Update or remove the defaults recorded by a previous version that the user has not changed
+	if record != nil {
+		for key, val := range record.Flags {
+			index := nodeutils.GetNodeIndex(pluginNode.Content, key)
+			if index == -1 {
+				continue
+			}
+			if value, ok := defaultFeatureFlags[key]; ok {
+				if pluginNode.Content[index].Value == val {
+					newVal := strconv.FormatBool(value)
+					if newVal != val {
+						pluginNode.Content[index].Tag = "!!str"
+						pluginNode.Content[index].Value = newVal
+						persist = true
+					}
+					applied[key] = newVal
+				}
+				continue
+			}
+			if pluginNode.Content[index].Value == val {
+				pluginNode.Content = append(pluginNode.Content[:index-1], pluginNode.Content[index+1:]...)
+				persist = true
+			}
+		}
+	}
+
+	// Configure the missing defaults, leaving out the features locked by the policy config
+	locked, err := GetPolicyLockedSettings()
+	if err != nil {
+		return nil, false, err
+	}
+	for key, value := range defaultFeatureFlags {
+		if nodeutils.GetNodeIndex(pluginNode.Content, key) != -1 {
+			continue
+		}
+		if lockedPath(locked, featurePolicyPath(plugin, key)) != "" {
+			continue
+		}
+		val := strconv.FormatBool(value)
+		pluginNode.Content = append(pluginNode.Content, nodeutils.CreateScalarNode(key, val)...)
+		applied[key] = val
+		persist = true
+	}
+	return applied, persist, nil
+}
+
+func setDefaultFeatureFlagsRecord(plugin string, record *configtypes.DefaultFeatureFlagsRecord) error {
+	// Retrieve config metadata node
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuMetadataLock()
+	node, err := getMetadataNodeNoLock()
+	if err != nil {
+		return err
+	}
+
+	keys := []nodeutils.Key{
+		{Name: KeyConfigMetadata, Type: yaml.MappingNode},
+		{Name: KeyDefaultFeatureFlags, Type: yaml.MappingNode},
+		{Name: plugin, Type: yaml.MappingNode},
+	}
+	recordNode := nodeutils.FindNode(node.Content[0], nodeutils.WithForceCreate(), nodeutils.WithKeys(keys))
+	if recordNode == nil {
+		return nodeutils.ErrNodeNotFound
+	}
+	newRecordNode, err := convertObjectToNode(record)
+	if err != nil {
+		return err
+	}
+	recordNode.Content = newRecordNode.Content[0].Content
+	// The record is not audited, as the audit entry of ApplyDefaultFeatureFlags records the feature flags it changed
+	path, err := CfgMetadataFilePath()
+	if err != nil {
+		return errors.Wrap(err, "could not find config metadata path")
+	}
+	return persistNode(node, WithCfgPath(path))
+}
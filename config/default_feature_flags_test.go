@@ -0,0 +1,160 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureDefaultFeatureFlagsIfMissing(t *testing.T) {
+	// Setup config test data
+	cfg := `clientOptions:
+  features:
+    test-plugin:
+      existing-feature: "false"
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfg: cfg})
+	defer func() {
+		cleanUp()
+	}()
+
+	err := ConfigureDefaultFeatureFlagsIfMissing("test-plugin", map[string]bool{
+		"existing-feature": true,
+		"new-feature":      true,
+	})
+	assert.NoError(t, err)
+
+	ok, err := IsFeatureEnabled("test-plugin", "existing-feature")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = IsFeatureEnabled("test-plugin", "new-feature")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestApplyDefaultFeatureFlags(t *testing.T) {
+	// Setup config test data
+	cfg := `clientOptions:
+  features:
+    test-plugin:
+      user-feature: "false"
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfg: cfg})
+	defer func() {
+		cleanUp()
+	}()
+
+	// Apply the defaults of the first plugin version
+	err := ApplyDefaultFeatureFlags("test-plugin", "v1.0.0", map[string]bool{
+		"user-feature":    true,
+		"stale-feature":   true,
+		"changed-feature": true,
+	})
+	assert.NoError(t, err)
+
+	record, err := GetDefaultFeatureFlagsRecord("test-plugin")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", record.Version)
+	assert.Equal(t, map[string]string{"stale-feature": "true", "changed-feature": "true"}, record.Flags)
+
+	ok, err := IsFeatureEnabled("test-plugin", "user-feature")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// User changes one of the applied defaults
+	err = SetFeature("test-plugin", "changed-feature", "false")
+	assert.NoError(t, err)
+
+	// Apply the defaults of the upgraded plugin version which dropped the stale and changed features
+	err = ApplyDefaultFeatureFlags("test-plugin", "v1.1.0", map[string]bool{
+		"user-feature": true,
+		"new-feature":  false,
+	})
+	assert.NoError(t, err)
+
+	record, err = GetDefaultFeatureFlagsRecord("test-plugin")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.0", record.Version)
+	assert.Equal(t, map[string]string{"new-feature": "false"}, record.Flags)
+
+	_, err = IsFeatureEnabled("test-plugin", "stale-feature")
+	assert.Equal(t, "not found", err.Error())
+
+	ok, err = IsFeatureEnabled("test-plugin", "changed-feature")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = IsFeatureEnabled("test-plugin", "new-feature")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// Defaults whose value changed are updated unless the user changed them, even for the same plugin version
+	err = SetFeature("test-plugin", "user-feature", "true")
+	assert.NoError(t, err)
+	err = ApplyDefaultFeatureFlags("test-plugin", "dev", map[string]bool{
+		"user-feature": false,
+		"new-feature":  true,
+	})
+	assert.NoError(t, err)
+	err = ApplyDefaultFeatureFlags("test-plugin", "dev", map[string]bool{
+		"user-feature": false,
+		"new-feature":  false,
+		"dev-feature":  true,
+	})
+	assert.NoError(t, err)
+
+	record, err = GetDefaultFeatureFlagsRecord("test-plugin")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", record.Version)
+	assert.Equal(t, map[string]string{"new-feature": "false", "dev-feature": "true"}, record.Flags)
+	assert.Equal(t, map[string]string{"user-feature": "false", "new-feature": "false", "dev-feature": "true"}, record.Defaults)
+
+	ok, err = IsFeatureEnabled("test-plugin", "user-feature")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = IsFeatureEnabled("test-plugin", "new-feature")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = IsFeatureEnabled("test-plugin", "dev-feature")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// No defaults and no record is a no-op
+	err = ApplyDefaultFeatureFlags("other-plugin", "v1.0.0", nil)
+	assert.NoError(t, err)
+	record, err = GetDefaultFeatureFlagsRecord("other-plugin")
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestApplyDefaultFeatureFlagsWritesMissingDefaultsOnly(t *testing.T) {
+	cfg := `clientOptions:
+  features:
+    test-plugin:
+      existing-feature: "false"
+`
+	files, cleanUp := setupTestConfig(t, &CfgTestData{cfg: cfg})
+	defer cleanUp()
+
+	// The config is not written when none of the defaults is missing
+	assert.NoError(t, ApplyDefaultFeatureFlags("test-plugin", "v1.0.0", map[string]bool{"existing-feature": true}))
+	data, err := os.ReadFile(files[0].Name())
+	assert.NoError(t, err)
+	assert.Equal(t, cfg, string(data))
+	record, err := GetDefaultFeatureFlagsRecord("test-plugin")
+	assert.NoError(t, err)
+	assert.Empty(t, record.Flags)
+
+	// Plugins without defaults leave the config and the metadata untouched
+	assert.NoError(t, ApplyDefaultFeatureFlags("other-plugin", "v1.0.0", nil))
+	record, err = GetDefaultFeatureFlagsRecord("other-plugin")
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+}
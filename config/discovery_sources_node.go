@@ -30,6 +30,10 @@ const (
 
 // setDiscoverySources adds or updates the node discoverySources
 func setDiscoverySources(node *yaml.Node, discoverySources []configtypes.PluginDiscovery, patchStrategyOpts ...nodeutils.PatchStrategyOpts) (persist bool, err error) {
+	// Avoid adding an empty discovery sources node to the user's config when there is nothing to set
+	if len(discoverySources) == 0 {
+		return false, nil
+	}
 	var anyPersists []bool
 	isTrue := func(item bool) bool { return item }
 	// Find the discovery sources node in the specific yaml node
@@ -106,13 +110,18 @@ func setDiscoverySource(discoverySourcesNode *yaml.Node, discoverySource configt
 				for _, opt := range patchStrategyOpts {
 					opt(options)
 				}
+				// Copy the patch strategies so the replacements only apply to this discovery source
+				patchStrategies := make(map[string]string, len(options.PatchStrategies)+2)
+				for key, value := range options.PatchStrategies {
+					patchStrategies[key] = value
+				}
 				replaceDiscoverySourceTypeKey := fmt.Sprintf("%v.%v", options.Key, discoverySourceTypeOfAnyType)
 				replaceDiscoverySourceContextTypeKey := fmt.Sprintf("%v.%v", options.Key, "contextType")
-				options.PatchStrategies[replaceDiscoverySourceTypeKey] = nodeutils.PatchStrategyReplace
-				options.PatchStrategies[replaceDiscoverySourceContextTypeKey] = nodeutils.PatchStrategyReplace
+				patchStrategies[replaceDiscoverySourceTypeKey] = nodeutils.PatchStrategyReplace
+				patchStrategies[replaceDiscoverySourceContextTypeKey] = nodeutils.PatchStrategyReplace
 
 				// Delete nodes as per patch strategy defined in config-metadata.yaml
-				_, err = nodeutils.DeleteNodes(newNode.Content[0], discoverySourceNode, patchStrategyOpts...)
+				_, err = nodeutils.DeleteNodes(newNode.Content[0], discoverySourceNode, nodeutils.WithPatchStrategyKey(options.Key), nodeutils.WithPatchStrategies(patchStrategies))
 				if err != nil {
 					return false, err
 				}
@@ -129,7 +138,7 @@ func setDiscoverySource(discoverySourcesNode *yaml.Node, discoverySource configt
 		result = append(result, newNode.Content[0])
 		persist = true
 	}
-	discoverySourcesNode.Style = 0
+	nodeutils.ResetEmptyCollectionStyle(discoverySourcesNode)
 	discoverySourcesNode.Content = result
 	return persist, err
 }
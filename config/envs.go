@@ -8,23 +8,20 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
 )
 
 // GetAllEnvs retrieves all env values from config
 func GetAllEnvs() (map[string]string, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	return getAllEnvs(node)
+	return getAllEnvs(cfg)
 }
 
-func getAllEnvs(node *yaml.Node) (map[string]string, error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getAllEnvs(cfg *configtypes.ClientConfig) (map[string]string, error) {
 	if cfg.ClientOptions != nil && cfg.ClientOptions.Env != nil {
 		return cfg.ClientOptions.Env, nil
 	}
@@ -33,24 +30,20 @@ func getAllEnvs(node *yaml.Node) (map[string]string, error) {
 
 // GetEnv retrieves env value by key
 func GetEnv(key string) (string, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return "", err
 	}
-	return getEnv(node, key)
+	return getEnv(cfg, key)
 }
 
-func getEnv(node *yaml.Node, key string) (string, error) {
+func getEnv(cfg *configtypes.ClientConfig, key string) (string, error) {
 	// check if key is empty
 	if key == "" {
 		return "", errors.New("key cannot be empty")
 	}
 
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return "", err
-	}
 	if cfg.ClientOptions == nil || cfg.ClientOptions.Env == nil {
 		return "", errors.New("not found")
 	}
@@ -62,8 +55,14 @@ func getEnv(node *yaml.Node, key string) (string, error) {
 
 // DeleteEnv delete the env entry of specified key
 func DeleteEnv(key string) error {
+	if err := checkPolicyLocked(envPolicyPath(key)); err != nil {
+		return err
+	}
+
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -73,7 +72,7 @@ func DeleteEnv(key string) error {
 	if err != nil {
 		return err
 	}
-	return persistConfig(node)
+	return persistConfig(node, "DeleteEnv")
 }
 
 func deleteEnv(node *yaml.Node, key string) (err error) {
@@ -112,21 +111,33 @@ func deleteEnv(node *yaml.Node, key string) (err error) {
 
 // SetEnv add or update a env key and value
 func SetEnv(key, value string) (err error) {
+	if err := checkPolicyLocked(envPolicyPath(key)); err != nil {
+		return err
+	}
+
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
 
+	// delete the envs replaced as per the patch strategies
+	replaced, err := applyValuePatchStrategies(node, []string{KeyClientOptions, KeyEnv, key}, value)
+	if err != nil {
+		return err
+	}
+
 	// add or update env map
 	persist, err := setEnv(node, key, value)
 	if err != nil {
 		return err
 	}
-	if persist {
-		return persistConfig(node)
+	if persist || replaced {
+		return persistConfig(node, "SetEnv")
 	}
 	return err
 }
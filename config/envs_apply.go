@@ -0,0 +1,189 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EnvPrecedence determines which value wins when an env is both configured and set in the process environment
+type EnvPrecedence string
+
+const (
+	// EnvPrecedenceProcess keeps the value already set in the process environment over the configured value
+	EnvPrecedenceProcess EnvPrecedence = "process"
+	// EnvPrecedenceConfig overrides the value set in the process environment with the configured value
+	EnvPrecedenceConfig EnvPrecedence = "config"
+)
+
+// EnvSource describes where the resolved value of an env came from
+type EnvSource string
+
+const (
+	// EnvSourceProcess indicates the value came from the process environment
+	EnvSourceProcess EnvSource = "process"
+	// EnvSourceConfig indicates the value came from ClientOptions.Env of the tanzu config
+	EnvSourceConfig EnvSource = "config"
+)
+
+// EnvValue is the resolved value of an env along with its origin
+type EnvValue struct {
+	// Key of the env
+	Key string `json:"key" yaml:"key"`
+	// Value after ${VAR} expansion
+	Value string `json:"value" yaml:"value"`
+	// Source is where the value came from
+	Source EnvSource `json:"source" yaml:"source"`
+	// ConfigValue is the raw configured value before expansion
+	ConfigValue string `json:"configValue,omitempty" yaml:"configValue,omitempty"`
+	// Shadowed is true if the configured value was not used because of the precedence policy
+	Shadowed bool `json:"shadowed,omitempty" yaml:"shadowed,omitempty"`
+}
+
+// ResolveEnvConfigurations resolves the envs configured in ClientOptions.Env against the process environment
+// using the specified precedence policy. References to other envs using ${VAR} or $VAR in configured values
+// are expanded, looking up other configured envs first and the process environment next.
+func ResolveEnvConfigurations(precedence EnvPrecedence) ([]EnvValue, error) {
+	return resolveEnvs(GetEnvConfigurations(), os.LookupEnv, precedence)
+}
+
+// ApplyEnvConfigurations sets the envs configured in ClientOptions.Env in the current process environment
+// using the specified precedence policy and returns the resolved values along with their origin
+func ApplyEnvConfigurations(precedence EnvPrecedence) ([]EnvValue, error) {
+	envs, err := ResolveEnvConfigurations(precedence)
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		if env.Source != EnvSourceConfig {
+			continue
+		}
+		if err := os.Setenv(env.Key, env.Value); err != nil {
+			return nil, errors.Wrapf(err, "failed to set env %q", env.Key)
+		}
+	}
+	return envs, nil
+}
+
+// EnvironWithConfig returns a copy of the process environment, in the form "key=value", layered with the
+// envs configured in ClientOptions.Env using the specified precedence policy.
+// The result can be used as the environment of child processes e.g. exec.Cmd.Env
+func EnvironWithConfig(precedence EnvPrecedence) ([]string, error) {
+	envs, err := ResolveEnvConfigurations(precedence)
+	if err != nil {
+		return nil, err
+	}
+	return mergeEnviron(os.Environ(), envs), nil
+}
+
+func mergeEnviron(environ []string, envs []EnvValue) []string {
+	overrides := make(map[string]string)
+	for _, env := range envs {
+		if env.Source == EnvSourceConfig {
+			overrides[env.Key] = env.Value
+		}
+	}
+	result := make([]string, 0, len(environ)+len(overrides))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := overrides[key]; ok {
+			continue
+		}
+		result = append(result, kv)
+	}
+	for _, env := range envs {
+		if val, ok := overrides[env.Key]; ok {
+			result = append(result, env.Key+"="+val)
+		}
+	}
+	return result
+}
+
+func resolveEnvs(configured map[string]string, lookupEnv func(string) (string, bool), precedence EnvPrecedence) ([]EnvValue, error) {
+	switch precedence {
+	case EnvPrecedenceProcess, EnvPrecedenceConfig:
+	default:
+		return nil, errors.Errorf("unknown env precedence %q, allowed values are %q or %q", precedence, EnvPrecedenceProcess, EnvPrecedenceConfig)
+	}
+
+	r := &envResolver{
+		configured: configured,
+		lookupEnv:  lookupEnv,
+		precedence: precedence,
+		resolved:   make(map[string]EnvValue),
+		resolving:  make(map[string]bool),
+	}
+	keys := make([]string, 0, len(configured))
+	for key := range configured {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	envs := make([]EnvValue, 0, len(keys))
+	for _, key := range keys {
+		env, err := r.resolve(key)
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// envResolver resolves configured envs expanding ${VAR} references and detecting reference cycles
+type envResolver struct {
+	configured map[string]string
+	lookupEnv  func(string) (string, bool)
+	precedence EnvPrecedence
+	resolved   map[string]EnvValue
+	resolving  map[string]bool
+}
+
+func (r *envResolver) resolve(key string) (EnvValue, error) {
+	if env, ok := r.resolved[key]; ok {
+		return env, nil
+	}
+	configValue := r.configured[key]
+	processValue, inProcess := r.lookupEnv(key)
+	if inProcess && r.precedence == EnvPrecedenceProcess {
+		env := EnvValue{Key: key, Value: processValue, Source: EnvSourceProcess, ConfigValue: configValue, Shadowed: true}
+		r.resolved[key] = env
+		return env, nil
+	}
+
+	if r.resolving[key] {
+		return EnvValue{}, errors.Errorf("cyclic reference detected while expanding env %q", key)
+	}
+	r.resolving[key] = true
+	defer delete(r.resolving, key)
+
+	var expandErr error
+	value := os.Expand(configValue, func(ref string) string {
+		if expandErr != nil {
+			return ""
+		}
+		if _, ok := r.configured[ref]; ok && ref != key {
+			env, err := r.resolve(ref)
+			if err != nil {
+				expandErr = err
+				return ""
+			}
+			return env.Value
+		}
+		// Self references and references to non configured envs are looked up in the process environment
+		val, _ := r.lookupEnv(ref)
+		return val
+	})
+	if expandErr != nil {
+		return EnvValue{}, expandErr
+	}
+
+	env := EnvValue{Key: key, Value: value, Source: EnvSourceConfig, ConfigValue: configValue}
+	r.resolved[key] = env
+	return env, nil
+}
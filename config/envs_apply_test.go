@@ -0,0 +1,126 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEnvs(t *testing.T) {
+	processEnvs := map[string]string{
+		"HOME":       "/home/user",
+		"PATH":       "/usr/bin",
+		"TEST_OWNED": "from-process",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		val, ok := processEnvs[key]
+		return val, ok
+	}
+
+	tests := []struct {
+		name       string
+		configured map[string]string
+		precedence EnvPrecedence
+		expected   []EnvValue
+		errStr     string
+	}{
+		{
+			name: "process value wins with process precedence",
+			configured: map[string]string{
+				"TEST_OWNED": "from-config",
+			},
+			precedence: EnvPrecedenceProcess,
+			expected: []EnvValue{
+				{Key: "TEST_OWNED", Value: "from-process", Source: EnvSourceProcess, ConfigValue: "from-config", Shadowed: true},
+			},
+		},
+		{
+			name: "config value wins with config precedence",
+			configured: map[string]string{
+				"TEST_OWNED": "from-config",
+			},
+			precedence: EnvPrecedenceConfig,
+			expected: []EnvValue{
+				{Key: "TEST_OWNED", Value: "from-config", Source: EnvSourceConfig, ConfigValue: "from-config"},
+			},
+		},
+		{
+			name: "expand references to configured and process envs",
+			configured: map[string]string{
+				"TEST_DIR":  "${HOME}/tanzu",
+				"TEST_FILE": "${TEST_DIR}/file",
+				"PATH":      "${PATH}:$TEST_DIR/bin",
+			},
+			precedence: EnvPrecedenceConfig,
+			expected: []EnvValue{
+				{Key: "PATH", Value: "/usr/bin:/home/user/tanzu/bin", Source: EnvSourceConfig, ConfigValue: "${PATH}:$TEST_DIR/bin"},
+				{Key: "TEST_DIR", Value: "/home/user/tanzu", Source: EnvSourceConfig, ConfigValue: "${HOME}/tanzu"},
+				{Key: "TEST_FILE", Value: "/home/user/tanzu/file", Source: EnvSourceConfig, ConfigValue: "${TEST_DIR}/file"},
+			},
+		},
+		{
+			name: "cyclic references",
+			configured: map[string]string{
+				"TEST_A": "${TEST_B}",
+				"TEST_B": "${TEST_A}",
+			},
+			precedence: EnvPrecedenceConfig,
+			errStr:     "cyclic reference detected while expanding env \"TEST_A\"",
+		},
+		{
+			name:       "unknown precedence",
+			precedence: "unknown",
+			errStr:     "unknown env precedence \"unknown\", allowed values are \"process\" or \"config\"",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			envs, err := resolveEnvs(tc.configured, lookupEnv, tc.precedence)
+			if tc.errStr != "" {
+				assert.Equal(t, tc.errStr, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, envs)
+		})
+	}
+}
+
+func TestApplyEnvConfigurations(t *testing.T) {
+	// Setup config test data
+	cfg := `clientOptions:
+  env:
+    TEST_TANZU_ENV: "${TEST_TANZU_BASE}/value"
+    TEST_TANZU_OWNED: from-config
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfg: cfg})
+	defer func() {
+		cleanUp()
+	}()
+	t.Setenv("TEST_TANZU_BASE", "base")
+	t.Setenv("TEST_TANZU_OWNED", "from-process")
+	t.Setenv("TEST_TANZU_ENV", "")
+
+	environ, err := EnvironWithConfig(EnvPrecedenceConfig)
+	assert.NoError(t, err)
+	assert.Contains(t, environ, "TEST_TANZU_ENV=base/value")
+	assert.Contains(t, environ, "TEST_TANZU_OWNED=from-config")
+	assert.NotContains(t, environ, "TEST_TANZU_OWNED=from-process")
+
+	envs, err := ApplyEnvConfigurations(EnvPrecedenceProcess)
+	assert.NoError(t, err)
+	assert.Len(t, envs, 2)
+	assert.Equal(t, EnvSourceProcess, envs[0].Source)
+	assert.Equal(t, EnvSourceProcess, envs[1].Source)
+	assert.Equal(t, "from-process", os.Getenv("TEST_TANZU_OWNED"))
+
+	assert.NoError(t, os.Unsetenv("TEST_TANZU_ENV"))
+	envs, err = ApplyEnvConfigurations(EnvPrecedenceProcess)
+	assert.NoError(t, err)
+	assert.Equal(t, EnvValue{Key: "TEST_TANZU_ENV", Value: "base/value", Source: EnvSourceConfig, ConfigValue: "${TEST_TANZU_BASE}/value"}, envs[0])
+	assert.Equal(t, "base/value", os.Getenv("TEST_TANZU_ENV"))
+}
@@ -0,0 +1,360 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/semver"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+// Feature values may be conditions instead of plain booleans. A condition is made of one or more
+// clauses separated by ';' and is considered enabled only when all the clauses match, e.g.
+//
+//	targets:kubernetes;percentage:25
+//	contexts:ctx-a,ctx-b
+//	versions:>=v1.2.0 <v2.0.0
+const (
+	// FeatureConditionContexts matches when any of the comma separated contexts is a current context
+	FeatureConditionContexts = "contexts"
+	// FeatureConditionTargets matches when a current context is set for any of the comma separated targets
+	FeatureConditionTargets = "targets"
+	// FeatureConditionVersions matches when the plugin version satisfies all the space separated constraints
+	FeatureConditionVersions = "versions"
+	// FeatureConditionPercentage matches when the stable hash bucket (0-99) of the machine ID is below the percentage
+	FeatureConditionPercentage = "percentage"
+
+	featureConditionClauseSeparator = ";"
+	featureConditionKeySeparator    = ":"
+	featureConditionListSeparator   = ","
+
+	// MachineIDFileName is the name of the file under LocalStateDir storing the generated machine ID
+	MachineIDFileName = ".machine-id"
+	// machineIDLockFileName is the name of the lock file under LocalStateDir taken while generating the machine ID
+	machineIDLockFileName = MachineIDFileName + ".lock"
+)
+
+// featureConditionKinds are the kinds of the feature condition clauses
+var featureConditionKinds = []string{
+	FeatureConditionContexts,
+	FeatureConditionTargets,
+	FeatureConditionVersions,
+	FeatureConditionPercentage,
+}
+
+// FeatureEvaluationContext provides the local information conditional feature values are evaluated against
+type FeatureEvaluationContext struct {
+	// CurrentContexts are the current context names per target
+	CurrentContexts map[configtypes.Target]string
+	// PluginVersion is the version of the plugin evaluating the feature
+	PluginVersion string
+	// MachineID is the stable identifier of the machine used for percentage based rollouts
+	MachineID string
+}
+
+// featurePluginVersion is the version of the running plugin conditional feature values are evaluated against,
+// set by SetFeaturePluginVersion
+var featurePluginVersion string
+
+// SetFeaturePluginVersion sets the version of the running plugin conditional feature values are evaluated against
+func SetFeaturePluginVersion(version string) {
+	featurePluginVersion = version
+}
+
+// featureCondition is a parsed feature condition clause
+type featureCondition struct {
+	kind   string
+	values []string
+}
+
+// IsFeatureCondition returns true if the feature value is a condition rather than a plain boolean,
+// i.e. it starts with a known condition kind followed by ':' e.g. targets:kubernetes
+func IsFeatureCondition(value string) bool {
+	value = strings.TrimSpace(value)
+	for _, kind := range featureConditionKinds {
+		if strings.HasPrefix(value, kind+featureConditionKeySeparator) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFeatureEnabledWithContext checks and returns whether specific plugin and key is enabled,
+// evaluating conditional feature values against the specified evaluation context.
+// The evaluation context is built from the current config, the machine ID and the version of the running plugin,
+// the fields set in evalCtx override the corresponding fields of the built context.
+func IsFeatureEnabledWithContext(plugin, key string, evalCtx *FeatureEvaluationContext) (bool, error) {
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
+	if err != nil {
+		return false, err
+	}
+	val, err := getFeature(cfg, plugin, key)
+	if err != nil {
+		return false, err
+	}
+	if !IsFeatureCondition(val) {
+		return strings.EqualFold(val, "true"), nil
+	}
+	return EvaluateFeatureCondition(plugin, key, val, newFeatureEvaluationContext(cfg).overlay(evalCtx))
+}
+
+// EvaluateFeatureCondition evaluates the conditional feature value of the specified plugin and key
+// against the evaluation context. The evaluation is done locally and never requires network access.
+func EvaluateFeatureCondition(plugin, key, value string, evalCtx *FeatureEvaluationContext) (bool, error) {
+	conditions, err := parseFeatureCondition(value)
+	if err != nil {
+		return false, err
+	}
+	if evalCtx == nil {
+		evalCtx = &FeatureEvaluationContext{}
+	}
+	for _, condition := range conditions {
+		matched, err := condition.matches(plugin, key, evalCtx)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// GetMachineID returns the stable machine identifier used for percentage based feature rollouts.
+// The identifier is generated and stored under LocalStateDir the first time it is requested.
+// An identifier stored under LocalDir by older versions is moved to LocalStateDir.
+func GetMachineID() (string, error) {
+	stateDir, err := LocalStateDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(stateDir, MachineIDFileName)
+	if id := readMachineID(path); id != "" {
+		return id, nil
+	}
+
+	// Concurrent invocations must agree on the generated identifier. The identifier is read along with the
+	// feature flags, so it has its own lock rather than contending with the writers of the config.
+	lock, err := getFileLockWithTimeOut(filepath.Join(stateDir, machineIDLockFileName), DefaultLockTimeout)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot acquire lock for the machine id file")
+	}
+	defer func() {
+		_ = lock.Unlock()
+	}()
+	if id := readMachineID(path); id != "" {
+		return id, nil
+	}
+	id := uuid.NewString()
+	if localDir, err := LocalDir(); err == nil {
+		if legacyID := readMachineID(filepath.Join(localDir, MachineIDFileName)); legacyID != "" {
+			id = legacyID
+		}
+	}
+	if err := writeMachineID(path, id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// writeMachineID writes the machine ID to a temporary file renamed to the path, so that it is never read partially
+func writeMachineID(path, id string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "could not make local tanzu state directory")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), MachineIDFileName+".*")
+	if err != nil {
+		return errors.Wrap(err, "failed to write the machine id to file")
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(id)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	return errors.Wrap(err, "failed to write the machine id to file")
+}
+
+func readMachineID(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// FeatureBucket returns the stable bucket (0-99) of the machine ID for the specified plugin and key
+func FeatureBucket(machineID, plugin, key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(machineID + "/" + plugin + "." + key))
+	return int(h.Sum32() % 100)
+}
+
+// newFeatureEvaluationContext returns the evaluation context of the current config, machine ID and running plugin
+func newFeatureEvaluationContext(cfg *configtypes.ClientConfig) *FeatureEvaluationContext {
+	evalCtx := &FeatureEvaluationContext{CurrentContexts: cfg.CurrentContext, PluginVersion: featurePluginVersion}
+	// A missing machine ID only means percentage conditions do not match
+	evalCtx.MachineID, _ = GetMachineID()
+	return evalCtx
+}
+
+// overlay returns a copy of the evaluation context with the fields set in other
+func (c *FeatureEvaluationContext) overlay(other *FeatureEvaluationContext) *FeatureEvaluationContext {
+	result := *c
+	if other == nil {
+		return &result
+	}
+	if other.CurrentContexts != nil {
+		result.CurrentContexts = other.CurrentContexts
+	}
+	if other.PluginVersion != "" {
+		result.PluginVersion = other.PluginVersion
+	}
+	if other.MachineID != "" {
+		result.MachineID = other.MachineID
+	}
+	return &result
+}
+
+func parseFeatureCondition(value string) ([]featureCondition, error) {
+	var conditions []featureCondition
+	for _, clause := range strings.Split(value, featureConditionClauseSeparator) {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+		kind, values, found := strings.Cut(clause, featureConditionKeySeparator)
+		if !found {
+			return nil, errors.Errorf("invalid feature condition %q, was expecting <kind>:<values>", clause)
+		}
+		condition := featureCondition{kind: strings.TrimSpace(kind)}
+		switch condition.kind {
+		case FeatureConditionContexts, FeatureConditionTargets:
+			for _, v := range strings.Split(values, featureConditionListSeparator) {
+				if v = strings.TrimSpace(v); v != "" {
+					condition.values = append(condition.values, v)
+				}
+			}
+		case FeatureConditionVersions:
+			condition.values = strings.Fields(values)
+			for _, constraint := range condition.values {
+				if _, _, err := parseVersionConstraint(constraint); err != nil {
+					return nil, err
+				}
+			}
+		case FeatureConditionPercentage:
+			percentage, err := strconv.Atoi(strings.TrimSpace(values))
+			if err != nil || percentage < 0 || percentage > 100 {
+				return nil, errors.Errorf("invalid feature condition percentage %q, was expecting a number between 0 and 100", values)
+			}
+			condition.values = []string{strconv.Itoa(percentage)}
+		default:
+			return nil, errors.Errorf("unknown feature condition %q", condition.kind)
+		}
+		conditions = append(conditions, condition)
+	}
+	if len(conditions) == 0 {
+		return nil, errors.Errorf("invalid feature condition %q", value)
+	}
+	return conditions, nil
+}
+
+func (c *featureCondition) matches(plugin, key string, evalCtx *FeatureEvaluationContext) (bool, error) {
+	switch c.kind {
+	case FeatureConditionContexts:
+		for _, ctxName := range evalCtx.CurrentContexts {
+			for _, v := range c.values {
+				if ctxName == v {
+					return true, nil
+				}
+			}
+		}
+	case FeatureConditionTargets:
+		for _, v := range c.values {
+			target := configtypes.StringToTarget(v)
+			if evalCtx.CurrentContexts[target] != "" {
+				return true, nil
+			}
+		}
+	case FeatureConditionVersions:
+		if evalCtx.PluginVersion == "" {
+			return false, nil
+		}
+		return versionSatisfies(evalCtx.PluginVersion, c.values)
+	case FeatureConditionPercentage:
+		if evalCtx.MachineID == "" {
+			return false, nil
+		}
+		percentage, _ := strconv.Atoi(c.values[0])
+		return FeatureBucket(evalCtx.MachineID, plugin, key) < percentage, nil
+	}
+	return false, nil
+}
+
+// versionSatisfies returns true if the version satisfies all the constraints e.g. >=v1.0.0 <v2.0.0.
+// Versions that are not semantic versions, e.g. dev builds, satisfy no constraints.
+func versionSatisfies(version string, constraints []string) (bool, error) {
+	version = canonicalSemver(version)
+	if !semver.IsValid(version) {
+		return false, nil
+	}
+	for _, constraint := range constraints {
+		op, constraintVersion, err := parseVersionConstraint(constraint)
+		if err != nil {
+			return false, err
+		}
+		cmp := semver.Compare(version, constraintVersion)
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func parseVersionConstraint(constraint string) (op, version string, err error) {
+	for _, o := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if strings.HasPrefix(constraint, o) {
+			op = o
+			break
+		}
+	}
+	version = canonicalSemver(strings.TrimPrefix(constraint, op))
+	if !semver.IsValid(version) {
+		return "", "", errors.Errorf("invalid version constraint %q", constraint)
+	}
+	return op, version, nil
+}
+
+func canonicalSemver(version string) string {
+	if version != "" && !strings.HasPrefix(version, "v") {
+		return "v" + version
+	}
+	return version
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestEvaluateFeatureCondition(t *testing.T) {
+	evalCtx := &FeatureEvaluationContext{
+		CurrentContexts: map[configtypes.Target]string{
+			configtypes.TargetK8s: "test-mc",
+		},
+		PluginVersion: "v1.2.3",
+		MachineID:     "test-machine",
+	}
+	bucket := FeatureBucket(evalCtx.MachineID, "global", "sample")
+
+	tests := []struct {
+		name    string
+		value   string
+		enabled bool
+		errStr  string
+	}{
+		{
+			name:    "matching context",
+			value:   "contexts:test-tmc,test-mc",
+			enabled: true,
+		},
+		{
+			name:  "non matching context",
+			value: "contexts:test-tmc",
+		},
+		{
+			name:    "matching target",
+			value:   "targets:k8s",
+			enabled: true,
+		},
+		{
+			name:  "non matching target",
+			value: "targets:mission-control",
+		},
+		{
+			name:    "version in range",
+			value:   "versions:>=v1.2.0 <2.0.0",
+			enabled: true,
+		},
+		{
+			name:  "version out of range",
+			value: "versions:>v1.2.3",
+		},
+		{
+			name:    "percentage including bucket",
+			value:   "percentage:" + strconv.Itoa(bucket+1),
+			enabled: true,
+		},
+		{
+			name:  "percentage excluding bucket",
+			value: "percentage:" + strconv.Itoa(bucket),
+		},
+		{
+			name:    "all clauses matching",
+			value:   "targets:kubernetes; versions:=1.2.3; percentage:100",
+			enabled: true,
+		},
+		{
+			name:  "one clause not matching",
+			value: "targets:kubernetes;percentage:0",
+		},
+		{
+			name:   "unknown condition",
+			value:  "os:linux",
+			errStr: "unknown feature condition \"os\"",
+		},
+		{
+			name:   "invalid percentage",
+			value:  "percentage:101",
+			errStr: "invalid feature condition percentage \"101\", was expecting a number between 0 and 100",
+		},
+		{
+			name:   "invalid version constraint",
+			value:  "versions:>=abc",
+			errStr: "invalid version constraint \">=abc\"",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			enabled, err := EvaluateFeatureCondition("global", "sample", tc.value, evalCtx)
+			if tc.errStr != "" {
+				assert.Equal(t, tc.errStr, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.enabled, enabled)
+		})
+	}
+}
+
+func TestIsFeatureCondition(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"true":                         false,
+		"False":                        false,
+		"targets:kubernetes":           true,
+		" contexts:ctx-a,ctx-b":        true,
+		"versions:>=v1.2.0 <v2.0.0":    true,
+		"percentage:25;targets:tmc":    true,
+		"https://example.com/endpoint": false,
+		"os:linux":                     false,
+		"time: 10:00":                  false,
+	} {
+		assert.Equal(t, expected, IsFeatureCondition(value), value)
+	}
+}
+
+func TestIsFeatureEnabledWithCondition(t *testing.T) {
+	// Setup config test data
+	_, cleanUp := setupTestConfig(t, &CfgTestData{})
+	func() {
+		LocalDirName = TestLocalDirName
+	}()
+	defer func() {
+		cleanUp()
+		cleanupDir(LocalDirName)
+	}()
+
+	cfg := &configtypes.ClientConfig{
+		KnownContexts: []*configtypes.Context{
+			{
+				Name:   "test-mc",
+				Target: configtypes.TargetK8s,
+				ClusterOpts: &configtypes.ClusterServer{
+					Endpoint: "test-endpoint",
+				},
+			},
+		},
+		CurrentContext: map[configtypes.Target]string{
+			configtypes.TargetK8s: "test-mc",
+		},
+		ClientOptions: &configtypes.ClientOptions{
+			Features: map[string]configtypes.FeatureMap{
+				"global": {
+					"k8s-only":   "targets:kubernetes",
+					"tmc-only":   "targets:mission-control",
+					"everyone":   "percentage:100",
+					"new-plugin": "versions:>=v1.0.0",
+				},
+			},
+		},
+	}
+	err := StoreClientConfig(cfg)
+	assert.NoError(t, err)
+
+	ok, err := IsFeatureEnabled("global", "k8s-only")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = IsFeatureEnabled("global", "tmc-only")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = IsFeatureEnabled("global", "everyone")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = IsFeatureEnabled("global", "new-plugin")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = IsFeatureEnabledWithContext("global", "new-plugin", &FeatureEvaluationContext{PluginVersion: "v1.1.0"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Fields not set by the caller are taken from the config
+	ok, err = IsFeatureEnabledWithContext("global", "k8s-only", &FeatureEvaluationContext{PluginVersion: "v1.1.0"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// The version of the running plugin is used by default
+	SetFeaturePluginVersion("v1.0.1")
+	defer SetFeaturePluginVersion("")
+	ok, err = IsFeatureEnabled("global", "new-plugin")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = IsFeatureEnabledWithContext("global", "new-plugin", &FeatureEvaluationContext{PluginVersion: "v0.9.0"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// Plugin versions that are not semantic versions do not satisfy version conditions
+	ok, err = IsFeatureEnabledWithContext("global", "new-plugin", &FeatureEvaluationContext{PluginVersion: "dev"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.True(t, IsFeatureActivated("features.global.k8s-only"))
+	assert.False(t, IsFeatureActivated("features.global.tmc-only"))
+
+}
+
+func TestGetMachineID(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(EnvConfigDirKey, configDir)
+
+	id, err := GetMachineID()
+	assert.NoError(t, err)
+	sameID, err := GetMachineID()
+	assert.NoError(t, err)
+	assert.Equal(t, id, sameID)
+	assert.FileExists(t, filepath.Join(configDir, stateDirName, MachineIDFileName))
+
+	// Generating the identifier does not take the config lock
+	t.Setenv(EnvConfigDirKey, t.TempDir())
+	assert.NoError(t, acquireTanzuConfigLock())
+	id, err = GetMachineID()
+	ReleaseTanzuConfigLock()
+	assert.NoError(t, err)
+	assert.NotEqual(t, sameID, id)
+
+	// An identifier stored in the config dir by older versions is kept
+	t.Setenv(EnvConfigDirKey, t.TempDir())
+	localDir, err := LocalDir()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(localDir, MachineIDFileName), []byte("legacy-id\n"), 0644))
+	id, err = GetMachineID()
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy-id", id)
+}
@@ -0,0 +1,338 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
+)
+
+// FeatureFlagType is the type of the value a feature flag holds
+type FeatureFlagType string
+
+// FeatureFlagStage is the lifecycle stage of a feature flag
+type FeatureFlagStage string
+
+const (
+	// FeatureFlagTypeBool is a feature flag holding a boolean value
+	FeatureFlagTypeBool FeatureFlagType = "bool"
+	// FeatureFlagTypeString is a feature flag holding a string value
+	FeatureFlagTypeString FeatureFlagType = "string"
+	// FeatureFlagTypeInt is a feature flag holding an integer value
+	FeatureFlagTypeInt FeatureFlagType = "int"
+	// FeatureFlagTypeDuration is a feature flag holding a duration value e.g. 30s, 5m
+	FeatureFlagTypeDuration FeatureFlagType = "duration"
+)
+
+const (
+	// FeatureFlagStageAlpha is a feature that is experimental and may change or be removed
+	FeatureFlagStageAlpha FeatureFlagStage = "alpha"
+	// FeatureFlagStageBeta is a feature that is well tested but may still change
+	FeatureFlagStageBeta FeatureFlagStage = "beta"
+	// FeatureFlagStageGA is a feature that is generally available
+	FeatureFlagStageGA FeatureFlagStage = "ga"
+	// FeatureFlagStageDeprecated is a feature that is going to be removed in a future version
+	FeatureFlagStageDeprecated FeatureFlagStage = "deprecated"
+)
+
+// FeatureFlagSource describes where the effective value of a feature flag came from
+type FeatureFlagSource string
+
+const (
+	// FeatureFlagSourceConfig indicates the value is configured in the tanzu config file
+	FeatureFlagSourceConfig FeatureFlagSource = "config"
+	// FeatureFlagSourceDefault indicates the value is the registered default of the feature flag
+	FeatureFlagSourceDefault FeatureFlagSource = "default"
+)
+
+// FeatureFlag describes a feature flag registered by a plugin
+type FeatureFlag struct {
+	// Plugin is the name of the plugin the feature flag belongs to e.g. global, cluster
+	Plugin string `json:"plugin" yaml:"plugin"`
+	// Name is the key of the feature flag
+	Name string `json:"name" yaml:"name"`
+	// Description is a short description of what the feature flag controls
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Type is the type of the feature flag value, defaults to bool
+	Type FeatureFlagType `json:"type" yaml:"type"`
+	// Stage is the lifecycle stage of the feature flag, defaults to alpha
+	Stage FeatureFlagStage `json:"stage" yaml:"stage"`
+	// Default is the value used when the feature flag is not configured
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+	// DeprecationMessage is shown to the user when a deprecated feature flag is used
+	DeprecationMessage string `json:"deprecationMessage,omitempty" yaml:"deprecationMessage,omitempty"`
+}
+
+// FeatureFlagValue describes a feature flag along with its effective value
+type FeatureFlagValue struct {
+	FeatureFlag `json:",inline" yaml:",inline"`
+	// Value is the effective value of the feature flag
+	Value string `json:"value" yaml:"value"`
+	// Source is where the effective value came from
+	Source FeatureFlagSource `json:"source" yaml:"source"`
+	// Registered tells whether the feature flag was registered or only found in the config file
+	Registered bool `json:"registered" yaml:"registered"`
+}
+
+// featureFlagRegistry holds the feature flags registered within the current process
+var featureFlagRegistry = struct {
+	sync.RWMutex
+	flags  map[string]FeatureFlag
+	warned map[string]bool
+}{
+	flags:  make(map[string]FeatureFlag),
+	warned: make(map[string]bool),
+}
+
+func featureFlagRegistryKey(plugin, key string) string {
+	return plugin + "." + key
+}
+
+// RegisterFeatureFlag registers a feature flag along with its description, type, default value and stage
+func RegisterFeatureFlag(flag FeatureFlag) error {
+	if flag.Plugin == "" {
+		return errors.New("plugin cannot be empty")
+	}
+	if flag.Name == "" {
+		return errors.New("key cannot be empty")
+	}
+	if flag.Type == "" {
+		flag.Type = FeatureFlagTypeBool
+	}
+	if flag.Stage == "" {
+		flag.Stage = FeatureFlagStageAlpha
+	}
+	switch flag.Stage {
+	case FeatureFlagStageAlpha, FeatureFlagStageBeta, FeatureFlagStageGA, FeatureFlagStageDeprecated:
+	default:
+		return errors.Errorf("feature flag %q has unknown stage %q", flag.Name, flag.Stage)
+	}
+	if flag.Default != "" {
+		if err := validateFeatureFlagValue(flag.Type, flag.Default); err != nil {
+			return errors.Wrapf(err, "feature flag %q has invalid default", flag.Name)
+		}
+	} else if flag.Type == FeatureFlagTypeBool {
+		flag.Default = strconv.FormatBool(false)
+	}
+
+	featureFlagRegistry.Lock()
+	defer featureFlagRegistry.Unlock()
+	featureFlagRegistry.flags[featureFlagRegistryKey(flag.Plugin, flag.Name)] = flag
+	return nil
+}
+
+// RegisterFeatureFlags registers all the specified feature flags
+func RegisterFeatureFlags(flags ...FeatureFlag) error {
+	for i := range flags {
+		if err := RegisterFeatureFlag(flags[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnregisterFeatureFlag removes a registered feature flag
+func UnregisterFeatureFlag(plugin, key string) {
+	featureFlagRegistry.Lock()
+	defer featureFlagRegistry.Unlock()
+	delete(featureFlagRegistry.flags, featureFlagRegistryKey(plugin, key))
+	delete(featureFlagRegistry.warned, featureFlagRegistryKey(plugin, key))
+}
+
+// GetRegisteredFeatureFlag returns the registered feature flag for the specified plugin and key
+func GetRegisteredFeatureFlag(plugin, key string) (*FeatureFlag, bool) {
+	featureFlagRegistry.RLock()
+	defer featureFlagRegistry.RUnlock()
+	flag, ok := featureFlagRegistry.flags[featureFlagRegistryKey(plugin, key)]
+	if !ok {
+		return nil, false
+	}
+	return &flag, true
+}
+
+// GetRegisteredFeatureFlags returns all registered feature flags sorted by plugin and key
+func GetRegisteredFeatureFlags() []FeatureFlag {
+	featureFlagRegistry.RLock()
+	defer featureFlagRegistry.RUnlock()
+	flags := make([]FeatureFlag, 0, len(featureFlagRegistry.flags))
+	for _, flag := range featureFlagRegistry.flags {
+		flags = append(flags, flag)
+	}
+	sortFeatureFlags(flags)
+	return flags
+}
+
+// GetFeatureBool returns the effective boolean value of the feature flag
+func GetFeatureBool(plugin, key string) (bool, error) {
+	val, err := getFeatureFlagValue(plugin, key, FeatureFlagTypeBool)
+	if err != nil {
+		return false, err
+	}
+	if IsFeatureCondition(val) {
+		cfg, err := GetClientConfig()
+		if err != nil {
+			return false, err
+		}
+		return EvaluateFeatureCondition(plugin, key, val, newFeatureEvaluationContext(cfg))
+	}
+	return strconv.ParseBool(val)
+}
+
+// GetFeatureString returns the effective string value of the feature flag
+func GetFeatureString(plugin, key string) (string, error) {
+	return getFeatureFlagValue(plugin, key, FeatureFlagTypeString)
+}
+
+// GetFeatureInt returns the effective integer value of the feature flag
+func GetFeatureInt(plugin, key string) (int, error) {
+	val, err := getFeatureFlagValue(plugin, key, FeatureFlagTypeInt)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(val)
+}
+
+// GetFeatureDuration returns the effective duration value of the feature flag
+func GetFeatureDuration(plugin, key string) (time.Duration, error) {
+	val, err := getFeatureFlagValue(plugin, key, FeatureFlagTypeDuration)
+	if err != nil {
+		return 0, err
+	}
+	return time.ParseDuration(val)
+}
+
+// ListFeatureFlags returns all registered feature flags and all feature flags found in the config file
+// along with their effective values
+func ListFeatureFlags() ([]FeatureFlagValue, error) {
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return listFeatureFlags(cfg)
+}
+
+func listFeatureFlags(cfg *configtypes.ClientConfig) ([]FeatureFlagValue, error) {
+	values := make([]FeatureFlagValue, 0)
+	seen := make(map[string]bool)
+	for _, flag := range GetRegisteredFeatureFlags() {
+		value := FeatureFlagValue{FeatureFlag: flag, Value: flag.Default, Source: FeatureFlagSourceDefault, Registered: true}
+		if cfg.ClientOptions != nil && cfg.ClientOptions.Features != nil && cfg.ClientOptions.Features[flag.Plugin] != nil {
+			if val, ok := cfg.ClientOptions.Features[flag.Plugin][flag.Name]; ok {
+				value.Value = val
+				value.Source = FeatureFlagSourceConfig
+			}
+		}
+		seen[featureFlagRegistryKey(flag.Plugin, flag.Name)] = true
+		values = append(values, value)
+	}
+
+	// Include unregistered feature flags configured in the config file
+	if cfg.ClientOptions != nil && cfg.ClientOptions.Features != nil {
+		for plugin, features := range cfg.ClientOptions.Features {
+			for key, val := range features {
+				if seen[featureFlagRegistryKey(plugin, key)] {
+					continue
+				}
+				values = append(values, FeatureFlagValue{
+					FeatureFlag: FeatureFlag{Plugin: plugin, Name: key},
+					Value:       val,
+					Source:      FeatureFlagSourceConfig,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].Plugin != values[j].Plugin {
+			return values[i].Plugin < values[j].Plugin
+		}
+		return values[i].Name < values[j].Name
+	})
+	return values, nil
+}
+
+// getFeatureFlagValue returns the configured value of the feature flag, falling back to the registered default
+func getFeatureFlagValue(plugin, key string, flagType FeatureFlagType) (string, error) {
+	flag, registered := GetRegisteredFeatureFlag(plugin, key)
+	if registered && flag.Type != flagType {
+		return "", errors.Errorf("feature flag %q is of type %q, not %q", key, flag.Type, flagType)
+	}
+	if registered {
+		warnIfFeatureFlagDeprecated(flag)
+	}
+
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
+	if err != nil {
+		return "", err
+	}
+	val, err := getFeature(cfg, plugin, key)
+	if err == nil {
+		if err := validateFeatureFlagValue(flagType, val); err != nil {
+			return "", errors.Wrapf(err, "invalid value for feature flag %q", key)
+		}
+		return val, nil
+	}
+	if registered && flag.Default != "" {
+		return flag.Default, nil
+	}
+	return "", err
+}
+
+// warnIfFeatureFlagDeprecated logs a warning the first time a deprecated feature flag is used within a process
+func warnIfFeatureFlagDeprecated(flag *FeatureFlag) {
+	if flag.Stage != FeatureFlagStageDeprecated {
+		return
+	}
+	featureFlagRegistry.Lock()
+	defer featureFlagRegistry.Unlock()
+	key := featureFlagRegistryKey(flag.Plugin, flag.Name)
+	if featureFlagRegistry.warned[key] {
+		return
+	}
+	featureFlagRegistry.warned[key] = true
+	msg := fmt.Sprintf("feature flag %q of plugin %q is deprecated", flag.Name, flag.Plugin)
+	if flag.DeprecationMessage != "" {
+		msg = fmt.Sprintf("%s: %s", msg, flag.DeprecationMessage)
+	}
+	log.Warning(msg)
+}
+
+func validateFeatureFlagValue(flagType FeatureFlagType, val string) error {
+	var err error
+	switch flagType {
+	case FeatureFlagTypeBool:
+		if IsFeatureCondition(val) {
+			_, err = parseFeatureCondition(val)
+		} else {
+			_, err = strconv.ParseBool(val)
+		}
+	case FeatureFlagTypeInt:
+		_, err = strconv.Atoi(val)
+	case FeatureFlagTypeDuration:
+		_, err = time.ParseDuration(val)
+	case FeatureFlagTypeString:
+	default:
+		err = errors.Errorf("unknown feature flag type %q", flagType)
+	}
+	return err
+}
+
+func sortFeatureFlags(flags []FeatureFlag) {
+	sort.SliceStable(flags, func(i, j int) bool {
+		if flags[i].Plugin != flags[j].Plugin {
+			return flags[i].Plugin < flags[j].Plugin
+		}
+		return flags[i].Name < flags[j].Name
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestRegisterFeatureFlag(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   FeatureFlag
+		errStr string
+	}{
+		{
+			name:   "empty plugin",
+			flag:   FeatureFlag{Name: "sample"},
+			errStr: "plugin cannot be empty",
+		},
+		{
+			name:   "empty key",
+			flag:   FeatureFlag{Plugin: "global"},
+			errStr: "key cannot be empty",
+		},
+		{
+			name:   "unknown stage",
+			flag:   FeatureFlag{Plugin: "global", Name: "sample", Stage: "unknown"},
+			errStr: "feature flag \"sample\" has unknown stage \"unknown\"",
+		},
+		{
+			name:   "invalid default",
+			flag:   FeatureFlag{Plugin: "global", Name: "sample", Type: FeatureFlagTypeInt, Default: "abc"},
+			errStr: "feature flag \"sample\" has invalid default",
+		},
+		{
+			name: "success",
+			flag: FeatureFlag{Plugin: "global", Name: "sample", Description: "sample feature"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			defer UnregisterFeatureFlag(tc.flag.Plugin, tc.flag.Name)
+			err := RegisterFeatureFlag(tc.flag)
+			if tc.errStr != "" {
+				assert.Contains(t, err.Error(), tc.errStr)
+				return
+			}
+			assert.NoError(t, err)
+			flag, ok := GetRegisteredFeatureFlag(tc.flag.Plugin, tc.flag.Name)
+			assert.True(t, ok)
+			assert.Equal(t, FeatureFlagTypeBool, flag.Type)
+			assert.Equal(t, FeatureFlagStageAlpha, flag.Stage)
+			assert.Equal(t, "false", flag.Default)
+		})
+	}
+}
+
+func TestTypedFeatureFlagGetters(t *testing.T) {
+	// setup
+	func() {
+		LocalDirName = TestLocalDirName
+	}()
+	defer func() {
+		cleanupDir(LocalDirName)
+	}()
+
+	flags := []FeatureFlag{
+		{Plugin: "registry-test", Name: "bool-flag", Type: FeatureFlagTypeBool, Default: "true"},
+		{Plugin: "registry-test", Name: "string-flag", Type: FeatureFlagTypeString, Default: "default"},
+		{Plugin: "registry-test", Name: "int-flag", Type: FeatureFlagTypeInt, Default: "3"},
+		{Plugin: "registry-test", Name: "duration-flag", Type: FeatureFlagTypeDuration, Default: "5m", Stage: FeatureFlagStageDeprecated},
+	}
+	err := RegisterFeatureFlags(flags...)
+	assert.NoError(t, err)
+	defer func() {
+		for _, flag := range flags {
+			UnregisterFeatureFlag(flag.Plugin, flag.Name)
+		}
+	}()
+
+	cfg := &configtypes.ClientConfig{
+		ClientOptions: &configtypes.ClientOptions{
+			Features: map[string]configtypes.FeatureMap{
+				"registry-test": {
+					"int-flag":   "10",
+					"unreg-flag": "true",
+				},
+			},
+		},
+	}
+	err = StoreClientConfig(cfg)
+	assert.NoError(t, err)
+
+	b, err := GetFeatureBool("registry-test", "bool-flag")
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	s, err := GetFeatureString("registry-test", "string-flag")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", s)
+
+	i, err := GetFeatureInt("registry-test", "int-flag")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, i)
+
+	d, err := GetFeatureDuration("registry-test", "duration-flag")
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, d)
+
+	_, err = GetFeatureInt("registry-test", "bool-flag")
+	assert.Equal(t, "feature flag \"bool-flag\" is of type \"bool\", not \"int\"", err.Error())
+
+	b, err = GetFeatureBool("registry-test", "unreg-flag")
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	_, err = GetFeatureBool("registry-test", "missing-flag")
+	assert.Equal(t, "not found", err.Error())
+
+	allValues, err := ListFeatureFlags()
+	assert.NoError(t, err)
+	var values []FeatureFlagValue
+	for idx := range allValues {
+		if allValues[idx].Plugin == "registry-test" {
+			values = append(values, allValues[idx])
+		}
+	}
+	assert.Len(t, values, 5)
+	assert.Equal(t, "bool-flag", values[0].Name)
+	assert.Equal(t, FeatureFlagSourceDefault, values[0].Source)
+	assert.Equal(t, "int-flag", values[2].Name)
+	assert.Equal(t, "10", values[2].Value)
+	assert.Equal(t, FeatureFlagSourceConfig, values[2].Source)
+	assert.Equal(t, "unreg-flag", values[4].Name)
+	assert.False(t, values[4].Registered)
+}
@@ -4,33 +4,20 @@
 package config
 
 import (
-	"strconv"
-	"strings"
-
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
 	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
 )
 
 // IsFeatureEnabled checks and returns whether specific plugin and key is true
+// Conditional feature values are evaluated against the current config and machine ID
 func IsFeatureEnabled(plugin, key string) (bool, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
-	if err != nil {
-		return false, err
-	}
-	val, err := getFeature(node, plugin, key)
-	if err != nil {
-		return false, err
-	}
-	if strings.EqualFold(val, "true") {
-		return true, nil
-	}
-	return false, nil
+	return IsFeatureEnabledWithContext(plugin, key, nil)
 }
 
-func getFeature(node *yaml.Node, plugin, key string) (string, error) {
+func getFeature(cfg *configtypes.ClientConfig, plugin, key string) (string, error) {
 	// check if plugin is empty
 	if plugin == "" {
 		return "", errors.New("plugin cannot be empty")
@@ -41,10 +28,6 @@ func getFeature(node *yaml.Node, plugin, key string) (string, error) {
 		return "", errors.New("key cannot be empty")
 	}
 
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return "", err
-	}
 	if cfg.ClientOptions == nil || cfg.ClientOptions.Features == nil || cfg.ClientOptions.Features[plugin] == nil {
 		return "", errors.New("not found")
 	}
@@ -56,8 +39,14 @@ func getFeature(node *yaml.Node, plugin, key string) (string, error) {
 
 // DeleteFeature deletes the specified plugin key
 func DeleteFeature(plugin, key string) error {
+	if err := checkPolicyLocked(featurePolicyPath(plugin, key)); err != nil {
+		return err
+	}
+
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -67,7 +56,7 @@ func DeleteFeature(plugin, key string) error {
 	if err != nil {
 		return err
 	}
-	return persistConfig(node)
+	return persistConfig(node, "DeleteFeature")
 }
 
 func deleteFeature(node *yaml.Node, plugin, key string) error {
@@ -105,20 +94,32 @@ func deleteFeature(node *yaml.Node, plugin, key string) error {
 
 // SetFeature add or update plugin key value
 func SetFeature(plugin, key, value string) (err error) {
+	if err := checkPolicyLocked(featurePolicyPath(plugin, key)); err != nil {
+		return err
+	}
+
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
+	// Delete the features replaced as per the patch strategies
+	replaced, err := applyValuePatchStrategies(node, []string{KeyClientOptions, KeyFeatures, plugin, key}, value)
+	if err != nil {
+		return err
+	}
+
 	// Add or Update Feature plugin
 	persist, err := setFeature(node, plugin, key, value)
 	if err != nil {
 		return err
 	}
-	if persist {
-		return persistConfig(node)
+	if persist || replaced {
+		return persistConfig(node, "SetFeature")
 	}
 	return err
 }
@@ -162,31 +163,23 @@ func setFeature(node *yaml.Node, plugin, key, value string) (persist bool, err e
 	return persist, err
 }
 
-// ConfigureDefaultFeatureFlagsIfMissing add or update plugin features based on specified default feature flags
+// ConfigureDefaultFeatureFlagsIfMissing add plugin features based on specified default feature flags
+// Features that are already configured are left untouched
 func ConfigureDefaultFeatureFlagsIfMissing(plugin string, defaultFeatureFlags map[string]bool) error {
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
-	// find plugin node
-	keys := []nodeutils.Key{
-		{Name: KeyClientOptions, Type: yaml.MappingNode},
-		{Name: KeyFeatures, Type: yaml.MappingNode},
-		{Name: plugin, Type: yaml.MappingNode},
+	_, persist, err := applyDefaultFeatureFlags(node, plugin, nil, defaultFeatureFlags)
+	if err != nil {
+		return err
 	}
-	pluginNode := nodeutils.FindNode(node.Content[0], nodeutils.WithForceCreate(), nodeutils.WithKeys(keys))
-	if pluginNode == nil {
-		return nodeutils.ErrNodeNotFound
-	}
-	for key, value := range defaultFeatureFlags {
-		val := strconv.FormatBool(value)
-		if index := nodeutils.GetNodeIndex(pluginNode.Content, key); index != -1 {
-			pluginNode.Content[index].Value = val
-		} else {
-			pluginNode.Content = append(pluginNode.Content, nodeutils.CreateScalarNode(key, val)...)
-		}
+	if persist {
+		return persistConfig(node, "ConfigureDefaultFeatureFlagsIfMissing")
 	}
 	return nil
 }
@@ -198,6 +191,12 @@ func IsFeatureActivated(feature string) bool {
 	if err != nil {
 		return false
 	}
+	if plugin, key, err := cfg.SplitFeaturePath(feature); err == nil &&
+		cfg.ClientOptions != nil && cfg.ClientOptions.Features != nil &&
+		IsFeatureCondition(cfg.ClientOptions.Features[plugin][key]) {
+		status, err := EvaluateFeatureCondition(plugin, key, cfg.ClientOptions.Features[plugin][key], newFeatureEvaluationContext(cfg))
+		return err == nil && status
+	}
 	status, err := cfg.IsConfigFeatureActivated(feature)
 	if err != nil {
 		return false
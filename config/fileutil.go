@@ -100,3 +100,39 @@ func fileExists(filename string) (bool, error) {
 	}
 	return true, nil
 }
+
+// moveMissingFiles moves the entries of a directory tree recursively, skipping the files that already exist in the
+// destination directory, which are left in the source directory. The source directory is removed once it is empty.
+// Source directory must exist.
+func moveMissingFiles(src, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		dstInfo, err := os.Stat(dstPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil {
+			if entry.IsDir() && dstInfo.IsDir() {
+				if err := moveMissingFiles(srcPath, dstPath); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := os.Rename(srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+	// Entries that were skipped keep the source directory
+	_ = os.Remove(src)
+	return nil
+}
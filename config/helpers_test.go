@@ -5,6 +5,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,7 +17,7 @@ type CfgTestData struct {
 	cfgMetadata string
 }
 
-func setupTestConfig(t *testing.T, data *CfgTestData) (files []*os.File, cleanup func()) {
+func setupTestConfig(t testing.TB, data *CfgTestData) (files []*os.File, cleanup func()) {
 	// Setup config data
 	cfgFile, err := os.CreateTemp("", "tanzu_config")
 	assert.Nil(t, err)
@@ -42,7 +43,16 @@ func setupTestConfig(t *testing.T, data *CfgTestData) (files []*os.File, cleanup
 	err = os.Setenv(EnvConfigMetadataKey, cfgMetadataFile.Name())
 	assert.NoError(t, err)
 
+	// Keep the audit trail of the test config changes out of the local dir
+	auditDir, err := os.MkdirTemp("", "tanzu_config_audit")
+	assert.Nil(t, err)
+	err = os.Setenv(EnvConfigAuditFileKey, filepath.Join(auditDir, AuditFileName))
+	assert.NoError(t, err)
+
 	cleanup = func() {
+		err = os.RemoveAll(auditDir)
+		assert.NoError(t, err)
+
 		err = os.Remove(cfgFile.Name())
 		assert.NoError(t, err)
 
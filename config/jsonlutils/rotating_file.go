@@ -0,0 +1,152 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jsonlutils provides functions to append records to JSON lines files that are rotated by size
+package jsonlutils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/fslock"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LockFileSuffix is appended to the path of the file to get the path of its lock file
+	LockFileSuffix = ".lock"
+	// lockTimeout is how long appending or reading waits on the lock of the file
+	lockTimeout = 10 * time.Second
+)
+
+// RotatingFile is a JSON lines file that is rotated once appending a record would exceed MaxFileSize,
+// keeping MaxBackups rotated files e.g. audit.log.1, audit.log.2. Appending and reading take the lock file of the
+// file so that processes do not rotate the file concurrently.
+type RotatingFile struct {
+	// Path of the file
+	Path string
+	// MaxFileSize is the size in bytes after which the file is rotated
+	MaxFileSize int64
+	// MaxBackups is the number of rotated files that are kept, the file is truncated instead if it is zero
+	MaxBackups int
+	// Name describes the records in errors e.g. audit
+	Name string
+}
+
+// Append appends the record marshaled as JSON to the file, rotating the file first if needed
+func (f *RotatingFile) Append(record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the %s record", f.Name)
+	}
+	data = append(data, '\n')
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create the %s directory", f.Name)
+	}
+
+	lock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = lock.Unlock()
+	}()
+	if err := f.rotate(int64(len(data))); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open the %s file", f.Name)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write the %s record", f.Name)
+	}
+	return nil
+}
+
+// Read calls fn with each line of the rotated files and of the file, oldest first. It is a no-op if the file
+// was never written.
+func (f *RotatingFile) Read(fn func(line []byte)) error {
+	if _, err := os.Stat(filepath.Dir(f.Path)); os.IsNotExist(err) {
+		return nil
+	}
+	lock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = lock.Unlock()
+	}()
+	for i := f.MaxBackups; i >= 0; i-- {
+		if err := f.readFile(f.BackupPath(i), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackupPath returns the path of the rotated file, the file itself for index 0
+func (f *RotatingFile) BackupPath(index int) string {
+	if index == 0 {
+		return f.Path
+	}
+	return fmt.Sprintf("%s.%d", f.Path, index)
+}
+
+func (f *RotatingFile) lock() (*fslock.Lock, error) {
+	lock := fslock.New(f.Path + LockFileSuffix)
+	if err := lock.LockWithTimeout(lockTimeout); err != nil {
+		return nil, errors.Wrapf(err, "failed to acquire the lock of the %s file", f.Name)
+	}
+	return lock, nil
+}
+
+// rotate rotates the file if appending size bytes would exceed MaxFileSize
+func (f *RotatingFile) rotate(size int64) error {
+	info, err := os.Stat(f.Path)
+	if err != nil || info.Size()+size <= f.MaxFileSize {
+		return nil
+	}
+	if f.MaxBackups <= 0 {
+		return errors.Wrapf(os.Truncate(f.Path, 0), "failed to truncate the %s file", f.Name)
+	}
+	_ = os.Remove(f.BackupPath(f.MaxBackups))
+	for i := f.MaxBackups - 1; i >= 0; i-- {
+		if err := os.Rename(f.BackupPath(i), f.BackupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to rotate the %s file", f.Name)
+		}
+	}
+	return nil
+}
+
+func (f *RotatingFile) readFile(path string, fn func(line []byte)) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to open the %s file", f.Name)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			fn(bytes.TrimSuffix(line, []byte("\n")))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the %s file", f.Name)
+		}
+	}
+}
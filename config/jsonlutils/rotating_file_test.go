@@ -0,0 +1,79 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonlutils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRecord struct {
+	ID int `json:"id"`
+}
+
+func readTestRecords(t *testing.T, f *RotatingFile) []int {
+	var ids []int
+	assert.NoError(t, f.Read(func(line []byte) {
+		var record testRecord
+		if err := json.Unmarshal(line, &record); err == nil {
+			ids = append(ids, record.ID)
+		}
+	}))
+	return ids
+}
+
+func TestRotatingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	// Reading a file that was never written returns nothing
+	f := &RotatingFile{Path: filepath.Join(t.TempDir(), "records", "records.jsonl"), MaxFileSize: 20, MaxBackups: 2, Name: "test"}
+	assert.Empty(readTestRecords(t, f))
+
+	// The file is rotated once appending a record would exceed the maximum size, and the oldest records are dropped
+	for i := 1; i <= 7; i++ {
+		assert.NoError(f.Append(&testRecord{ID: i}))
+	}
+	assert.FileExists(f.BackupPath(1))
+	assert.FileExists(f.BackupPath(2))
+	assert.NoFileExists(f.BackupPath(3))
+	assert.Equal([]int{3, 4, 5, 6, 7}, readTestRecords(t, f))
+
+	// Partially written records are left to the caller
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_WRONLY, 0600)
+	assert.NoError(err)
+	_, err = file.WriteString(`{"id": `)
+	assert.NoError(err)
+	assert.NoError(file.Close())
+	assert.Equal([]int{3, 4, 5, 6, 7}, readTestRecords(t, f))
+
+	// Without backups the file is truncated
+	f = &RotatingFile{Path: filepath.Join(t.TempDir(), "records.jsonl"), MaxFileSize: 20, Name: "test"}
+	for i := 1; i <= 3; i++ {
+		assert.NoError(f.Append(&testRecord{ID: i}))
+	}
+	assert.NoFileExists(f.BackupPath(1))
+	assert.Equal([]int{3}, readTestRecords(t, f))
+}
+
+func TestRotatingFileConcurrentAppend(t *testing.T) {
+	// Rotations of concurrent writers do not lose records
+	f := &RotatingFile{Path: filepath.Join(t.TempDir(), "records.jsonl"), MaxFileSize: 100, MaxBackups: 100, Name: "test"}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, f.Append(&testRecord{ID: writer*10 + j}))
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, readTestRecords(t, f), 100)
+}
@@ -0,0 +1,368 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+// ConfigLayer is a source of configuration merged into the layered config
+type ConfigLayer string
+
+const (
+	// ConfigLayerSystem is the system-wide config e.g. /etc/tanzu/config.yaml, providing defaults for all users
+	ConfigLayerSystem ConfigLayer = "system"
+	// ConfigLayerUser is the config of the user stored under LocalDir
+	ConfigLayerUser ConfigLayer = "user"
+	// ConfigLayerProject is the project-local .tanzu/config.yaml found in the working directory or its parents,
+	// only merged when opted in with TANZU_CONFIG_USE_PROJECT
+	ConfigLayerProject ConfigLayer = "project"
+	// ConfigLayerPolicy is the policy config e.g. /etc/tanzu/policy.yaml, whose values cannot be overridden
+	ConfigLayerPolicy ConfigLayer = "policy"
+)
+
+const (
+	// EnvSystemConfigKey is the environment variable that points to the system-wide tanzu config
+	EnvSystemConfigKey = "TANZU_SYSTEM_CONFIG"
+	// EnvConfigUseProjectKey is the environment variable that opts in the project-local config layer
+	EnvConfigUseProjectKey = "TANZU_CONFIG_USE_PROJECT"
+	// ProjectConfigDirName is the name of the directory holding the project-local config
+	ProjectConfigDirName = ".tanzu"
+)
+
+var (
+	// SystemConfigDir is the directory in which the system-wide tanzu config is stored
+	SystemConfigDir = "/etc/tanzu"
+	// ConfigLayers are the config layers in the order they are merged into the config read by the getters
+	// e.g. GetContext, later layers override earlier ones
+	ConfigLayers = []ConfigLayer{ConfigLayerSystem, ConfigLayerUser, ConfigLayerProject, ConfigLayerPolicy}
+	// ProjectConfigKeys are the dotted paths of the settings the project config may set, along with the settings
+	// nested under them. Other settings of the project config are ignored so that the config of a checked out
+	// repository cannot redirect the CLI to other contexts, servers or discovery sources.
+	ProjectConfigKeys = []string{"clientOptions.features"}
+)
+
+// LayeredValue is a config value along with the layer that provided it
+type LayeredValue struct {
+	// Path addresses the value as described by nodeutils.Change e.g. contexts[test-mc].clusterOpts.endpoint
+	Path  string      `json:"path" yaml:"path"`
+	Value string      `json:"value" yaml:"value"`
+	Layer ConfigLayer `json:"layer" yaml:"layer"`
+}
+
+// SystemConfigPath returns the path of the system-wide config, checking for environment overrides
+func SystemConfigPath() string {
+	if path, ok := os.LookupEnv(EnvSystemConfigKey); ok {
+		return path
+	}
+	return filepath.Join(SystemConfigDir, ConfigName)
+}
+
+// ProjectConfigPath returns the path of the project-local config found by walking up from the working directory.
+// It returns false if there is none or if the project config is not opted in with TANZU_CONFIG_USE_PROJECT.
+// The home directory is skipped since its .tanzu directory is the legacy user config directory.
+func ProjectConfigPath() (string, bool, error) {
+	if !useProjectConfig() {
+		return "", false, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false, errors.Wrap(err, "failed to get the working directory")
+	}
+	home, _ := os.UserHomeDir()
+	for {
+		if filepath.Clean(dir) != filepath.Clean(home) {
+			path := filepath.Join(dir, ProjectConfigDirName, ConfigName)
+			if exists, err := fileExists(path); err == nil && exists {
+				return path, true, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false, nil
+		}
+		dir = parent
+	}
+}
+
+func useProjectConfig() bool {
+	useProject, _ := strconv.ParseBool(os.Getenv(EnvConfigUseProjectKey))
+	return useProject
+}
+
+// GetLayeredClientConfig returns the config merged from the system, user, project and policy layers in order
+func GetLayeredClientConfig() (*configtypes.ClientConfig, error) {
+	return getDecodedClientConfig()
+}
+
+// GetLayeredConfigValues returns the values of the layered config along with the layer that provided each one
+func GetLayeredConfigValues() ([]LayeredValue, error) {
+	var paths []string
+	values := make(map[string]LayeredValue)
+	for _, layer := range ConfigLayers {
+		node, err := getConfigLayerNode(layer)
+		if err != nil {
+			return nil, err
+		}
+		for _, leaf := range nodeutils.Leaves(node) {
+			if _, ok := values[leaf.Path]; !ok {
+				paths = append(paths, leaf.Path)
+			}
+			values[leaf.Path] = LayeredValue{Path: leaf.Path, Value: leaf.Node.Value, Layer: layer}
+		}
+	}
+	result := make([]LayeredValue, 0, len(paths))
+	for _, path := range paths {
+		result = append(result, values[path])
+	}
+	return result, nil
+}
+
+// GetLayeredConfigValue returns the value at the path of the layered config along with the layer that provided it
+func GetLayeredConfigValue(path string) (*LayeredValue, error) {
+	values, err := GetLayeredConfigValues()
+	if err != nil {
+		return nil, err
+	}
+	for i := range values {
+		if values[i].Path == path {
+			return &values[i], nil
+		}
+	}
+	return nil, errors.Errorf("config value %q not found", path)
+}
+
+// SetLayeredConfigValue sets the scalar value at the dotted path e.g. cli.edition in the config of the layer.
+// Only scalars nested in mappings can be set, the path cannot address a mapping, a sequence or the items of a
+// sequence such as contexts; use the setters e.g. SetContext to update the user layer instead.
+// Writes to the project layer go to the project config found by ProjectConfigPath, or to a new
+// .tanzu/config.yaml in the working directory, and are limited to the settings under ProjectConfigKeys.
+// Values locked by the policy config can only be set in the policy layer.
+func SetLayeredConfigValue(layer ConfigLayer, path, value string) error {
+	keys, err := layeredConfigKeys(path)
+	if err != nil {
+		return err
+	}
+	if layer == ConfigLayerProject {
+		if !useProjectConfig() {
+			return errors.Errorf("the project config is not enabled, set %s to use it", EnvConfigUseProjectKey)
+		}
+		if !isProjectConfigKey(path) {
+			return errors.Errorf("config value %q cannot be set in the project config, only %s can be set", path, strings.Join(ProjectConfigKeys, ", "))
+		}
+	}
+	if layer != ConfigLayerPolicy {
+		if err := checkPolicyLocked(path); err != nil {
+			return err
+		}
+	}
+	if layer == ConfigLayerUser {
+		if err := acquireTanzuConfigLock(); err != nil {
+			return err
+		}
+		defer ReleaseTanzuConfigLock()
+		node, err := getClientConfigNodeNoLock()
+		if err != nil {
+			return err
+		}
+		persist, err := setLayeredConfigScalar(node, keys, value)
+		if err != nil || !persist {
+			return err
+		}
+		return persistConfig(node, "SetLayeredConfigValue")
+	}
+
+	cfgPath, err := configLayerPath(layer, true)
+	if err != nil {
+		return err
+	}
+	node, err := readConfigLayerFile(cfgPath)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		if node, err = newClientConfigNode(); err != nil {
+			return err
+		}
+	}
+	persist, err := setLayeredConfigScalar(node, keys, value)
+	if err != nil || !persist {
+		return err
+	}
+	return persistNode(node, WithCfgPath(cfgPath))
+}
+
+// getLayeredConfigNode merges the config layers in order, matching keyed sequences such as contexts by name.
+// The config of the user is returned as is if the other layers have no config.
+func getLayeredConfigNode(layers []ConfigLayer) (*yaml.Node, error) {
+	var merged *yaml.Node
+	for _, layer := range layers {
+		node, err := getConfigLayerNode(layer)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			continue
+		}
+		if merged == nil {
+			merged = node
+			continue
+		}
+		if _, err := nodeutils.MergeNodes(node, merged, nodeutils.WithSequenceKeys(nodeutils.DefaultSequenceKeys)); err != nil {
+			return nil, errors.Wrapf(err, "failed to merge the %s config", layer)
+		}
+	}
+	if merged == nil {
+		return newClientConfigNode()
+	}
+	return merged, nil
+}
+
+// getConfigLayerNode returns the config node of the layer, or nil if the layer has no config
+func getConfigLayerNode(layer ConfigLayer) (*yaml.Node, error) {
+	if layer == ConfigLayerUser {
+		return getUserConfigNode()
+	}
+	path, err := configLayerPath(layer, false)
+	if err != nil || path == "" {
+		return nil, err
+	}
+	node, err := readConfigLayerFile(path)
+	if err != nil || node == nil || layer != ConfigLayerProject {
+		return node, err
+	}
+	return filterProjectConfigNode(node)
+}
+
+// isProjectConfigKey checks whether the setting at the dotted path may be set by the project config
+func isProjectConfigKey(path string) bool {
+	for _, key := range ProjectConfigKeys {
+		if path == key || strings.HasPrefix(path, key+nodeutils.PatchStrategyKeySeparator) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterProjectConfigNode returns a copy of the project config node holding only the settings under ProjectConfigKeys
+func filterProjectConfigNode(node *yaml.Node) (*yaml.Node, error) {
+	filtered, err := newClientConfigNode()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range ProjectConfigKeys {
+		keys := strings.Split(path, nodeutils.PatchStrategyKeySeparator)
+		src := node.Content[0]
+		for _, key := range keys {
+			if src == nil || src.Kind != yaml.MappingNode {
+				src = nil
+				break
+			}
+			index := nodeutils.GetNodeIndex(src.Content, key)
+			if index == -1 {
+				src = nil
+				break
+			}
+			src = src.Content[index]
+		}
+		if src == nil {
+			continue
+		}
+		dst := filtered.Content[0]
+		for _, key := range keys[:len(keys)-1] {
+			index := nodeutils.GetNodeIndex(dst.Content, key)
+			if index == -1 {
+				dst.Content = append(dst.Content, nodeutils.CreateMappingNode(key)...)
+				index = len(dst.Content) - 1
+			}
+			dst = dst.Content[index]
+		}
+		dst.Content = append(dst.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: keys[len(keys)-1]}, nodeutils.CopyNode(src))
+	}
+	return filtered, nil
+}
+
+// configLayerPath returns the config path of the system, project or policy layer. Without create, it returns an empty path
+// if there is no project config.
+func configLayerPath(layer ConfigLayer, create bool) (string, error) {
+	switch layer {
+	case ConfigLayerSystem:
+		return SystemConfigPath(), nil
+	case ConfigLayerPolicy:
+		return PolicyConfigPath(), nil
+	case ConfigLayerProject:
+		path, found, err := ProjectConfigPath()
+		if err != nil || found || !create {
+			return path, err
+		}
+		dir, err := os.Getwd()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get the working directory")
+		}
+		return filepath.Join(dir, ProjectConfigDirName, ConfigName), nil
+	}
+	return "", errors.Errorf("unknown config layer %q", layer)
+}
+
+// readConfigLayerFile returns the config node stored in the file, or nil if the file does not exist, is unreadable
+// or is empty. The parsed file is cached as long as it is unchanged.
+func readConfigLayerFile(path string) (*yaml.Node, error) {
+	node, err := readConfigFileNode(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the config %s", path)
+	}
+	return node, nil
+}
+
+func layeredConfigKeys(path string) ([]string, error) {
+	keys := strings.Split(path, nodeutils.PatchStrategyKeySeparator)
+	for _, key := range keys {
+		if key == "" {
+			return nil, errors.Errorf("invalid config path %q", path)
+		}
+	}
+	return keys, nil
+}
+
+// setLayeredConfigScalar sets the scalar at the keys, creating missing mappings. It returns true if the node changed.
+// It returns an error if the keys address a mapping or a sequence, or go through a node that is not a mapping.
+func setLayeredConfigScalar(node *yaml.Node, keys []string, value string) (bool, error) {
+	parent := node.Content[0]
+	for i, key := range keys[:len(keys)-1] {
+		index := nodeutils.GetNodeIndex(parent.Content, key)
+		if index == -1 {
+			parent.Content = append(parent.Content, nodeutils.CreateMappingNode(key)...)
+			parent = parent.Content[len(parent.Content)-1]
+			continue
+		}
+		if parent.Content[index].Kind != yaml.MappingNode {
+			return false, errors.Errorf("config value %q is not a mapping, only scalars nested in mappings can be set", strings.Join(keys[:i+1], nodeutils.PatchStrategyKeySeparator))
+		}
+		parent = parent.Content[index]
+	}
+	key := keys[len(keys)-1]
+	if index := nodeutils.GetNodeIndex(parent.Content, key); index != -1 {
+		current := parent.Content[index]
+		if current.Kind != yaml.ScalarNode {
+			return false, errors.Errorf("config value %q is not a scalar, only scalars nested in mappings can be set", strings.Join(keys, nodeutils.PatchStrategyKeySeparator))
+		}
+		if current.Value == value {
+			return false, nil
+		}
+		// Update the value in place to keep the comments and style of the node
+		current.Value = value
+		return true, nil
+	}
+	parent.Content = append(parent.Content, nodeutils.CreateScalarNode(key, value)...)
+	return true, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestLayeredConfig(t *testing.T) {
+	cfgNextGen := `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: user-endpoint
+      path: user-path
+cli:
+  ceipOptIn: "true"
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfgNextGen: cfgNextGen})
+	defer cleanUp()
+
+	systemConfig := filepath.Join(t.TempDir(), ConfigName)
+	require.NoError(t, os.WriteFile(systemConfig, []byte(`contexts:
+  - name: test-mc
+    clusterOpts:
+      endpoint: system-endpoint
+      context: system-context
+  - name: system-ctx
+    target: kubernetes
+cli:
+  ceipOptIn: "false"
+  eulaStatus: accepted
+`), 0644))
+	t.Setenv(EnvSystemConfigKey, systemConfig)
+
+	// The project config is found by walking up from the working directory once opted in
+	projectDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, ProjectConfigDirName), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ProjectConfigDirName, ConfigName), []byte(`contexts:
+  - name: test-mc
+    clusterOpts:
+      endpoint: project-endpoint
+clientOptions:
+  features:
+    global:
+      project-feature: "true"
+`), 0644))
+	workingDir := filepath.Join(projectDir, "src", "app")
+	require.NoError(t, os.MkdirAll(workingDir, 0755))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(workingDir))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	t.Setenv(EnvConfigUseProjectKey, "")
+	_, found, err := ProjectConfigPath()
+	assert.NoError(t, err)
+	assert.False(t, found)
+	_, err = IsFeatureEnabled("global", "project-feature")
+	assert.Error(t, err)
+	assert.EqualError(t, SetLayeredConfigValue(ConfigLayerProject, "clientOptions.features.global.other-feature", "true"), "the project config is not enabled, set TANZU_CONFIG_USE_PROJECT to use it")
+
+	t.Setenv(EnvConfigUseProjectKey, "true")
+	path, found, err := ProjectConfigPath()
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, filepath.Join(projectDir, ProjectConfigDirName, ConfigName), path)
+
+	// The project config only sets the features, the user config overrides the system config
+	cfg, err := GetLayeredClientConfig()
+	assert.NoError(t, err)
+	require.Len(t, cfg.KnownContexts, 2)
+	assert.Equal(t, "test-mc", cfg.KnownContexts[0].Name)
+	assert.Equal(t, "user-endpoint", cfg.KnownContexts[0].ClusterOpts.Endpoint)
+	assert.Equal(t, "user-path", cfg.KnownContexts[0].ClusterOpts.Path)
+	assert.Equal(t, "system-context", cfg.KnownContexts[0].ClusterOpts.Context)
+	assert.Equal(t, configtypes.TargetK8s, cfg.KnownContexts[0].Target)
+	assert.Equal(t, "true", cfg.CoreCliOptions.CEIPOptIn)
+	assert.Equal(t, "accepted", cfg.CoreCliOptions.EULAStatus)
+
+	value, err := GetLayeredConfigValue("contexts[test-mc].clusterOpts.endpoint")
+	assert.NoError(t, err)
+	assert.Equal(t, LayeredValue{Path: "contexts[test-mc].clusterOpts.endpoint", Value: "user-endpoint", Layer: ConfigLayerUser}, *value)
+	value, err = GetLayeredConfigValue("clientOptions.features.global.project-feature")
+	assert.NoError(t, err)
+	assert.Equal(t, LayeredValue{Path: "clientOptions.features.global.project-feature", Value: "true", Layer: ConfigLayerProject}, *value)
+	value, err = GetLayeredConfigValue("cli.eulaStatus")
+	assert.NoError(t, err)
+	assert.Equal(t, ConfigLayerSystem, value.Layer)
+	value, err = GetLayeredConfigValue("cli.ceipOptIn")
+	assert.NoError(t, err)
+	assert.Equal(t, LayeredValue{Path: "cli.ceipOptIn", Value: "true", Layer: ConfigLayerUser}, *value)
+	_, err = GetLayeredConfigValue("cli.missing")
+	assert.EqualError(t, err, "config value \"cli.missing\" not found")
+
+	// Writes go to the chosen layer
+	assert.NoError(t, SetLayeredConfigValue(ConfigLayerProject, "clientOptions.features.global.project-feature", "false"))
+	assert.EqualError(t, SetLayeredConfigValue(ConfigLayerProject, "cli.ceipOptIn", "false"), "config value \"cli.ceipOptIn\" cannot be set in the project config, only clientOptions.features can be set")
+	assert.NoError(t, SetLayeredConfigValue(ConfigLayerSystem, "clientOptions.features.global.system-feature", "true"))
+	assert.NoError(t, SetLayeredConfigValue(ConfigLayerSystem, "cli.ceipOptIn", "true"))
+	value, err = GetLayeredConfigValue("clientOptions.features.global.project-feature")
+	assert.NoError(t, err)
+	assert.Equal(t, LayeredValue{Path: "clientOptions.features.global.project-feature", Value: "false", Layer: ConfigLayerProject}, *value)
+	value, err = GetLayeredConfigValue("clientOptions.features.global.system-feature")
+	assert.NoError(t, err)
+	assert.Equal(t, ConfigLayerSystem, value.Layer)
+	assert.NoError(t, SetLayeredConfigValue(ConfigLayerUser, "cli.ceipOptIn", "false"))
+
+	// The getters read the layered config
+	mergedCfg, err := GetClientConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "false", mergedCfg.CoreCliOptions.CEIPOptIn)
+	ceipOptIn, err := GetCEIPOptIn()
+	assert.NoError(t, err)
+	assert.Equal(t, "false", ceipOptIn)
+	enabled, err := IsFeatureEnabled("global", "system-feature")
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	enabled, err = IsFeatureEnabled("global", "project-feature")
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	ctx, err := GetContext("test-mc")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-endpoint", ctx.ClusterOpts.Endpoint)
+	assert.Equal(t, "system-context", ctx.ClusterOpts.Context)
+	assert.Equal(t, "user-path", ctx.ClusterOpts.Path)
+
+	// Contexts set by the other layers cannot be removed
+	assert.EqualError(t, RemoveContext("system-ctx"), "context system-ctx is set by the system config and cannot be removed")
+	assert.EqualError(t, RemoveContext("test-mc"), "context test-mc is set by the system config and cannot be removed")
+	_, err = GetContext("test-mc")
+	assert.NoError(t, err)
+
+	// The setters only write the user config
+	assert.NoError(t, SetLayeredConfigValue(ConfigLayerUser, "cli.eulaStatus", "shown"))
+	eulaStatus, err := GetEULAStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, EULAStatusShown, eulaStatus)
+	assert.NoError(t, SetEnv("TEST_ENV", "value"))
+	userCfg, err := GetLegacyClientConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"TEST_ENV": "value"}, userCfg.ClientOptions.Env)
+	assert.Nil(t, userCfg.ClientOptions.Features)
+	data, err := os.ReadFile(os.Getenv(EnvConfigNextGenKey))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "system-context")
+
+	assert.EqualError(t, SetLayeredConfigValue(ConfigLayerUser, "cli..ceipOptIn", "x"), "invalid config path \"cli..ceipOptIn\"")
+	assert.EqualError(t, SetLayeredConfigValue(ConfigLayerUser, "contexts", "x"), "config value \"contexts\" is not a scalar, only scalars nested in mappings can be set")
+	assert.EqualError(t, SetLayeredConfigValue(ConfigLayerUser, "cli.ceipOptIn.value", "x"), "config value \"cli.ceipOptIn\" is not a mapping, only scalars nested in mappings can be set")
+	assert.EqualError(t, SetLayeredConfigValue("unknown", "cli.ceipOptIn", "x"), "unknown config layer \"unknown\"")
+}
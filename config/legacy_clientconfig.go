@@ -57,6 +57,10 @@ func storeConfigToLegacyDir(data []byte) {
 		}
 	}()
 
+	// The legacy location only mirrors the config of the default profile
+	if !isDefaultProfileActive() {
+		return
+	}
 	legacyDir, err = legacyLocalDir()
 	if err != nil {
 		return
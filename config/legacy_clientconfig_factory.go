@@ -14,18 +14,8 @@ import (
 
 // GetClientConfig retrieves the config from the local directory with file lock
 func GetClientConfig() (cfg *configtypes.ClientConfig, err error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
-	if err != nil {
-		return nil, err
-	}
-
-	cfg, err = convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
-
-	return cfg, nil
+	// Retrieve client config
+	return getDecodedClientConfig()
 }
 
 // GetClientConfigNoLock retrieves the config from the local directory without acquiring the lock
@@ -78,7 +68,7 @@ func StoreClientConfig(cfg *configtypes.ClientConfig) error {
 	if err != nil {
 		return err
 	}
-	return persistConfig(node)
+	return persistConfig(node, "StoreClientConfig")
 }
 
 func clientConfigSetClientOptions(cfg *configtypes.ClientConfig, node *yaml.Node) error {
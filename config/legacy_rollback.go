@@ -0,0 +1,113 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/collectionutils"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+// GetLegacyClientConfig reconstructs the standalone legacy config from the current config.
+// It only holds the items of LegacyConfigNodeKeys, with servers converted from the contexts that have no
+// matching server and the current server set from the current contexts as done by populateServers.
+func GetLegacyClientConfig() (*configtypes.ClientConfig, error) {
+	node, err := getUserConfigNode()
+	if err != nil {
+		return nil, err
+	}
+	legacyNode, err := legacyClientConfigNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return convertNodeToClientConfig(legacyNode)
+}
+
+// RollbackToLegacyConfig re-splits the config so that an older CLI can be used temporarily.
+// The legacy items are written to a standalone config.yaml built as by GetLegacyClientConfig, and to the legacy
+// config location if it exists, while config-ng.yaml keeps all the other items. useUnifiedConfig is turned off
+// afterwards so that reads combine both files again.
+func RollbackToLegacyConfig() error {
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuConfigLock()
+	node, err := getClientConfigNodeNoLock()
+	if err != nil {
+		return err
+	}
+
+	legacyNode, err := legacyClientConfigNode(node)
+	if err != nil {
+		return err
+	}
+	nextGenNode, err := newClientConfigNode()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content[0].Content); i += 2 {
+		if !collectionutils.Contains(LegacyConfigNodeKeys, node.Content[0].Content[i].Value) {
+			nextGenNode.Content[0].Content = append(nextGenNode.Content[0].Content, node.Content[0].Content[i:i+2]...)
+		}
+	}
+
+	if err := persistClientConfig(legacyNode); err != nil {
+		return err
+	}
+	if err := persistClientConfigNextGen(nextGenNode); err != nil {
+		return err
+	}
+	if err := persistLegacyClientConfig(legacyNode); err != nil {
+		return err
+	}
+	if err := SetConfigMetadataSetting(SettingUseUnifiedConfig, "false"); err != nil {
+		return errors.Wrap(err, "failed to turn off the unified config")
+	}
+	return nil
+}
+
+// legacyClientConfigNode returns a config node with the legacy items of the node.
+// Converted servers are appended to the existing servers, whose nodes are kept as is.
+func legacyClientConfigNode(node *yaml.Node) (*yaml.Node, error) {
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &configtypes.ClientConfig{}
+	}
+	existingServers := len(cfg.KnownServers)
+	populateServers(cfg)
+
+	legacyNode, err := newClientConfigNode()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range LegacyConfigNodeKeys {
+		if index := nodeutils.GetNodeIndex(node.Content[0].Content, key); index != -1 {
+			legacyNode.Content[0].Content = append(legacyNode.Content[0].Content, node.Content[0].Content[index-1:index+1]...)
+		}
+	}
+
+	for _, server := range cfg.KnownServers[existingServers:] {
+		serverNode, err := convertObjectToNode(server)
+		if err != nil {
+			return nil, err
+		}
+		keys := []nodeutils.Key{{Name: KeyServers, Type: yaml.SequenceNode}}
+		serversNode := nodeutils.FindNode(legacyNode.Content[0], nodeutils.WithForceCreate(), nodeutils.WithKeys(keys))
+		if serversNode == nil {
+			return nil, nodeutils.ErrNodeNotFound
+		}
+		serversNode.Content = append(serversNode.Content, serverNode.Content[0])
+	}
+
+	if cfg.CurrentServer != "" && nodeutils.GetNodeIndex(legacyNode.Content[0].Content, KeyCurrentServer) == -1 {
+		legacyNode.Content[0].Content = append(legacyNode.Content[0].Content, nodeutils.CreateScalarNode(KeyCurrentServer, cfg.CurrentServer)...)
+	}
+	return legacyNode, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestRollbackToLegacyConfig(t *testing.T) {
+	// Config written by a newer CLI using the unified config
+	cfgNextGen := `clientOptions:
+  cli:
+    edition: tkg
+  features:
+    global:
+      context-target-v2: "true"
+servers:
+  - name: legacy-mc
+    type: managementcluster
+    managementClusterOpts:
+      endpoint: legacy-endpoint
+contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      isManagementCluster: true
+      endpoint: test-endpoint
+      path: test-path
+      context: test-context
+  - name: test-tmc
+    target: mission-control
+    globalOpts:
+      endpoint: tmc-endpoint
+currentContext:
+  kubernetes: test-mc
+  mission-control: test-tmc
+cli:
+  ceipOptIn: "true"
+`
+	files, cleanUp := setupTestConfig(t, &CfgTestData{cfgNextGen: cfgNextGen, cfgMetadata: setupConfigMetadataWithMigrateToNewConfig()})
+	defer cleanUp()
+
+	before, err := GetClientConfig()
+	require.NoError(t, err)
+
+	legacyCfg, err := GetLegacyClientConfig()
+	require.NoError(t, err)
+	assert.Empty(t, legacyCfg.KnownContexts)
+	assert.Empty(t, legacyCfg.CurrentContext)
+	assert.Nil(t, legacyCfg.CoreCliOptions)
+	assert.Equal(t, "test-mc", legacyCfg.CurrentServer)
+	require.Len(t, legacyCfg.KnownServers, 3)
+	assert.Equal(t, "legacy-mc", legacyCfg.KnownServers[0].Name)
+	assert.Equal(t, &configtypes.Server{
+		Name: "test-mc",
+		Type: configtypes.ManagementClusterServerType,
+		ManagementClusterOpts: &configtypes.ManagementClusterServer{
+			Endpoint: "test-endpoint",
+			Path:     "test-path",
+			Context:  "test-context",
+		},
+	}, legacyCfg.KnownServers[1])
+	assert.Equal(t, configtypes.GlobalServerType, legacyCfg.KnownServers[2].Type)
+	assert.Equal(t, "tmc-endpoint", legacyCfg.KnownServers[2].GlobalOpts.Endpoint)
+
+	require.NoError(t, RollbackToLegacyConfig())
+	useUnifiedConfig, err := UseUnifiedConfig()
+	assert.NoError(t, err)
+	assert.False(t, useUnifiedConfig)
+
+	// The older CLI only reads the standalone config.yaml
+	data, err := os.ReadFile(files[0].Name())
+	require.NoError(t, err)
+	var oldCLICfg configtypes.ClientConfig
+	require.NoError(t, yaml.Unmarshal(data, &oldCLICfg))
+	assert.Equal(t, legacyCfg, &oldCLICfg)
+
+	// The next gen only items are kept in config-ng.yaml
+	data, err = os.ReadFile(files[1].Name())
+	require.NoError(t, err)
+	var nextGenCfg configtypes.ClientConfig
+	require.NoError(t, yaml.Unmarshal(data, &nextGenCfg))
+	assert.Equal(t, before.KnownContexts, nextGenCfg.KnownContexts)
+	assert.Equal(t, before.CurrentContext, nextGenCfg.CurrentContext)
+	assert.Equal(t, before.CoreCliOptions, nextGenCfg.CoreCliOptions)
+	assert.Nil(t, nextGenCfg.ClientOptions)
+	assert.Empty(t, nextGenCfg.KnownServers)
+
+	// Reads combine both files again without losing anything
+	after, err := GetClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, before.KnownContexts, after.KnownContexts)
+	assert.Equal(t, before.CurrentContext, after.CurrentContext)
+	assert.Equal(t, before.CoreCliOptions, after.CoreCliOptions)
+	assert.Equal(t, before.ClientOptions, after.ClientOptions)
+	assert.Equal(t, legacyCfg.KnownServers, after.KnownServers)
+
+	// Changes made by the older CLI to config.yaml are picked up by the newer CLI
+	oldCLICfg.KnownServers = append(oldCLICfg.KnownServers, &configtypes.Server{
+		Name:       "old-cli-server",
+		Type:       configtypes.GlobalServerType,
+		GlobalOpts: &configtypes.GlobalServer{Endpoint: "old-cli-endpoint"},
+	})
+	data, err = yaml.Marshal(&oldCLICfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(files[0].Name(), data, 0644))
+
+	server, err := GetServer("old-cli-server")
+	require.NoError(t, err)
+	assert.Equal(t, "old-cli-endpoint", server.GlobalOpts.Endpoint)
+	ctx, err := GetContext("test-mc")
+	require.NoError(t, err)
+	assert.Equal(t, "test-endpoint", ctx.ClusterOpts.Endpoint)
+
+	// Rolling back again is a no-op
+	require.NoError(t, RollbackToLegacyConfig())
+	legacyCfg, err = GetLegacyClientConfig()
+	require.NoError(t, err)
+	assert.Len(t, legacyCfg.KnownServers, 4)
+}
@@ -20,8 +20,6 @@ const (
 	DefaultLockTimeout = 10 * time.Minute
 )
 
-var tanzuConfigLockFile string
-
 // tanzuConfigLock used as a static lock variable that stores fslock
 // This is used for interprocess locking of the config file
 var tanzuConfigLock *fslock.Lock
@@ -30,30 +28,42 @@ var tanzuConfigLock *fslock.Lock
 // within the existing process trying to acquire the lock
 var mutex sync.Mutex
 
-// AcquireTanzuConfigLock tries to acquire lock to update tanzu config file with timeout
+// configSnapshot is the config read by the holder of the tanzuConfigLock, set while the lock is held
+var configSnapshot *nodeSnapshot
+
+// AcquireTanzuConfigLock tries to acquire lock to update tanzu config file with timeout.
+// It panics if the lock cannot be acquired.
 func AcquireTanzuConfigLock() {
-	var err error
+	if err := acquireTanzuConfigLock(); err != nil {
+		panic(err.Error())
+	}
+}
 
-	if tanzuConfigLockFile == "" {
-		path, err := ClientConfigPath()
-		if err != nil {
-			panic(fmt.Sprintf("cannot get config path while acquiring lock on tanzu config file, reason: %v", err))
-		}
-		tanzuConfigLockFile = filepath.Join(filepath.Dir(path), LocalTanzuFileLock)
+// acquireTanzuConfigLock acquires the locks of the config files of the active profile, whose path is resolved on
+// every acquisition
+func acquireTanzuConfigLock() error {
+	path, err := ClientConfigPath()
+	if err != nil {
+		return errors.Wrap(err, "cannot get config path while acquiring lock on tanzu config file")
 	}
 
 	// using fslock to handle interprocess locking
-	lock, err := getFileLockWithTimeOut(tanzuConfigLockFile, DefaultLockTimeout)
+	lock, err := getFileLockWithTimeOut(filepath.Join(filepath.Dir(path), LocalTanzuFileLock), DefaultLockTimeout)
 	if err != nil {
-		panic(fmt.Sprintf("cannot acquire lock for tanzu config file, reason: %v", err))
+		return errors.Wrap(err, "cannot acquire lock for tanzu config file")
 	}
 
 	// Lock the mutex to prevent concurrent calls to acquire and configure the tanzuConfigLock
 	mutex.Lock()
 	tanzuConfigLock = lock
+	configSnapshot = &nodeSnapshot{}
 
 	// Get lock on config-ng.yaml
-	AcquireTanzuConfigNextGenLock()
+	if err := acquireTanzuConfigNextGenLock(); err != nil {
+		ReleaseTanzuConfigLock()
+		return err
+	}
+	return nil
 }
 
 // ReleaseTanzuConfigLock releases the lock if the tanzuConfigLock was acquired
@@ -66,6 +76,7 @@ func ReleaseTanzuConfigLock() {
 	}
 
 	tanzuConfigLock = nil
+	configSnapshot = nil
 	// Unlock the mutex to allow other concurrent calls to acquire and configure the tanzuConfigLock
 	mutex.Unlock()
 
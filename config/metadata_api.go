@@ -4,8 +4,6 @@
 package config
 
 import (
-	"strings"
-
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
@@ -36,7 +34,9 @@ func GetConfigMetadata() (*configtypes.ConfigMetadata, error) {
 // GetConfigMetadataPatchStrategy retrieves patch strategies
 func GetConfigMetadataPatchStrategy() (map[string]string, error) {
 	// Retrieve config metadata node
-	AcquireTanzuMetadataLock()
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return nil, err
+	}
 	defer ReleaseTanzuMetadataLock()
 	node, err := getMetadataNodeNoLock()
 	if err != nil {
@@ -48,7 +48,9 @@ func GetConfigMetadataPatchStrategy() (map[string]string, error) {
 // SetConfigMetadataPatchStrategy add or update patch strategy specified by key-value pair
 func SetConfigMetadataPatchStrategy(key, value string) error {
 	// Retrieve config metadata node
-	AcquireTanzuMetadataLock()
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuMetadataLock()
 	node, err := getMetadataNodeNoLock()
 	if err != nil {
@@ -60,13 +62,15 @@ func SetConfigMetadataPatchStrategy(key, value string) error {
 	if err != nil {
 		return err
 	}
-	return persistConfigMetadata(node)
+	return persistConfigMetadata(node, "SetConfigMetadataPatchStrategy")
 }
 
 // SetConfigMetadataPatchStrategies add or update map of patch strategies
 func SetConfigMetadataPatchStrategies(patchStrategies map[string]string) error {
 	// Retrieve config metadata node
-	AcquireTanzuMetadataLock()
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuMetadataLock()
 	node, err := getMetadataNodeNoLock()
 	if err != nil {
@@ -78,7 +82,7 @@ func SetConfigMetadataPatchStrategies(patchStrategies map[string]string) error {
 	if err != nil {
 		return err
 	}
-	return persistConfigMetadata(node)
+	return persistConfigMetadata(node, "SetConfigMetadataPatchStrategies")
 }
 
 func getConfigMetadata(node *yaml.Node) (*configtypes.ConfigMetadata, error) {
@@ -115,6 +119,12 @@ func getConfigMetadataPatchStrategy(node *yaml.Node) (map[string]string, error)
 }
 
 func setConfigMetadataPatchStrategies(node *yaml.Node, patchStrategies map[string]string) error {
+	// validate all the patch strategies before updating any of them
+	for key, value := range patchStrategies {
+		if err := validatePatchStrategy(key, value); err != nil {
+			return err
+		}
+	}
 	for key, value := range patchStrategies {
 		err := setConfigMetadataPatchStrategy(node, key, value)
 		if err != nil {
@@ -125,13 +135,8 @@ func setConfigMetadataPatchStrategies(node *yaml.Node, patchStrategies map[strin
 }
 
 func setConfigMetadataPatchStrategy(node *yaml.Node, key, value string) error {
-	// check if key is empty
-	if key == "" {
-		return errors.New("key cannot be empty")
-	}
-
-	if !strings.EqualFold(value, "replace") && !strings.EqualFold(value, "merge") {
-		return errors.New("allowed values are replace or merge")
+	if err := validatePatchStrategy(key, value); err != nil {
+		return err
 	}
 
 	// find patch strategy node
@@ -151,3 +156,15 @@ func setConfigMetadataPatchStrategy(node *yaml.Node, key, value string) error {
 	}
 	return nil
 }
+
+func validatePatchStrategy(key, value string) error {
+	// check if key is empty or malformed
+	if err := nodeutils.ValidatePatchStrategyKey(key); err != nil {
+		return err
+	}
+
+	if !nodeutils.IsValidPatchStrategy(value) {
+		return errors.New("allowed values are replace or merge")
+	}
+	return nil
+}
@@ -161,6 +161,17 @@ func TestSetConfigMetadataPatchStrategy(t *testing.T) {
 			value:  "add",
 			errStr: "allowed values are replace or merge",
 		},
+		{
+			name:  "success add wildcard patch strategy",
+			key:   "contexts.*.discoverySources",
+			value: "replace",
+		},
+		{
+			name:   "failed add new patch strategy malformed key",
+			key:    "contexts..group",
+			value:  "replace",
+			errStr: "invalid patch strategy key \"contexts..group\", key segments cannot be empty",
+		},
 	}
 	for _, spec := range tests {
 		t.Run(spec.name, func(t *testing.T) {
@@ -7,8 +7,6 @@
 package config
 
 import (
-	"os"
-
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
@@ -18,35 +16,34 @@ import (
 // getMetadataNode retrieves the config from the local directory with lock
 func getMetadataNode() (*yaml.Node, error) {
 	// Retrieve config metadata node
-	AcquireTanzuMetadataLock()
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return nil, err
+	}
 	defer ReleaseTanzuMetadataLock()
 	return getMetadataNodeNoLock()
 }
 
-// getMetadataNodeNoLock retrieves the config from the local directory without acquiring the lock
+// getMetadataNodeNoLock retrieves the config from the local directory without acquiring the lock.
+// The first metadata read by the holder of the lock is kept as the snapshot persistConfigMetadata merges concurrent
+// changes against.
 func getMetadataNodeNoLock() (*yaml.Node, error) {
 	cfgPath, err := CfgMetadataFilePath()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed getting config metadata path")
 	}
 
-	bytes, err := os.ReadFile(cfgPath)
-	if err != nil || len(bytes) == 0 {
-		node, err := newMetadataNode()
+	node, err := readConfigFileNode(cfgPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to construct struct from config metadata data")
+	}
+	if node == nil {
+		node, err = newMetadataNode()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create new config metadata")
 		}
-		return node, nil
 	}
-	var node yaml.Node
-
-	err = yaml.Unmarshal(bytes, &node)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to construct struct from config metadata data")
-	}
-	node.Content[0].Style = 0
-
-	return &node, nil
+	metadataSnapshot.record(node)
+	return node, nil
 }
 
 func newMetadataNode() (*yaml.Node, error) {
@@ -59,10 +56,21 @@ func newMetadataNode() (*yaml.Node, error) {
 	return node, nil
 }
 
-func persistConfigMetadata(node *yaml.Node) error {
+// persistConfigMetadata writes the metadata node and records the changes in the audit entry of the config API.
+// The changes written by others since the metadata was read under the lock are merged into the node.
+func persistConfigMetadata(node *yaml.Node, api string) error {
 	path, err := CfgMetadataFilePath()
 	if err != nil {
 		return errors.Wrap(err, "could not find config metadata path")
 	}
-	return persistNode(node, WithCfgPath(path))
+	oldNode, _ := getMetadataNodeNoLock()
+	metadataSnapshot.merge(node, oldNode)
+	if err := persistNode(node, WithCfgPath(path)); err != nil {
+		return err
+	}
+	metadataSnapshot.reset(node)
+	if oldNode != nil {
+		recordAudit(api, oldNode, node)
+	}
+	return nil
 }
@@ -31,6 +31,7 @@ func metadataPath(localDirGetter func() (string, error)) (path string, err error
 	return
 }
 
+// CfgMetadataFilePath returns the config metadata path of the active profile, checking for environment overrides.
 func CfgMetadataFilePath() (path string, err error) {
-	return metadataPath(LocalDir)
+	return metadataPath(ProfileLocalDir)
 }
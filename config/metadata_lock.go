@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/juju/fslock"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -19,8 +20,6 @@ const (
 	DefaultMetadataLockTimeout = 10 * time.Minute
 )
 
-var tanzuMetadataLockFile string
-
 // tanzuMetadataLock used as a static lock variable that stores fslock
 // This is used for interprocess locking of the config file
 var tanzuMetadataLock *fslock.Lock
@@ -29,27 +28,36 @@ var tanzuMetadataLock *fslock.Lock
 // within the existing process trying to acquire the lock
 var mutexMetadata sync.Mutex
 
-// AcquireTanzuMetadataLock tries to acquire lock to update tanzu config metadata file with timeout
+// metadataSnapshot is the config metadata read by the holder of the tanzuMetadataLock, set while the lock is held
+var metadataSnapshot *nodeSnapshot
+
+// AcquireTanzuMetadataLock tries to acquire lock to update tanzu config metadata file with timeout.
+// It panics if the lock cannot be acquired.
 func AcquireTanzuMetadataLock() {
-	var err error
+	if err := acquireTanzuMetadataLock(); err != nil {
+		panic(err.Error())
+	}
+}
 
-	if tanzuMetadataLockFile == "" {
-		path, err := CfgMetadataFilePath()
-		if err != nil {
-			panic(fmt.Sprintf("cannot get config path while acquiring lock on tanzu config metadata file, reason: %v", err))
-		}
-		tanzuMetadataLockFile = filepath.Join(filepath.Dir(path), LocalTanzuMetadataFileLock)
+// acquireTanzuMetadataLock acquires the lock of the tanzu config metadata file of the active profile, whose path is resolved on every
+// acquisition
+func acquireTanzuMetadataLock() error {
+	path, err := CfgMetadataFilePath()
+	if err != nil {
+		return errors.Wrap(err, "cannot get config path while acquiring lock on tanzu config metadata file")
 	}
 
 	// using fslock to handle interprocess locking
-	lock, err := getFileLockWithTimeOut(tanzuMetadataLockFile, DefaultMetadataLockTimeout)
+	lock, err := getFileLockWithTimeOut(filepath.Join(filepath.Dir(path), LocalTanzuMetadataFileLock), DefaultMetadataLockTimeout)
 	if err != nil {
-		panic(fmt.Sprintf("cannot acquire lock for tanzu config metadata file, reason: %v", err))
+		return errors.Wrap(err, "cannot acquire lock for tanzu config metadata file")
 	}
 
 	// Lock the mutex to prevent concurrent calls to acquire and configure the tanzuMetadataLock
 	mutexMetadata.Lock()
 	tanzuMetadataLock = lock
+	metadataSnapshot = &nodeSnapshot{}
+	return nil
 }
 
 // ReleaseTanzuMetadataLock releases the lock if the tanzuMetadataLock was acquired
@@ -62,6 +70,7 @@ func ReleaseTanzuMetadataLock() {
 	}
 
 	tanzuMetadataLock = nil
+	metadataSnapshot = nil
 	// Unlock the mutex to allow other concurrent calls to acquire and configure the tanzuMetadataLock
 	mutexMetadata.Unlock()
 }
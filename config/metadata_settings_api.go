@@ -58,7 +58,9 @@ func UseUnifiedConfig() (bool, error) {
 // DeleteConfigMetadataSetting delete the env entry of specified key
 func DeleteConfigMetadataSetting(key string) error {
 	// Retrieve config metadata node
-	AcquireTanzuMetadataLock()
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuMetadataLock()
 	node, err := getMetadataNodeNoLock()
 	if err != nil {
@@ -70,13 +72,15 @@ func DeleteConfigMetadataSetting(key string) error {
 		return err
 	}
 
-	return persistConfigMetadata(node)
+	return persistConfigMetadata(node, "DeleteConfigMetadataSetting")
 }
 
 // SetConfigMetadataSetting add or update a env key and value
 func SetConfigMetadataSetting(key, value string) (err error) {
 	// Retrieve config metadata node
-	AcquireTanzuMetadataLock()
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuMetadataLock()
 	node, err := getMetadataNodeNoLock()
 	if err != nil {
@@ -86,7 +90,7 @@ func SetConfigMetadataSetting(key, value string) (err error) {
 	persist, err := setSetting(node, key, value)
 
 	if persist {
-		return persistConfigMetadata(node)
+		return persistConfigMetadata(node, "SetConfigMetadataSetting")
 	}
 
 	return err
@@ -0,0 +1,38 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// AttachFootCommentToDocument moves the comment at the end of a document, which yaml attaches as the foot comment of
+// the last key of the document mapping, to the document node so it stays at the end when keys are added.
+func AttachFootCommentToDocument(node *yaml.Node) {
+	if node == nil || node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
+		return
+	}
+	mapping := node.Content[0]
+	if mapping.Kind != yaml.MappingNode || len(mapping.Content) < 2 {
+		return
+	}
+	lastKey := mapping.Content[len(mapping.Content)-2]
+	if lastKey.FootComment == "" {
+		return
+	}
+	if node.FootComment != "" {
+		node.FootComment = lastKey.FootComment + "\n\n" + node.FootComment
+	} else {
+		node.FootComment = lastKey.FootComment
+	}
+	lastKey.FootComment = ""
+}
+
+// ResetEmptyCollectionStyle resets the flow style of an empty mapping or sequence e.g. {} or [], so the items added
+// to it are written in block style. The style of collections with items is kept as written by the user.
+func ResetEmptyCollectionStyle(node *yaml.Node) {
+	if node != nil && (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && len(node.Content) == 0 {
+		node.Style = 0
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAttachFootCommentToDocument(t *testing.T) {
+	node := parseNode(t, `# head
+a: b
+c: d
+# foot
+`)
+	AttachFootCommentToDocument(node)
+	node.Content[0].Content = append(node.Content[0].Content, CreateScalarNode("e", "f")...)
+
+	out, err := yaml.Marshal(node)
+	require.NoError(t, err)
+	assert.Equal(t, `# head
+a: b
+c: d
+e: f
+
+# foot
+`, string(out))
+}
+
+func TestResetEmptyCollectionStyle(t *testing.T) {
+	// Non-empty collections keep their style
+	items := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "a"}}}
+	ResetEmptyCollectionStyle(items)
+	assert.Equal(t, yaml.FlowStyle, items.Style)
+
+	// Empty collections are reset to the block style, so that they are written in block style once filled
+	empty := &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+	node := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "empty"}, empty}}
+	ResetEmptyCollectionStyle(empty)
+	assert.Equal(t, yaml.Style(0), empty.Style)
+
+	empty.Content = append(empty.Content, CreateScalarNode("a", "b")...)
+	out, err := yaml.Marshal(node)
+	require.NoError(t, err)
+	assert.Equal(t, "empty:\n    a: b\n", string(out))
+}
@@ -6,11 +6,15 @@ package nodeutils
 import (
 	"reflect"
 
+	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 )
 
 // Equal checks whether the passed two nodes are equal
 func Equal(node1, node2 *yaml.Node) (bool, error) {
+	if node1.Kind == yaml.SequenceNode || node2.Kind == yaml.SequenceNode {
+		return equalSequences(node1, node2)
+	}
 	m1, err := ConvertNodeToMapInterface(node1)
 	if err != nil {
 		return false, err
@@ -22,6 +26,17 @@ func Equal(node1, node2 *yaml.Node) (bool, error) {
 	return reflect.DeepEqual(m1, m2), nil
 }
 
+func equalSequences(node1, node2 *yaml.Node) (bool, error) {
+	var s1, s2 []interface{}
+	if err := node1.Decode(&s1); err != nil {
+		return false, errors.Wrap(err, "failed to convert node to slice")
+	}
+	if err := node2.Decode(&s2); err != nil {
+		return false, errors.Wrap(err, "failed to convert node to slice")
+	}
+	return reflect.DeepEqual(s1, s2), nil
+}
+
 // NotEqual checks whether the passed two nodes are not deep equal
 func NotEqual(node1, node2 *yaml.Node) (bool, error) {
 	equal, err := Equal(node1, node2)
@@ -4,7 +4,6 @@
 package nodeutils
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -26,10 +25,10 @@ func DeleteNodes(src, dst *yaml.Node, opts ...PatchStrategyOpts) (bool, error) {
 	for _, opt := range opts {
 		opt(options)
 	}
-	return replaceUnequalObjects, deleteNodes(src, dst, options.Key, options.PatchStrategies)
+	return replaceUnequalObjects, deleteNodes(src, dst, options.Key, options.PatchStrategies, options.SequenceKeys)
 }
 
-func deleteNodes(src, dst *yaml.Node, patchStrategyKey string, patchStrategies map[string]string) error {
+func deleteNodes(src, dst *yaml.Node, patchStrategyKey string, patchStrategies map[string]string, sequenceKeys SequenceKeys) error {
 	err := checkErrors(src, dst)
 	if err != nil {
 		return err
@@ -48,14 +47,14 @@ func deleteNodes(src, dst *yaml.Node, patchStrategyKey string, patchStrategies m
 				found = true
 
 				// check for patch strategy before performing deep replace
-				key = fmt.Sprintf("%v.%v", key, dst.Content[i].Value)
-				if strings.EqualFold(patchStrategies[key], "replace") {
+				key = joinPath(key, dst.Content[i].Value)
+				if isReplacePatchStrategy(patchStrategies, key) {
 					dst.Content = append(dst.Content[:i], dst.Content[i+2:]...)
 					i -= 2
 					break
 				}
 
-				if err := deleteNodes(src.Content[j+1], dst.Content[i+1], key, patchStrategies); err != nil {
+				if err := deleteNodes(src.Content[j+1], dst.Content[i+1], key, patchStrategies, sequenceKeys); err != nil {
 					return errors.Wrap(err, " delete at key "+src.Content[i].Value)
 				}
 				key = patchStrategyKey
@@ -63,8 +62,8 @@ func deleteNodes(src, dst *yaml.Node, patchStrategyKey string, patchStrategies m
 			}
 			// if match not found remove the node if it is found in patch strategy
 			if !found {
-				key = fmt.Sprintf("%v.%v", key, dst.Content[i].Value)
-				if strings.EqualFold(patchStrategies[key], "replace") {
+				key = joinPath(key, dst.Content[i].Value)
+				if isReplacePatchStrategy(patchStrategies, key) {
 					dst.Content = append(dst.Content[:i], dst.Content[i+2:]...)
 					i -= 2
 				}
@@ -72,8 +71,21 @@ func deleteNodes(src, dst *yaml.Node, patchStrategyKey string, patchStrategies m
 		}
 	case yaml.ScalarNode:
 	case yaml.SequenceNode:
+		// items of keyed sequences are matched by key so patch strategies apply within the items
+		if keyFunc := sequenceKeys.lookup(patchStrategyKey); keyFunc != nil {
+			srcItems := indexItems(src, keyFunc)
+			for _, dstItem := range dst.Content {
+				itemKey, ok := keyFunc(dstItem)
+				if !ok || srcItems[itemKey] == nil {
+					continue
+				}
+				if err := deleteNodes(srcItems[itemKey], dstItem, patchStrategyKey, patchStrategies, sequenceKeys); err != nil {
+					return errors.Wrap(err, "delete at item "+itemKey)
+				}
+			}
+		}
 	case yaml.DocumentNode:
-		err := deleteNodes(src.Content[0], dst.Content[0], patchStrategyKey, patchStrategies)
+		err := deleteNodes(src.Content[0], dst.Content[0], patchStrategyKey, patchStrategies, sequenceKeys)
 		if err != nil {
 			return errors.Wrap(err, "delete at key "+src.Content[0].Value)
 		}
@@ -82,3 +94,9 @@ func deleteNodes(src, dst *yaml.Node, patchStrategyKey string, patchStrategies m
 	}
 	return nil
 }
+
+// isReplacePatchStrategy checks whether the key, or a wildcard key matching it, has the replace patch strategy
+func isReplacePatchStrategy(patchStrategies map[string]string, key string) bool {
+	strategy, _ := GetPatchStrategy(patchStrategies, key)
+	return strings.EqualFold(strategy, PatchStrategyReplace)
+}
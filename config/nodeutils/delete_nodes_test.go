@@ -202,3 +202,51 @@ discoverySources:
 		})
 	}
 }
+
+func TestDeleteNodesKeyedSequences(t *testing.T) {
+	dst := parseNode(t, `- name: test-mc
+  group: one
+  clusterOpts:
+    endpoint: test-endpoint
+- name: test-mc2
+  group: two
+`).Content[0]
+	src := parseNode(t, `- name: test-mc
+  clusterOpts:
+    endpoint: test-endpoint
+`).Content[0]
+	patchStrategies := map[string]string{"contexts.group": PatchStrategyReplace}
+
+	// Without sequence keys the items are not matched
+	_, err := DeleteNodes(src, dst, WithPatchStrategyKey("contexts"), WithPatchStrategies(patchStrategies))
+	assert.NoError(t, err)
+	assert.NotNil(t, FindNode(dst.Content[0], WithKeys([]Key{{Name: "group"}})))
+
+	_, err = DeleteNodes(src, dst, WithPatchStrategyKey("contexts"), WithPatchStrategies(patchStrategies), WithSequenceKeys(DefaultSequenceKeys))
+	assert.NoError(t, err)
+	assert.Nil(t, FindNode(dst.Content[0], WithKeys([]Key{{Name: "group"}})))
+	assert.NotNil(t, FindNode(dst.Content[1], WithKeys([]Key{{Name: "group"}})))
+}
+
+func TestDeleteNodesItemWildcard(t *testing.T) {
+	dst := parseNode(t, `- name: test-mc
+  discoverySources:
+  - oci:
+      name: default
+      image: test-image
+  - oci:
+      name: other
+      image: other-image
+`).Content[0]
+	src := parseNode(t, `- name: test-mc
+  discoverySources:
+  - oci:
+      name: default
+      image: new-image
+`).Content[0]
+	patchStrategies := map[string]string{"contexts.*.discoverySources": PatchStrategyReplace}
+
+	_, err := DeleteNodes(src, dst, WithPatchStrategyKey("contexts"), WithPatchStrategies(patchStrategies), WithSequenceKeys(DefaultSequenceKeys))
+	assert.NoError(t, err)
+	assert.Nil(t, FindNode(dst.Content[0], WithKeys([]Key{{Name: "discoverySources"}})))
+}
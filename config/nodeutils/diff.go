@@ -0,0 +1,187 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeType describes how a node changed between two yaml nodes
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// Change describes a node that was added, removed or modified.
+// Path addresses the node with '.' separated mapping keys, keyed sequence items as [key]
+// and other sequence items as [index] e.g. contexts[test-mc].clusterOpts.endpoint
+type Change struct {
+	Path string
+	Type ChangeType
+	// Old is the node before the change, nil for additions
+	Old *yaml.Node
+	// New is the node after the change, nil for removals
+	New *yaml.Node
+}
+
+// newDiffOptions returns the options to compare yaml nodes, keyed sequences default to DefaultSequenceKeys
+func newDiffOptions(opts []PatchStrategyOpts) *PatchStrategyOptions {
+	options := &PatchStrategyOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.SequenceKeys == nil {
+		options.SequenceKeys = DefaultSequenceKeys
+	}
+	return options
+}
+
+// Diff returns the changes required to turn the old node into the new node, in document order.
+// Sequences are matched by key as per DefaultSequenceKeys unless overridden with WithSequenceKeys,
+// and WithPatchStrategyKey sets the path of the compared nodes e.g. contexts when comparing contexts.
+func Diff(oldNode, newNode *yaml.Node, opts ...PatchStrategyOpts) []Change {
+	options := newDiffOptions(opts)
+	return diffNodes(oldNode, newNode, options.Key, options.Key, options.SequenceKeys)
+}
+
+// diffNodes compares the nodes at path, keyPath is the path without sequence item selectors used to lookup sequence keys
+func diffNodes(oldNode, newNode *yaml.Node, path, keyPath string, sequenceKeys SequenceKeys) []Change {
+	oldNode, newNode = documentContent(oldNode), documentContent(newNode)
+	switch {
+	case oldNode == nil && newNode == nil:
+		return nil
+	case oldNode == nil:
+		return []Change{{Path: path, Type: ChangeAdded, New: newNode}}
+	case newNode == nil:
+		return []Change{{Path: path, Type: ChangeRemoved, Old: oldNode}}
+	case oldNode.Kind != newNode.Kind:
+		return []Change{{Path: path, Type: ChangeModified, Old: oldNode, New: newNode}}
+	}
+
+	switch oldNode.Kind {
+	case yaml.MappingNode:
+		return diffMappings(oldNode, newNode, path, keyPath, sequenceKeys)
+	case yaml.SequenceNode:
+		return diffSequences(oldNode, newNode, path, keyPath, sequenceKeys)
+	default:
+		if !nodesEqual(oldNode, newNode) {
+			return []Change{{Path: path, Type: ChangeModified, Old: oldNode, New: newNode}}
+		}
+	}
+	return nil
+}
+
+func diffMappings(oldNode, newNode *yaml.Node, path, keyPath string, sequenceKeys SequenceKeys) []Change {
+	var changes []Change
+	for _, key := range mappingKeys(oldNode, newNode) {
+		changes = append(changes, diffNodes(mappingValue(oldNode, key), mappingValue(newNode, key),
+			joinPath(path, key), joinPath(keyPath, key), sequenceKeys)...)
+	}
+	return changes
+}
+
+func diffSequences(oldNode, newNode *yaml.Node, path, keyPath string, sequenceKeys SequenceKeys) []Change {
+	if keyFunc := sequenceKeys.lookup(keyPath); keyFunc != nil {
+		oldKeys, oldItems, oldOK := keyedItems(oldNode, keyFunc)
+		newKeys, newItems, newOK := keyedItems(newNode, keyFunc)
+		if oldOK && newOK {
+			var changes []Change
+			for _, key := range unionKeys(oldKeys, newKeys) {
+				changes = append(changes, diffNodes(oldItems[key], newItems[key], fmt.Sprintf("%s[%s]", path, key), keyPath, sequenceKeys)...)
+			}
+			return changes
+		}
+	}
+
+	var changes []Change
+	for i := 0; i < len(oldNode.Content) || i < len(newNode.Content); i++ {
+		changes = append(changes, diffNodes(sequenceItem(oldNode, i), sequenceItem(newNode, i), fmt.Sprintf("%s[%d]", path, i), keyPath, sequenceKeys)...)
+	}
+	return changes
+}
+
+// documentContent unwraps document nodes
+func documentContent(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return node.Content[0]
+	}
+	return node
+}
+
+// nodesEqual returns true if both nodes decode to the same value, nil nodes are only equal to each other
+func nodesEqual(node1, node2 *yaml.Node) bool {
+	node1, node2 = documentContent(node1), documentContent(node2)
+	if node1 == nil || node2 == nil {
+		return node1 == node2
+	}
+	var v1, v2 interface{}
+	if err := node1.Decode(&v1); err != nil {
+		return false
+	}
+	if err := node2.Decode(&v2); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(v1, v2)
+}
+
+// mappingKeys returns the keys of the mapping nodes in order, keys only present in later nodes are appended
+func mappingKeys(nodes ...*yaml.Node) []string {
+	var keys []string
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		var nodeKeys []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			nodeKeys = append(nodeKeys, node.Content[i].Value)
+		}
+		keys = unionKeys(keys, nodeKeys)
+	}
+	return keys
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if index := GetNodeIndex(node.Content, key); index != -1 {
+		return node.Content[index]
+	}
+	return nil
+}
+
+func sequenceItem(node *yaml.Node, index int) *yaml.Node {
+	if node == nil || index >= len(node.Content) {
+		return nil
+	}
+	return node.Content[index]
+}
+
+// unionKeys returns the keys of the first slice followed by the keys only present in the second slice
+func unionKeys(first, second []string) []string {
+	seen := make(map[string]bool, len(first))
+	keys := make([]string, 0, len(first)+len(second))
+	for _, key := range first {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range second {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func parseNode(t *testing.T, data string) *yaml.Node {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(data), &node))
+	return &node
+}
+
+func TestDiff(t *testing.T) {
+	oldNode := parseNode(t, `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: old-endpoint
+  - name: test-tmc
+    target: mission-control
+    discoverySources:
+      - oci:
+          name: default
+          image: old-image
+      - local:
+          name: admin
+          path: admin
+cli:
+  edition: tkg
+  unstableVersionSelector: all
+`)
+	newNode := parseNode(t, `contexts:
+  - name: test-tmc
+    target: mission-control
+    discoverySources:
+      - local:
+          name: admin
+          path: admin
+      - oci:
+          name: default
+          image: new-image
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: new-endpoint
+      context: test-context
+  - name: test-new
+    target: kubernetes
+cli:
+  edition: tkg
+plugins:
+  - a
+  - b
+`)
+	changes := Diff(oldNode, newNode)
+
+	summary := make([]string, 0, len(changes))
+	for _, change := range changes {
+		summary = append(summary, string(change.Type)+" "+change.Path)
+	}
+	assert.Equal(t, []string{
+		"modified contexts[test-mc].clusterOpts.endpoint",
+		"added contexts[test-mc].clusterOpts.context",
+		"modified contexts[test-tmc].discoverySources[oci/default].oci.image",
+		"added contexts[test-new]",
+		"removed cli.unstableVersionSelector",
+		"added plugins",
+	}, summary)
+	assert.Equal(t, "old-endpoint", changes[0].Old.Value)
+	assert.Equal(t, "new-endpoint", changes[0].New.Value)
+	assert.Nil(t, changes[1].Old)
+	assert.Nil(t, changes[4].New)
+
+	assert.Empty(t, Diff(oldNode, oldNode))
+}
+
+func TestDiffSequencesByIndex(t *testing.T) {
+	oldNode := parseNode(t, `items: [a, b, c]`)
+	newNode := parseNode(t, `items: [a, d]`)
+
+	changes := Diff(oldNode, newNode)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, Change{Path: "items[1]", Type: ChangeModified, Old: changes[0].Old, New: changes[0].New}, changes[0])
+	assert.Equal(t, "d", changes[0].New.Value)
+	assert.Equal(t, "items[2]", changes[1].Path)
+	assert.Equal(t, ChangeRemoved, changes[1].Type)
+
+	// Keyed sequences are matched by key, or by position when no sequence keys are configured
+	oldNode = parseNode(t, `contexts: [{name: a}, {name: b}]`)
+	newNode = parseNode(t, `contexts: [{name: b}, {name: a}]`)
+	assert.Empty(t, Diff(oldNode, newNode))
+	assert.Len(t, Diff(oldNode, newNode, WithSequenceKeys(SequenceKeys{})), 2)
+}
@@ -25,7 +25,7 @@ func FindNode(node *yaml.Node, opts ...Options) *yaml.Node {
 			}
 		}
 		parent = child
-		parent.Style = 0
+		ResetEmptyCollectionStyle(parent)
 	}
 	return parent
 }
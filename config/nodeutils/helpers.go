@@ -36,3 +36,32 @@ func checkErrors(src, dst *yaml.Node) error {
 	}
 	return nil
 }
+
+// CopyNode returns a deep copy of the node. Aliases within the node refer to the copied anchors.
+func CopyNode(node *yaml.Node) *yaml.Node {
+	copies := make(map[*yaml.Node]*yaml.Node)
+	copied := copyNode(node, copies)
+	for _, c := range copies {
+		if c.Alias != nil {
+			if anchor, ok := copies[c.Alias]; ok {
+				c.Alias = anchor
+			}
+		}
+	}
+	return copied
+}
+
+func copyNode(node *yaml.Node, copies map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	copied := *node
+	copies[node] = &copied
+	if node.Content != nil {
+		copied.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			copied.Content[i] = copyNode(child, copies)
+		}
+	}
+	return &copied
+}
@@ -60,3 +60,24 @@ func TestUniqNode(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyNode(t *testing.T) {
+	node := parseNode(t, `defaults: &defaults
+  endpoint: test-endpoint
+contexts:
+  - name: test
+    clusterOpts: *defaults
+`)
+	copied := CopyNode(node)
+	assert.True(t, nodesEqual(node, copied))
+
+	// Modifying the copy, including through aliases, leaves the node untouched
+	alias := copied.Content[0].Content[3].Content[0].Content[3]
+	assert.Equal(t, yaml.AliasNode, alias.Kind)
+	assert.Same(t, copied.Content[0].Content[1], alias.Alias)
+	alias.Alias.Content[1].Value = "modified"
+	assert.Equal(t, "test-endpoint", node.Content[0].Content[1].Content[1].Value)
+	assert.Equal(t, "modified", copied.Content[0].Content[1].Content[1].Value)
+
+	assert.Nil(t, CopyNode(nil))
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Leaf is a scalar node addressed by its path, using the same addressing as Change
+type Leaf struct {
+	Path string
+	Node *yaml.Node
+}
+
+// Leaves returns the scalar nodes of the node in document order.
+// Sequences are addressed as by Diff, keyed by DefaultSequenceKeys unless overridden with WithSequenceKeys.
+func Leaves(node *yaml.Node, opts ...PatchStrategyOpts) []Leaf {
+	options := newDiffOptions(opts)
+	return leaves(node, options.Key, options.Key, options.SequenceKeys)
+}
+
+func leaves(node *yaml.Node, path, keyPath string, sequenceKeys SequenceKeys) []Leaf {
+	node = documentContent(node)
+	if node == nil {
+		return nil
+	}
+	var result []Leaf
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			result = append(result, leaves(node.Content[i+1], joinPath(path, key), joinPath(keyPath, key), sequenceKeys)...)
+		}
+	case yaml.SequenceNode:
+		keyFunc := sequenceKeys.lookup(keyPath)
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if keyFunc != nil {
+				if itemKey, ok := keyFunc(item); ok {
+					itemPath = fmt.Sprintf("%s[%s]", path, itemKey)
+				}
+			}
+			result = append(result, leaves(item, itemPath, keyPath, sequenceKeys)...)
+		}
+	case yaml.AliasNode:
+		result = append(result, leaves(node.Alias, path, keyPath, sequenceKeys)...)
+	default:
+		result = append(result, Leaf{Path: path, Node: node})
+	}
+	return result
+}
@@ -0,0 +1,33 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaves(t *testing.T) {
+	node := parseNode(t, `contexts:
+  - name: test-mc
+    clusterOpts:
+      endpoint: test-endpoint
+cli:
+  edition: tkg
+plugins: [a, b]
+empty: {}
+`)
+	var paths []string
+	for _, leaf := range Leaves(node) {
+		paths = append(paths, leaf.Path+"="+leaf.Node.Value)
+	}
+	assert.Equal(t, []string{
+		"contexts[test-mc].name=test-mc",
+		"contexts[test-mc].clusterOpts.endpoint=test-endpoint",
+		"cli.edition=tkg",
+		"plugins[0]=a",
+		"plugins[1]=b",
+	}, paths)
+}
@@ -13,8 +13,21 @@ var (
 	ErrNonPointerArgument      = errors.New("dst must be a pointer")
 )
 
-// MergeNodes to merge two yaml nodes src(source) to dst(destination) node
-func MergeNodes(src, dst *yaml.Node) (bool, error) {
+// MergeNodes to merge two yaml nodes src(source) to dst(destination) node.
+// Items of keyed sequences configured WithSequenceKeys are upserted by key, items of other sequences of scalars are
+// added if missing. WithPatchStrategyKey sets the path of the merged nodes used to lookup the sequence keys.
+func MergeNodes(src, dst *yaml.Node, opts ...PatchStrategyOpts) (bool, error) {
+	options := &PatchStrategyOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	// a keyed sequence only changes when one of its items is added or not equal to the existing item
+	if keyFunc := options.SequenceKeys.lookup(options.Key); keyFunc != nil && src.Kind == yaml.SequenceNode && dst.Kind == yaml.SequenceNode {
+		if merged, changed, err := mergeKeyedSequenceNodes(src, dst, options.Key, keyFunc, options.SequenceKeys); merged {
+			return changed, err
+		}
+	}
+
 	// only replace if the change is not equal to existing
 	mergeUnequalObjects, err := NotEqual(src, dst)
 	if err != nil {
@@ -23,10 +36,10 @@ func MergeNodes(src, dst *yaml.Node) (bool, error) {
 	if !mergeUnequalObjects {
 		return mergeUnequalObjects, nil
 	}
-	return mergeUnequalObjects, mergeNodes(src, dst)
+	return mergeUnequalObjects, mergeNodes(src, dst, options.Key, options.SequenceKeys)
 }
 
-func mergeNodes(src, dst *yaml.Node) error {
+func mergeNodes(src, dst *yaml.Node, key string, sequenceKeys SequenceKeys) error {
 	err := checkErrors(src, dst)
 	if err != nil {
 		return err
@@ -38,7 +51,7 @@ func mergeNodes(src, dst *yaml.Node) error {
 			for j := 0; j < len(dst.Content); j += 2 {
 				if ok, _ := equalScalars(src.Content[i], dst.Content[j]); ok {
 					found = true
-					if err := mergeNodes(src.Content[i+1], dst.Content[j+1]); err != nil {
+					if err := mergeNodes(src.Content[i+1], dst.Content[j+1], joinPath(key, src.Content[i].Value), sequenceKeys); err != nil {
 						return errors.Wrap(err, "merge at key "+src.Content[i].Value)
 					}
 					break
@@ -49,9 +62,14 @@ func mergeNodes(src, dst *yaml.Node) error {
 			}
 		}
 	case yaml.SequenceNode:
+		if keyFunc := sequenceKeys.lookup(key); keyFunc != nil {
+			if merged, _, err := mergeKeyedSequenceNodes(src, dst, key, keyFunc, sequenceKeys); merged {
+				return err
+			}
+		}
 		setSeqNode(src, dst)
 	case yaml.DocumentNode:
-		err := mergeNodes(src.Content[0], dst.Content[0])
+		err := mergeNodes(src.Content[0], dst.Content[0], key, sequenceKeys)
 		if err != nil {
 			return errors.Wrap(err, "merge at key "+src.Content[0].Value)
 		}
@@ -65,8 +83,44 @@ func mergeNodes(src, dst *yaml.Node) error {
 	return nil
 }
 
+// mergeKeyedSequenceNodes merges the src items into the dst items with the same key and adds the others,
+// returning whether any item was added or not equal to the existing item.
+// Existing items that cannot be identified are left untouched.
+// It returns merged as false if the src items cannot be identified by key.
+func mergeKeyedSequenceNodes(src, dst *yaml.Node, key string, keyFunc SequenceKeyFunc, sequenceKeys SequenceKeys) (merged, changed bool, err error) {
+	srcKeys, srcItems, ok := keyedItems(src, keyFunc)
+	if !ok {
+		return false, false, nil
+	}
+	dstItems := indexItems(dst, keyFunc)
+	for _, itemKey := range srcKeys {
+		dstItem, exists := dstItems[itemKey]
+		if !exists {
+			dst.Content = append(dst.Content, srcItems[itemKey])
+			dstItems[itemKey] = srcItems[itemKey]
+			changed = true
+			continue
+		}
+		if nodesEqual(srcItems[itemKey], dstItem) {
+			continue
+		}
+		changed = true
+		if err := mergeNodes(srcItems[itemKey], dstItem, key, sequenceKeys); err != nil {
+			return true, changed, errors.Wrap(err, "merge at item "+itemKey)
+		}
+	}
+	return true, changed, nil
+}
+
 // Construct unique sequence nodes for scalar value type
 func setSeqNode(src, dst *yaml.Node) {
+	if len(src.Content) == 0 {
+		return
+	}
+	if len(dst.Content) == 0 {
+		dst.Content = append(dst.Content, src.Content...)
+		return
+	}
 	if dst.Content[0].Kind == yaml.ScalarNode && src.Content[0].Kind == yaml.ScalarNode {
 		dst.Content = append(dst.Content, src.Content...)
 		dst.Content = UniqNodes(dst.Content)
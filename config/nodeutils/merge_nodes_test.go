@@ -318,3 +318,57 @@ func TestMergeNode(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeNodesKeyedSequences(t *testing.T) {
+	dst := parseNode(t, `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: old-endpoint
+  - target: kubernetes
+certs:
+  - host: test-host
+    skipCertVerify: "false"
+`)
+	src := parseNode(t, `contexts:
+  - name: test-new
+    target: mission-control
+  - name: test-mc
+    clusterOpts:
+      endpoint: new-endpoint
+certs:
+  - host: test-host
+    skipCertVerify: "true"
+  - host: test-host-2
+    insecure: "true"
+`)
+	persist, err := MergeNodes(src, dst, WithSequenceKeys(DefaultSequenceKeys))
+	assert.NoError(t, err)
+	assert.True(t, persist)
+
+	expected := `contexts:
+    - name: test-mc
+      target: kubernetes
+      clusterOpts:
+        endpoint: new-endpoint
+    - target: kubernetes
+    - name: test-new
+      target: mission-control
+certs:
+    - host: test-host
+      skipCertVerify: "true"
+    - host: test-host-2
+      insecure: "true"
+`
+	out, err := yaml.Marshal(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(out))
+
+	// Merging items equal to the existing ones leaves the sequence unchanged
+	contexts := FindNode(dst.Content[0], WithKeys([]Key{{Name: "contexts"}}))
+	newContexts := parseNode(t, `[{name: test-new, target: mission-control}]`).Content[0]
+	persist, err = MergeNodes(newContexts, contexts, WithPatchStrategyKey("contexts"), WithSequenceKeys(DefaultSequenceKeys))
+	assert.NoError(t, err)
+	assert.False(t, persist)
+	assert.Len(t, contexts.Content, 3)
+}
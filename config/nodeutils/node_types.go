@@ -42,6 +42,8 @@ func WithKeys(keys []Key) Options {
 type PatchStrategyOptions struct {
 	Key             string
 	PatchStrategies map[string]string
+	// SequenceKeys identifies the items of keyed sequences, which are matched by key instead of position
+	SequenceKeys SequenceKeys
 }
 
 type PatchStrategyOpts func(options *PatchStrategyOptions)
@@ -57,6 +59,13 @@ func WithPatchStrategies(patchStrategies map[string]string) PatchStrategyOpts {
 	}
 }
 
+// WithSequenceKeys sets the keys used to match the items of keyed sequences e.g. DefaultSequenceKeys
+func WithSequenceKeys(sequenceKeys SequenceKeys) PatchStrategyOpts {
+	return func(options *PatchStrategyOptions) {
+		options.SequenceKeys = sequenceKeys
+	}
+}
+
 const (
 	NodeTagStr = "!!str"
 )
@@ -0,0 +1,126 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// PatchStrategyKeySeparator separates the segments of a patch strategy key e.g. contexts.clusterOpts.endpoint
+	PatchStrategyKeySeparator = "."
+	// PatchStrategyWildcardSegment matches any number of segments, including none
+	PatchStrategyWildcardSegment = "**"
+)
+
+// IsValidPatchStrategy returns true if the value is a known patch strategy
+func IsValidPatchStrategy(value string) bool {
+	return strings.EqualFold(value, PatchStrategyReplace) || strings.EqualFold(value, PatchStrategyMerge)
+}
+
+// ValidatePatchStrategyKey validates the patch strategy key. Segments of the key are separated by '.' and may use
+// glob patterns e.g. '*' to match any single segment, or be '**' to match any number of segments.
+// Keys are paths of mapping keys, the items of sequences have no segment e.g. contexts.group is the group of every
+// context. A '*' segment following a keyed sequence of DefaultSequenceKeys may also stand for its items e.g.
+// contexts.*.discoverySources matches contexts.discoverySources, the discovery sources of every context.
+func ValidatePatchStrategyKey(key string) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+	for _, segment := range strings.Split(key, PatchStrategyKeySeparator) {
+		if segment == "" {
+			return errors.Errorf("invalid patch strategy key %q, key segments cannot be empty", key)
+		}
+		if segment == PatchStrategyWildcardSegment {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return errors.Errorf("invalid patch strategy key %q, malformed pattern %q", key, segment)
+		}
+	}
+	return nil
+}
+
+// MatchPatchStrategyKey returns true if the patch strategy key pattern matches the key
+func MatchPatchStrategyKey(pattern, key string) bool {
+	return matchSegments(strings.Split(pattern, PatchStrategyKeySeparator), strings.Split(key, PatchStrategyKeySeparator), 0, true)
+}
+
+// matchSegments matches the patterns against the segments from index i. If items is true, a '*' pattern followed by
+// other patterns may match the items of the keyed sequence at segments[:i], which have no segment.
+func matchSegments(patterns, segments []string, i int, items bool) bool {
+	if len(patterns) == 0 {
+		return i == len(segments)
+	}
+	if patterns[0] == PatchStrategyWildcardSegment {
+		for j := i; j <= len(segments); j++ {
+			if matchSegments(patterns[1:], segments, j, items) {
+				return true
+			}
+		}
+		return false
+	}
+	if items && patterns[0] == "*" && len(patterns) > 1 && i > 0 &&
+		isSequenceKey(strings.Join(segments[:i], PatchStrategyKeySeparator)) &&
+		matchSegments(patterns[1:], segments, i, items) {
+		return true
+	}
+	if i == len(segments) {
+		return false
+	}
+	if ok, err := path.Match(patterns[0], segments[i]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(patterns[1:], segments, i+1, items)
+}
+
+// isSequenceKey returns true if the key is the path of a keyed sequence of DefaultSequenceKeys
+func isSequenceKey(key string) bool {
+	for pattern := range DefaultSequenceKeys {
+		if pattern == key || matchSegments(strings.Split(pattern, PatchStrategyKeySeparator), strings.Split(key, PatchStrategyKeySeparator), 0, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPatchStrategy returns the patch strategy configured for the key along with the patch strategy key that matched.
+// An exact key takes precedence over wildcard keys, and amongst wildcard keys the most specific one wins.
+// It returns empty strings if no patch strategy is configured for the key.
+func GetPatchStrategy(patchStrategies map[string]string, key string) (strategy, matchedKey string) {
+	if value, ok := patchStrategies[key]; ok {
+		return value, key
+	}
+	bestScore := -1
+	for pattern, value := range patchStrategies {
+		if !strings.ContainsAny(pattern, "*?[") || !MatchPatchStrategyKey(pattern, key) {
+			continue
+		}
+		score := patchStrategyKeySpecificity(pattern)
+		// Break ties deterministically to avoid depending on map iteration order
+		if score > bestScore || (score == bestScore && pattern < matchedKey) {
+			bestScore = score
+			strategy, matchedKey = value, pattern
+		}
+	}
+	return strategy, matchedKey
+}
+
+// patchStrategyKeySpecificity scores a key pattern: literal segments weigh more than glob segments, and '**' nothing
+func patchStrategyKeySpecificity(pattern string) int {
+	score := 0
+	for _, segment := range strings.Split(pattern, PatchStrategyKeySeparator) {
+		switch {
+		case segment == PatchStrategyWildcardSegment:
+		case strings.ContainsAny(segment, "*?["):
+			score++
+		default:
+			score += 2
+		}
+	}
+	return score
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPatchStrategyKey(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{pattern: "contexts.group", key: "contexts.group", match: true},
+		{pattern: "contexts.*", key: "contexts.group", match: true},
+		{pattern: "contexts.*", key: "contexts.clusterOpts.endpoint"},
+		{pattern: "contexts.*.endpoint", key: "contexts.clusterOpts.endpoint", match: true},
+		{pattern: "contexts.*.endpoint", key: "contexts.globalOpts.endpoint", match: true},
+		{pattern: "contexts.*.endpoint", key: "contexts.clusterOpts.path"},
+		{pattern: "**.discoverySources", key: "contexts.discoverySources", match: true},
+		{pattern: "**.discoverySources", key: "discoverySources", match: true},
+		{pattern: "contexts.*.discoverySources", key: "contexts.discoverySources", match: true},
+		{pattern: "contexts.*.discoverySources", key: "contexts.clusterOpts.discoverySources", match: true},
+		{pattern: "contexts.*.discoverySources.*.image", key: "contexts.discoverySources.oci.image", match: true},
+		{pattern: "clientOptions.*.discoverySources", key: "clientOptions.discoverySources"},
+		{pattern: "contexts.**", key: "contexts.clusterOpts.endpoint", match: true},
+		{pattern: "contexts.**", key: "servers.group"},
+		{pattern: "cli.discoverySources.o*.image", key: "cli.discoverySources.oci.image", match: true},
+		{pattern: "cli.discoverySources.o*.image", key: "cli.discoverySources.local.image"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern+" "+tc.key, func(t *testing.T) {
+			assert.Equal(t, tc.match, MatchPatchStrategyKey(tc.pattern, tc.key))
+		})
+	}
+}
+
+func TestGetPatchStrategy(t *testing.T) {
+	patchStrategies := map[string]string{
+		"contexts.clusterOpts.endpoint": "merge",
+		"contexts.*.endpoint":           "replace",
+		"contexts.**":                   "merge",
+		"**.discoverySources":           "replace",
+	}
+	tests := []struct {
+		key        string
+		strategy   string
+		matchedKey string
+	}{
+		{key: "contexts.clusterOpts.endpoint", strategy: "merge", matchedKey: "contexts.clusterOpts.endpoint"},
+		{key: "contexts.globalOpts.endpoint", strategy: "replace", matchedKey: "contexts.*.endpoint"},
+		{key: "contexts.globalOpts.auth", strategy: "merge", matchedKey: "contexts.**"},
+		{key: "servers.discoverySources", strategy: "replace", matchedKey: "**.discoverySources"},
+		{key: "servers.group"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.key, func(t *testing.T) {
+			strategy, matchedKey := GetPatchStrategy(patchStrategies, tc.key)
+			assert.Equal(t, tc.strategy, strategy)
+			assert.Equal(t, tc.matchedKey, matchedKey)
+		})
+	}
+}
+
+func TestValidatePatchStrategyKey(t *testing.T) {
+	assert.NoError(t, ValidatePatchStrategyKey("contexts.*.discoverySources"))
+	assert.NoError(t, ValidatePatchStrategyKey("**.discoverySources"))
+	assert.EqualError(t, ValidatePatchStrategyKey(""), "key cannot be empty")
+	assert.EqualError(t, ValidatePatchStrategyKey("contexts..group"), "invalid patch strategy key \"contexts..group\", key segments cannot be empty")
+	assert.EqualError(t, ValidatePatchStrategyKey("contexts.[a"), "invalid patch strategy key \"contexts.[a\", malformed pattern \"[a\"")
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SequenceKeyFunc returns the identity of a sequence item, used to match the items of two sequences.
+// It returns false if the item cannot be identified.
+type SequenceKeyFunc func(item *yaml.Node) (string, bool)
+
+// SequenceKeys maps sequence paths to the function identifying their items.
+// Paths are dotted keys without item selectors e.g. contexts, contexts.discoverySources,
+// and may use the same wildcards as patch strategy keys e.g. **.discoverySources
+type SequenceKeys map[string]SequenceKeyFunc
+
+// DefaultSequenceKeys identifies the items of the keyed sequences of the tanzu config
+var DefaultSequenceKeys = SequenceKeys{
+	"servers":             SequenceKeyByFields("name"),
+	"contexts":            SequenceKeyByFields("name"),
+	"certs":               SequenceKeyByFields("host"),
+	"**.discoverySources": SequenceKeyByTypedName("name"),
+	"**.repositories":     SequenceKeyByTypedName("name"),
+}
+
+// SequenceKeyByFields identifies mapping items by the values of the specified scalar fields
+func SequenceKeyByFields(fields ...string) SequenceKeyFunc {
+	return func(item *yaml.Node) (string, bool) {
+		if item == nil || item.Kind != yaml.MappingNode {
+			return "", false
+		}
+		values := make([]string, 0, len(fields))
+		for _, field := range fields {
+			index := GetNodeIndex(item.Content, field)
+			if index == -1 || item.Content[index].Kind != yaml.ScalarNode {
+				return "", false
+			}
+			values = append(values, item.Content[index].Value)
+		}
+		return strings.Join(values, "/"), true
+	}
+}
+
+// SequenceKeyByTypedName identifies mapping items holding a typed mapping with a name field,
+// e.g. discovery sources `- oci: {name: default, image: ...}` are identified as oci/default
+func SequenceKeyByTypedName(nameField string) SequenceKeyFunc {
+	return func(item *yaml.Node) (string, bool) {
+		if item == nil || item.Kind != yaml.MappingNode {
+			return "", false
+		}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			value := item.Content[i+1]
+			if value.Kind != yaml.MappingNode {
+				continue
+			}
+			if index := GetNodeIndex(value.Content, nameField); index != -1 && value.Content[index].Kind == yaml.ScalarNode {
+				return item.Content[i].Value + "/" + value.Content[index].Value, true
+			}
+		}
+		return "", false
+	}
+}
+
+// lookup returns the function identifying the items of the sequence at the path, or nil if the sequence is not keyed
+func (k SequenceKeys) lookup(path string) SequenceKeyFunc {
+	if keyFunc, ok := k[path]; ok {
+		return keyFunc
+	}
+	_, matchedKey := GetPatchStrategy(k.patterns(), path)
+	if matchedKey == "" {
+		return nil
+	}
+	return k[matchedKey]
+}
+
+func (k SequenceKeys) patterns() map[string]string {
+	patterns := make(map[string]string, len(k))
+	for pattern := range k {
+		patterns[pattern] = pattern
+	}
+	return patterns
+}
+
+// keyedItems returns the keys of the sequence items in order along with the items by key.
+// It returns false if any item cannot be identified or keys are not unique.
+func keyedItems(seq *yaml.Node, keyFunc SequenceKeyFunc) (keys []string, items map[string]*yaml.Node, ok bool) {
+	items = make(map[string]*yaml.Node)
+	if seq == nil {
+		return nil, items, true
+	}
+	for _, item := range seq.Content {
+		key, found := keyFunc(item)
+		if !found {
+			return nil, nil, false
+		}
+		if _, duplicate := items[key]; duplicate {
+			return nil, nil, false
+		}
+		keys = append(keys, key)
+		items[key] = item
+	}
+	return keys, items, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + PatchStrategyKeySeparator + key
+}
+
+// FindSequenceItem returns the item of the sequence identified by the key, or nil if there is none
+func FindSequenceItem(seq *yaml.Node, key string, keyFunc SequenceKeyFunc) *yaml.Node {
+	if seq == nil {
+		return nil
+	}
+	for _, item := range seq.Content {
+		if itemKey, ok := keyFunc(item); ok && itemKey == key {
+			return item
+		}
+	}
+	return nil
+}
+
+// indexItems returns the identifiable items of the sequence by key, keeping the first item of duplicate keys.
+// Unlike keyedItems it tolerates items that cannot be identified, which are left out.
+func indexItems(seq *yaml.Node, keyFunc SequenceKeyFunc) map[string]*yaml.Node {
+	items := make(map[string]*yaml.Node)
+	for _, item := range seq.Content {
+		if key, ok := keyFunc(item); ok {
+			if _, duplicate := items[key]; !duplicate {
+				items[key] = item
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Conflict describes a node changed differently by both sides of a three-way merge.
+// A nil node means the node is absent on that side e.g. removed by one side and modified by the other.
+type Conflict struct {
+	Path   string
+	Base   *yaml.Node
+	Ours   *yaml.Node
+	Theirs *yaml.Node
+}
+
+// ThreeWayMerge merges the changes made by ours and theirs to their common ancestor base.
+// Changes made by only one side are applied, mappings and keyed sequences changed by both sides are
+// merged recursively, and any other node changed differently by both sides is reported as a conflict
+// and resolved to ours. Sequences are matched as by Diff. The input nodes are not modified.
+func ThreeWayMerge(base, ours, theirs *yaml.Node, opts ...PatchStrategyOpts) (*yaml.Node, []Conflict) {
+	options := newDiffOptions(opts)
+	merged, conflicts := mergeThreeWay(base, ours, theirs, options.Key, options.Key, options.SequenceKeys)
+	if merged != nil && (documentKind(ours) || documentKind(theirs)) {
+		merged = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{merged}}
+	}
+	return merged, conflicts
+}
+
+func mergeThreeWay(base, ours, theirs *yaml.Node, path, keyPath string, sequenceKeys SequenceKeys) (*yaml.Node, []Conflict) {
+	base, ours, theirs = documentContent(base), documentContent(ours), documentContent(theirs)
+
+	switch {
+	case nodesEqual(ours, theirs), nodesEqual(base, theirs):
+		return CopyNode(ours), nil
+	case nodesEqual(base, ours):
+		return CopyNode(theirs), nil
+	}
+
+	if ours != nil && theirs != nil && ours.Kind == theirs.Kind {
+		if base != nil && base.Kind != ours.Kind {
+			base = nil
+		}
+		switch ours.Kind {
+		case yaml.MappingNode:
+			return mergeThreeWayMappings(base, ours, theirs, path, keyPath, sequenceKeys)
+		case yaml.SequenceNode:
+			if merged, conflicts, ok := mergeThreeWayKeyedSequences(base, ours, theirs, path, keyPath, sequenceKeys); ok {
+				return merged, conflicts
+			}
+		}
+	}
+	return CopyNode(ours), []Conflict{{Path: path, Base: base, Ours: ours, Theirs: theirs}}
+}
+
+func mergeThreeWayMappings(base, ours, theirs *yaml.Node, path, keyPath string, sequenceKeys SequenceKeys) (*yaml.Node, []Conflict) {
+	merged := &yaml.Node{Kind: ours.Kind, Tag: ours.Tag, Style: ours.Style}
+	var conflicts []Conflict
+	for _, key := range mappingKeys(ours, theirs, base) {
+		value, valueConflicts := mergeThreeWay(mappingValue(base, key), mappingValue(ours, key), mappingValue(theirs, key),
+			joinPath(path, key), joinPath(keyPath, key), sequenceKeys)
+		conflicts = append(conflicts, valueConflicts...)
+		if value == nil {
+			continue
+		}
+		keyNode := mappingKey(ours, key)
+		if keyNode == nil {
+			keyNode = mappingKey(theirs, key)
+		}
+		merged.Content = append(merged.Content, CopyNode(keyNode), value)
+	}
+	return merged, conflicts
+}
+
+// mergeThreeWayKeyedSequences merges sequences by item key, returns false if the sequence is not keyed
+func mergeThreeWayKeyedSequences(base, ours, theirs *yaml.Node, path, keyPath string, sequenceKeys SequenceKeys) (*yaml.Node, []Conflict, bool) {
+	keyFunc := sequenceKeys.lookup(keyPath)
+	if keyFunc == nil {
+		return nil, nil, false
+	}
+	_, baseItems, baseOK := keyedItems(base, keyFunc)
+	ourKeys, ourItems, oursOK := keyedItems(ours, keyFunc)
+	theirKeys, theirItems, theirsOK := keyedItems(theirs, keyFunc)
+	if !baseOK || !oursOK || !theirsOK {
+		return nil, nil, false
+	}
+
+	merged := &yaml.Node{Kind: ours.Kind, Tag: ours.Tag, Style: ours.Style}
+	var conflicts []Conflict
+	for _, key := range unionKeys(ourKeys, theirKeys) {
+		item, itemConflicts := mergeThreeWay(baseItems[key], ourItems[key], theirItems[key], fmt.Sprintf("%s[%s]", path, key), keyPath, sequenceKeys)
+		conflicts = append(conflicts, itemConflicts...)
+		if item != nil {
+			merged.Content = append(merged.Content, item)
+		}
+	}
+	return merged, conflicts, true
+}
+
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if index := GetNodeIndex(node.Content, key); index != -1 {
+		return node.Content[index-1]
+	}
+	return nil
+}
+
+func documentKind(node *yaml.Node) bool {
+	return node != nil && node.Kind == yaml.DocumentNode
+}
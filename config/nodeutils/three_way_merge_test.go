@@ -0,0 +1,115 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodeutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	base := parseNode(t, `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: endpoint
+  - name: test-removed
+    target: kubernetes
+cli:
+  edition: tkg
+`)
+	ours := parseNode(t, `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: our-endpoint
+  - name: test-ours
+    target: kubernetes
+cli:
+  edition: tkg
+`)
+	theirs := parseNode(t, `contexts:
+  - name: test-theirs
+    target: mission-control
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: endpoint
+      context: their-context
+  - name: test-removed
+    target: kubernetes
+cli:
+  edition: tce
+`)
+	merged, conflicts := ThreeWayMerge(base, ours, theirs)
+	assert.Empty(t, conflicts)
+
+	expected := parseNode(t, `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: our-endpoint
+      context: their-context
+  - name: test-ours
+    target: kubernetes
+  - name: test-theirs
+    target: mission-control
+cli:
+  edition: tce
+`)
+	assert.Empty(t, Diff(expected, merged))
+
+	// The inputs are left untouched
+	assert.Empty(t, Diff(parseNode(t, `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: our-endpoint
+  - name: test-ours
+    target: kubernetes
+cli:
+  edition: tkg
+`), ours))
+}
+
+func TestThreeWayMergeConflicts(t *testing.T) {
+	base := parseNode(t, `cli:
+  edition: tkg
+  repositories: [a]
+contexts:
+  - name: test-mc
+    target: kubernetes
+`)
+	ours := parseNode(t, `cli:
+  edition: tce
+  repositories: [a, b]
+`)
+	theirs := parseNode(t, `cli:
+  edition: tanzu
+  repositories: [a, c]
+contexts:
+  - name: test-mc
+    target: mission-control
+`)
+	merged, conflicts := ThreeWayMerge(base, ours, theirs)
+	require.Len(t, conflicts, 3)
+	assert.Equal(t, "cli.edition", conflicts[0].Path)
+	assert.Equal(t, "tkg", conflicts[0].Base.Value)
+	assert.Equal(t, "tce", conflicts[0].Ours.Value)
+	assert.Equal(t, "tanzu", conflicts[0].Theirs.Value)
+	assert.Equal(t, "cli.repositories", conflicts[1].Path)
+	assert.Equal(t, "contexts", conflicts[2].Path)
+	assert.Nil(t, conflicts[2].Ours)
+
+	// Conflicts are resolved to ours
+	out, err := yaml.Marshal(merged)
+	require.NoError(t, err)
+	assert.Equal(t, `cli:
+    edition: tce
+    repositories: [a, b]
+`, string(out))
+}
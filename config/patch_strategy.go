@@ -0,0 +1,122 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+)
+
+// EffectivePatchStrategy describes the patch strategy that applies to a config path
+type EffectivePatchStrategy struct {
+	// Path is the dotted config path the strategy applies to e.g. contexts.clusterOpts.endpoint
+	Path string `json:"path" yaml:"path"`
+	// Strategy is the effective patch strategy, either replace or merge
+	Strategy string `json:"strategy" yaml:"strategy"`
+	// MatchedKey is the patch strategy key that determined the strategy, empty if the default applies
+	MatchedKey string `json:"matchedKey,omitempty" yaml:"matchedKey,omitempty"`
+	// MatchedPath is the path the matched key applies to, which is an ancestor of Path when inherited
+	MatchedPath string `json:"matchedPath,omitempty" yaml:"matchedPath,omitempty"`
+}
+
+// GetEffectivePatchStrategy returns the patch strategy that applies to the specified dotted config path.
+// Patch strategy keys may contain wildcards e.g. contexts.*.endpoint or **.discoverySources.
+// A path without a strategy of its own inherits the strategy of its nearest ancestor with one,
+// since replacing a node replaces all of its children. The default patch strategy is merge.
+func GetEffectivePatchStrategy(path string) (*EffectivePatchStrategy, error) {
+	if path == "" {
+		return nil, errors.New("path cannot be empty")
+	}
+	return getEffectivePatchStrategy(getPatchStrategies(), path), nil
+}
+
+func getEffectivePatchStrategy(patchStrategies map[string]string, path string) *EffectivePatchStrategy {
+	segments := strings.Split(path, nodeutils.PatchStrategyKeySeparator)
+	for i := len(segments); i > 0; i-- {
+		candidate := strings.Join(segments[:i], nodeutils.PatchStrategyKeySeparator)
+		strategy, matchedKey := nodeutils.GetPatchStrategy(patchStrategies, candidate)
+		if strategy == "" {
+			continue
+		}
+		return &EffectivePatchStrategy{
+			Path:        path,
+			Strategy:    strings.ToLower(strategy),
+			MatchedKey:  matchedKey,
+			MatchedPath: candidate,
+		}
+	}
+	return &EffectivePatchStrategy{Path: path, Strategy: nodeutils.PatchStrategyMerge}
+}
+
+// getPatchStrategies returns the patch strategies from config metadata, or an empty map if none are configured
+func getPatchStrategies() map[string]string {
+	patchStrategies, err := GetConfigMetadataPatchStrategy()
+	if err != nil || patchStrategies == nil {
+		return make(map[string]string)
+	}
+	return patchStrategies
+}
+
+// sequencePatchStrategyOpts returns the options to upsert items into the keyed config sequence at the path,
+// e.g. contexts, as per the patch strategies. Nested sequences are left to their own setters.
+func sequencePatchStrategyOpts(path string, patchStrategies map[string]string) []nodeutils.PatchStrategyOpts {
+	return []nodeutils.PatchStrategyOpts{
+		nodeutils.WithPatchStrategyKey(path),
+		nodeutils.WithPatchStrategies(patchStrategies),
+		nodeutils.WithSequenceKeys(nodeutils.SequenceKeys{path: nodeutils.DefaultSequenceKeys[path]}),
+	}
+}
+
+// applyValuePatchStrategies deletes the node on the path of keys that is replaced by setting the value,
+// e.g. the other envs if clientOptions.env has the replace patch strategy. Only the nodes on the path are
+// considered, the siblings of the path are never replaced. It returns true if a node was deleted.
+func applyValuePatchStrategies(node *yaml.Node, keys []string, value string) (bool, error) {
+	patchStrategies := getPatchStrategies()
+	if len(patchStrategies) == 0 {
+		return false, nil
+	}
+	parent := node.Content[0]
+	for i, key := range keys {
+		if parent.Kind != yaml.MappingNode {
+			return false, nil
+		}
+		j := mappingKeyIndex(parent, key)
+		if j < 0 {
+			return false, nil
+		}
+		strategy, _ := nodeutils.GetPatchStrategy(patchStrategies, strings.Join(keys[:i+1], nodeutils.PatchStrategyKeySeparator))
+		if strings.EqualFold(strategy, nodeutils.PatchStrategyReplace) {
+			if holdsOnlyValue(parent.Content[j+1], keys[i+1:], value) {
+				return false, nil
+			}
+			parent.Content = append(parent.Content[:j], parent.Content[j+2:]...)
+			return true, nil
+		}
+		parent = parent.Content[j+1]
+	}
+	return false, nil
+}
+
+// mappingKeyIndex returns the index of the key in the content of the mapping node, or -1 if it is missing
+func mappingKeyIndex(node *yaml.Node, key string) int {
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// holdsOnlyValue checks whether the node holds nothing but the value at the path of keys
+func holdsOnlyValue(node *yaml.Node, keys []string, value string) bool {
+	if len(keys) == 0 {
+		return node.Kind == yaml.ScalarNode && node.Value == value
+	}
+	return node.Kind == yaml.MappingNode && len(node.Content) == 2 && node.Content[0].Value == keys[0] &&
+		holdsOnlyValue(node.Content[1], keys[1:], value)
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestGetEffectivePatchStrategy(t *testing.T) {
+	// Setup config data
+	metadata := `configMetadata:
+  patchStrategy:
+    contexts.group: replace
+    contexts.*.endpoint: replace
+    contexts.clusterOpts: merge
+    "**.discoverySources": replace
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfgMetadata: metadata})
+	defer func() {
+		cleanUp()
+	}()
+
+	tests := []struct {
+		path     string
+		expected *EffectivePatchStrategy
+		errStr   string
+	}{
+		{
+			path:     "contexts.group",
+			expected: &EffectivePatchStrategy{Path: "contexts.group", Strategy: "replace", MatchedKey: "contexts.group", MatchedPath: "contexts.group"},
+		},
+		{
+			path:     "contexts.globalOpts.endpoint",
+			expected: &EffectivePatchStrategy{Path: "contexts.globalOpts.endpoint", Strategy: "replace", MatchedKey: "contexts.*.endpoint", MatchedPath: "contexts.globalOpts.endpoint"},
+		},
+		{
+			path:     "contexts.clusterOpts.path",
+			expected: &EffectivePatchStrategy{Path: "contexts.clusterOpts.path", Strategy: "merge", MatchedKey: "contexts.clusterOpts", MatchedPath: "contexts.clusterOpts"},
+		},
+		{
+			path:     "servers.discoverySources.oci.image",
+			expected: &EffectivePatchStrategy{Path: "servers.discoverySources.oci.image", Strategy: "replace", MatchedKey: "**.discoverySources", MatchedPath: "servers.discoverySources"},
+		},
+		{
+			path:     "certs.host",
+			expected: &EffectivePatchStrategy{Path: "certs.host", Strategy: "merge"},
+		},
+		{
+			path:   "",
+			errStr: "path cannot be empty",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			strategy, err := GetEffectivePatchStrategy(tc.path)
+			if tc.errStr != "" {
+				assert.EqualError(t, err, tc.errStr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, strategy)
+		})
+	}
+}
+
+func TestSetContextWithWildcardPatchStrategy(t *testing.T) {
+	// Setup config data
+	cfg := `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: test-endpoint
+      path: test-path
+      context: test-context
+`
+	metadata := `configMetadata:
+  patchStrategy:
+    contexts.*.path: replace
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfgNextGen: cfg, cfgMetadata: metadata})
+	defer func() {
+		cleanUp()
+	}()
+
+	err := SetContext(&configtypes.Context{
+		Name:   "test-mc",
+		Target: configtypes.TargetK8s,
+		ClusterOpts: &configtypes.ClusterServer{
+			Endpoint: "updated-endpoint",
+		},
+	}, false)
+	assert.NoError(t, err)
+
+	ctx, err := GetContext("test-mc")
+	assert.NoError(t, err)
+	assert.Equal(t, "updated-endpoint", ctx.ClusterOpts.Endpoint)
+	assert.Equal(t, "", ctx.ClusterOpts.Path)
+	assert.Equal(t, "test-context", ctx.ClusterOpts.Context)
+}
+
+func TestSetContextWithItemWildcardPatchStrategy(t *testing.T) {
+	// Setup config data
+	cfg := `contexts:
+  - name: test-mc
+    target: kubernetes
+    clusterOpts:
+      endpoint: test-endpoint
+    discoverySources:
+      - oci:
+          name: default
+          image: test-image
+      - oci:
+          name: other
+          image: other-image
+`
+	metadata := `configMetadata:
+  patchStrategy:
+    contexts.*.discoverySources: replace
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfgNextGen: cfg, cfgMetadata: metadata})
+	defer func() {
+		cleanUp()
+	}()
+
+	err := SetContext(&configtypes.Context{
+		Name:   "test-mc",
+		Target: configtypes.TargetK8s,
+		DiscoverySources: []configtypes.PluginDiscovery{
+			{OCI: &configtypes.OCIDiscovery{Name: "default", Image: "updated-image"}},
+		},
+	}, false)
+	assert.NoError(t, err)
+
+	ctx, err := GetContext("test-mc")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-endpoint", ctx.ClusterOpts.Endpoint)
+	assert.Equal(t, []configtypes.PluginDiscovery{
+		{OCI: &configtypes.OCIDiscovery{Name: "default", Image: "updated-image"}},
+	}, ctx.DiscoverySources)
+}
+
+func TestSetEnvWithPatchStrategy(t *testing.T) {
+	// Setup config data
+	cfg := `clientOptions:
+  env:
+    test: test-value
+    other: other-value
+`
+	metadata := `configMetadata:
+  patchStrategy:
+    clientOptions.env: replace
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfg: cfg, cfgMetadata: metadata})
+	defer func() {
+		cleanUp()
+	}()
+
+	err := SetEnv("test", "test-value")
+	assert.NoError(t, err)
+
+	envs, err := GetAllEnvs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"test": "test-value"}, envs)
+}
+
+func TestSetValueWithSiblingReplacePatchStrategy(t *testing.T) {
+	cfg := `clientOptions:
+  features:
+    global:
+      context-target-v2: "true"
+  env:
+    other: other-value
+`
+	cfgNextGen := `cli:
+  discoverySources:
+    - oci:
+        name: default
+        image: test-image
+`
+	metadata := `configMetadata:
+  patchStrategy:
+    clientOptions.features: replace
+    cli.discoverySources: replace
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfg: cfg, cfgNextGen: cfgNextGen, cfgMetadata: metadata})
+	defer cleanUp()
+
+	// Setting a value keeps the siblings of its path that have the replace patch strategy
+	assert.NoError(t, SetEnv("test", "test-value"))
+	assert.NoError(t, SetCEIPOptIn("true"))
+	assert.NoError(t, SetEULAStatus(EULAStatusAccepted))
+	assert.NoError(t, SetEdition("tkg"))
+
+	enabled, err := IsFeatureEnabled("global", "context-target-v2")
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	envs, err := GetAllEnvs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"test": "test-value", "other": "other-value"}, envs)
+	sources, err := GetCLIDiscoverySources()
+	assert.NoError(t, err)
+	assert.Equal(t, []configtypes.PluginDiscovery{{OCI: &configtypes.OCIDiscovery{Name: "default", Image: "test-image"}}}, sources)
+
+	// Setting a value on the path replaces the node with the replace patch strategy
+	assert.NoError(t, SetFeature("plugin", "new-feature", "true"))
+	enabled, err = IsFeatureEnabled("plugin", "new-feature")
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	_, err = IsFeatureEnabled("global", "context-target-v2")
+	assert.ErrorContains(t, err, "not found")
+}
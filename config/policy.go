@@ -0,0 +1,144 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/nodeutils"
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+const (
+	// EnvPolicyConfigKey is the environment variable that points to the policy config
+	EnvPolicyConfigKey = "TANZU_POLICY_CONFIG"
+	// PolicyConfigName is the name of the policy config stored in SystemConfigDir
+	PolicyConfigName = "policy.yaml"
+)
+
+// ErrPolicyLocked is returned when updating a config setting that is locked by the policy config
+var ErrPolicyLocked = errors.New("config setting is locked by policy")
+
+// PolicyLockedError describes the config setting that is locked by the policy config.
+// It matches ErrPolicyLocked with errors.Is.
+type PolicyLockedError struct {
+	// Path addresses the locked setting as described by nodeutils.Change e.g. cli.ceipOptIn
+	Path string
+}
+
+func (e *PolicyLockedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrPolicyLocked.Error(), e.Path)
+}
+
+func (e *PolicyLockedError) Unwrap() error {
+	return ErrPolicyLocked
+}
+
+// PolicyConfigPath returns the path of the policy config, checking for environment overrides.
+// The policy config has the layout of the tanzu config and every value it sets is locked,
+// e.g. `cli: {ceipOptIn: "false"}` pins the CEIP opt-in status.
+func PolicyConfigPath() string {
+	if path, ok := os.LookupEnv(EnvPolicyConfigKey); ok {
+		return path
+	}
+	return filepath.Join(SystemConfigDir, PolicyConfigName)
+}
+
+// GetPolicyLockedSettings retrieves the settings locked by the policy config, mapping the path of each setting
+// e.g. clientOptions.features.global.context-target-v2 to its pinned value
+func GetPolicyLockedSettings() (map[string]string, error) {
+	node, err := readConfigLayerFile(PolicyConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string)
+	for _, leaf := range nodeutils.Leaves(node) {
+		settings[leaf.Path] = leaf.Node.Value
+	}
+	return settings, nil
+}
+
+// IsPolicyLocked checks whether the setting at the path, or any setting nested under it, is locked by the policy config
+func IsPolicyLocked(path string) (bool, error) {
+	settings, err := GetPolicyLockedSettings()
+	if err != nil {
+		return false, err
+	}
+	return lockedPath(settings, path) != "", nil
+}
+
+// checkPolicyLocked returns a PolicyLockedError if any of the paths is locked by the policy config
+func checkPolicyLocked(paths ...string) error {
+	settings, err := GetPolicyLockedSettings()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if locked := lockedPath(settings, path); locked != "" {
+			return &PolicyLockedError{Path: locked}
+		}
+	}
+	return nil
+}
+
+// checkDiscoverySourcePolicyLocked returns a PolicyLockedError if the discovery source is locked by the policy config
+func checkDiscoverySourcePolicyLocked(discoverySource configtypes.PluginDiscovery) error {
+	discoverySourceType, discoverySourceName, err := getDiscoverySourceTypeAndName(discoverySource)
+	if err != nil {
+		return err
+	}
+	return checkPolicyLocked(discoverySourcePolicyPath(discoverySourceType, discoverySourceName))
+}
+
+// checkDiscoverySourceNamePolicyLocked returns a PolicyLockedError if a discovery source of any type with the name
+// is locked by the policy config
+func checkDiscoverySourceNamePolicyLocked(name string) error {
+	paths := make([]string, 0, 5)
+	for _, discoverySourceType := range []string{DiscoveryTypeOCI, DiscoveryTypeLocal, DiscoveryTypeGCP, DiscoveryTypeKubernetes, DiscoveryTypeREST} {
+		paths = append(paths, discoverySourcePolicyPath(discoverySourceType, name))
+	}
+	return checkPolicyLocked(paths...)
+}
+
+func discoverySourcePolicyPath(discoverySourceType, name string) string {
+	return fmt.Sprintf("%s.%s[%s/%s]", KeyCLI, KeyDiscoverySources, discoverySourceType, name)
+}
+
+func contextPolicyPath(name string) string {
+	return fmt.Sprintf("%s[%s]", KeyContexts, name)
+}
+
+func serverPolicyPath(name string) string {
+	return fmt.Sprintf("%s[%s]", KeyServers, name)
+}
+
+func certPolicyPath(host string) string {
+	return fmt.Sprintf("%s[%s]", KeyCerts, host)
+}
+
+func featurePolicyPath(plugin, key string) string {
+	return strings.Join([]string{KeyClientOptions, KeyFeatures, plugin, key}, nodeutils.PatchStrategyKeySeparator)
+}
+
+func envPolicyPath(key string) string {
+	return strings.Join([]string{KeyClientOptions, KeyEnv, key}, nodeutils.PatchStrategyKeySeparator)
+}
+
+// lockedPath returns the locked setting at the path or nested under it, or an empty string if there is none
+func lockedPath(settings map[string]string, path string) string {
+	if _, ok := settings[path]; ok {
+		return path
+	}
+	for locked := range settings {
+		if strings.HasPrefix(locked, path+nodeutils.PatchStrategyKeySeparator) || strings.HasPrefix(locked, path+"[") {
+			return locked
+		}
+	}
+	return ""
+}
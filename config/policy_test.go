@@ -0,0 +1,138 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func setupTestPolicy(t *testing.T, policy string) {
+	path := filepath.Join(t.TempDir(), PolicyConfigName)
+	require.NoError(t, os.WriteFile(path, []byte(policy), 0644))
+	t.Setenv(EnvPolicyConfigKey, path)
+}
+
+func TestPolicyLockedSettings(t *testing.T) {
+	cfg := `clientOptions:
+  features:
+    global:
+      context-target-v2: "true"
+`
+	_, cleanUp := setupTestConfig(t, &CfgTestData{cfg: cfg})
+	defer cleanUp()
+
+	setupTestPolicy(t, `cli:
+  ceipOptIn: "false"
+  discoverySources:
+    - oci:
+        name: default
+        image: registry.example.com/plugins:latest
+clientOptions:
+  features:
+    global:
+      context-target-v2: "false"
+  env:
+    TANZU_CLI_PLUGIN_REGISTRY: registry.example.com
+`)
+
+	settings, err := GetPolicyLockedSettings()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"cli.ceipOptIn": "false",
+		"cli.discoverySources[oci/default].oci.name":      "default",
+		"cli.discoverySources[oci/default].oci.image":     "registry.example.com/plugins:latest",
+		"clientOptions.features.global.context-target-v2": "false",
+		"clientOptions.env.TANZU_CLI_PLUGIN_REGISTRY":     "registry.example.com",
+	}, settings)
+
+	locked, err := IsPolicyLocked("cli.discoverySources[oci/default]")
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	locked, err = IsPolicyLocked("cli.eulaStatus")
+	assert.NoError(t, err)
+	assert.False(t, locked)
+
+	// The getters return the pinned values, and the mandatory discovery sources are present
+	ceipOptIn, err := GetCEIPOptIn()
+	assert.NoError(t, err)
+	assert.Equal(t, "false", ceipOptIn)
+	enabled, err := IsFeatureEnabled("global", "context-target-v2")
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	env, err := GetEnv("TANZU_CLI_PLUGIN_REGISTRY")
+	assert.NoError(t, err)
+	assert.Equal(t, "registry.example.com", env)
+	discoverySource, err := GetCLIDiscoverySource("default")
+	assert.NoError(t, err)
+	assert.Equal(t, "registry.example.com/plugins:latest", discoverySource.OCI.Image)
+
+	err = SetCEIPOptIn("true")
+	assert.True(t, errors.Is(err, ErrPolicyLocked))
+	assert.EqualError(t, err, "config setting is locked by policy: cli.ceipOptIn")
+	assert.NoError(t, SetEULAStatus(EULAStatusAccepted))
+
+	assert.ErrorIs(t, SetFeature("global", "context-target-v2", "true"), ErrPolicyLocked)
+	assert.ErrorIs(t, DeleteFeature("global", "context-target-v2"), ErrPolicyLocked)
+	assert.NoError(t, SetFeature("global", "other-feature", "true"))
+
+	assert.ErrorIs(t, SetEnv("TANZU_CLI_PLUGIN_REGISTRY", "other"), ErrPolicyLocked)
+	assert.ErrorIs(t, DeleteEnv("TANZU_CLI_PLUGIN_REGISTRY"), ErrPolicyLocked)
+	assert.NoError(t, SetEnv("OTHER_ENV", "value"))
+
+	source := configtypes.PluginDiscovery{OCI: &configtypes.OCIDiscovery{Name: "default", Image: "other"}}
+	assert.ErrorIs(t, SetCLIDiscoverySource(source), ErrPolicyLocked)
+	assert.ErrorIs(t, SetCLIDiscoverySources([]configtypes.PluginDiscovery{source}), ErrPolicyLocked)
+	assert.ErrorIs(t, DeleteCLIDiscoverySource("default"), ErrPolicyLocked)
+	assert.NoError(t, SetCLIDiscoverySource(configtypes.PluginDiscovery{OCI: &configtypes.OCIDiscovery{Name: "other", Image: "other"}}))
+
+	// Contexts and certs set by the policy are locked too
+	setupTestPolicy(t, `contexts:
+  - name: locked-ctx
+    target: kubernetes
+certs:
+  - host: registry.example.com
+    skipCertVerify: "false"
+`)
+	assert.ErrorIs(t, SetContext(&configtypes.Context{Name: "locked-ctx", Target: configtypes.TargetK8s}, true), ErrPolicyLocked)
+	assert.ErrorIs(t, RemoveContext("locked-ctx"), ErrPolicyLocked)
+	assert.NoError(t, SetContext(&configtypes.Context{Name: "other-ctx", Target: configtypes.TargetK8s}, false))
+	assert.ErrorIs(t, SetCert(&configtypes.Cert{Host: "registry.example.com", SkipCertVerify: "true"}), ErrPolicyLocked)
+	assert.ErrorIs(t, DeleteCert("registry.example.com"), ErrPolicyLocked)
+	assert.NoError(t, SetCert(&configtypes.Cert{Host: "other.example.com", SkipCertVerify: "true"}))
+	ctx, err := GetContext("locked-ctx")
+	assert.NoError(t, err)
+	assert.Equal(t, configtypes.TargetK8s, ctx.Target)
+	cert, err := GetCert("registry.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "false", cert.SkipCertVerify)
+
+	// Default feature flags leave out locked features
+	assert.NoError(t, ConfigureDefaultFeatureFlagsIfMissing("plugin", map[string]bool{"new-feature": true}))
+	setupTestPolicy(t, `clientOptions:
+  features:
+    plugin:
+      locked-feature: "false"
+`)
+	assert.NoError(t, ConfigureDefaultFeatureFlagsIfMissing("plugin", map[string]bool{"locked-feature": true}))
+	enabled, err = IsFeatureEnabled("plugin", "new-feature")
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	enabled, err = IsFeatureEnabled("plugin", "locked-feature")
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	// The policy layer overrides the other layers
+	value, err := GetLayeredConfigValue("clientOptions.features.plugin.locked-feature")
+	assert.NoError(t, err)
+	assert.Equal(t, LayeredValue{Path: "clientOptions.features.plugin.locked-feature", Value: "false", Layer: ConfigLayerPolicy}, *value)
+	assert.ErrorIs(t, SetLayeredConfigValue(ConfigLayerUser, "clientOptions.features.plugin.locked-feature", "true"), ErrPolicyLocked)
+}
@@ -0,0 +1,262 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvConfigProfileKey is the environment variable that overrides the active config profile
+	EnvConfigProfileKey = "TANZU_CONFIG_PROFILE"
+
+	// DefaultProfileName is the name of the profile whose config files are stored directly under LocalDir
+	DefaultProfileName = "default"
+	// ProfilesDirName is the name of the directory under LocalDir in which named profiles are stored
+	ProfilesDirName = "profiles"
+	// ActiveProfileFileName is the name of the file under LocalDir storing the name of the active profile
+	ActiveProfileFileName = ".active-profile"
+)
+
+// profileNameRegex restricts profile names to ones that are safe to use as directory names
+var profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// profileFileNames are the config files stored in each profile
+var profileFileNames = []string{ConfigName, CfgNextGenName, CfgMetadataName}
+
+// GetActiveProfile returns the name of the active config profile.
+// The TANZU_CONFIG_PROFILE environment variable takes precedence over the profile set with SetActiveProfile.
+// It returns an error if the active profile does not exist, rather than creating it on the next update.
+func GetActiveProfile() (string, error) {
+	if profile := os.Getenv(EnvConfigProfileKey); profile != "" {
+		return profile, checkProfileExists(profile)
+	}
+	localDir, err := LocalDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(localDir, ActiveProfileFileName))
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		return DefaultProfileName, nil
+	}
+	profile := strings.TrimSpace(string(data))
+	return profile, checkProfileExists(profile)
+}
+
+// SetActiveProfile switches the active config profile. The profile must exist.
+// The switch happens while holding the config locks so that no update of the current profile is in progress.
+func SetActiveProfile(name string) error {
+	if err := checkProfileExists(name); err != nil {
+		return err
+	}
+	localDir, err := LocalDir()
+	if err != nil {
+		return err
+	}
+
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuConfigLock()
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuMetadataLock()
+
+	// Write the pointer to a temporary file first so readers never see a partially written profile name
+	path := filepath.Join(localDir, ActiveProfileFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(name), 0644); err != nil {
+		return errors.Wrap(err, "failed to write the active profile")
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "failed to set the active profile")
+	}
+	return nil
+}
+
+// ListProfiles returns the names of the config profiles, including the default profile
+func ListProfiles() ([]string, error) {
+	localDir, err := LocalDir()
+	if err != nil {
+		return nil, err
+	}
+	profiles := []string{DefaultProfileName}
+	entries, err := os.ReadDir(filepath.Join(localDir, ProfilesDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to list profiles")
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && validateProfileName(entry.Name()) == nil && entry.Name() != DefaultProfileName {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles[1:])
+	return profiles, nil
+}
+
+// CreateProfile creates a new empty config profile
+func CreateProfile(name string) error {
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuConfigLock()
+
+	dir, err := newProfileDir(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create profile %q", name)
+	}
+	return nil
+}
+
+// CopyProfile creates a new config profile with a copy of the config files of an existing profile
+func CopyProfile(src, dst string) error {
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuConfigLock()
+	if err := acquireTanzuMetadataLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuMetadataLock()
+
+	if err := checkProfileExists(src); err != nil {
+		return err
+	}
+	srcDir, err := profileDir(src)
+	if err != nil {
+		return err
+	}
+	dstDir, err := newProfileDir(dst)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create profile %q", dst)
+	}
+	for _, fileName := range profileFileNames {
+		srcFile := filepath.Join(srcDir, fileName)
+		if exists, err := fileExists(srcFile); err != nil || !exists {
+			continue
+		}
+		if err := copyFile(srcFile, filepath.Join(dstDir, fileName)); err != nil {
+			return errors.Wrapf(err, "failed to copy %s of profile %q", fileName, src)
+		}
+	}
+	return nil
+}
+
+// DeleteProfile deletes a config profile. The default profile and the active profile cannot be deleted.
+func DeleteProfile(name string) error {
+	if name == DefaultProfileName {
+		return errors.New("the default profile cannot be deleted")
+	}
+	dir, err := profileDir(name)
+	if err != nil {
+		return err
+	}
+
+	// Locking the config files of the active profile prevents switching to the profile while it is deleted
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
+	defer ReleaseTanzuConfigLock()
+
+	active, err := GetActiveProfile()
+	if err != nil {
+		return err
+	}
+	if active == name {
+		return errors.Errorf("profile %q is active, switch to another profile before deleting it", name)
+	}
+	if err := checkProfileExists(name); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return errors.Wrapf(err, "failed to delete profile %q", name)
+	}
+	return nil
+}
+
+// ProfileLocalDir returns the directory in which the config files of the active profile are stored
+func ProfileLocalDir() (string, error) {
+	profile, err := GetActiveProfile()
+	if err != nil {
+		return "", err
+	}
+	return profileDir(profile)
+}
+
+// profileDir returns the directory of the named profile
+func profileDir(name string) (string, error) {
+	if err := validateProfileName(name); err != nil {
+		return "", err
+	}
+	localDir, err := LocalDir()
+	if err != nil {
+		return "", err
+	}
+	if name == DefaultProfileName {
+		return localDir, nil
+	}
+	return filepath.Join(localDir, ProfilesDirName, name), nil
+}
+
+// newProfileDir returns the directory of a profile to be created, which must not exist yet
+func newProfileDir(name string) (string, error) {
+	dir, err := profileDir(name)
+	if err != nil {
+		return "", err
+	}
+	if name == DefaultProfileName {
+		return "", errors.Errorf("profile %q already exists", name)
+	}
+	exists, err := fileExists(dir)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", errors.Errorf("profile %q already exists", name)
+	}
+	return dir, nil
+}
+
+// checkProfileExists returns an error if the profile name is invalid or the profile does not exist
+func checkProfileExists(name string) error {
+	dir, err := profileDir(name)
+	if err != nil {
+		return err
+	}
+	exists, err := fileExists(dir)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.Errorf("profile %q not found", name)
+	}
+	return nil
+}
+
+func validateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return errors.Errorf("invalid profile name %q, names must start with a letter or digit and only contain letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// isDefaultProfileActive returns true if the config files of the default profile are in use
+func isDefaultProfileActive() bool {
+	profile, err := GetActiveProfile()
+	return err == nil && profile == DefaultProfileName
+}
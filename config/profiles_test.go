@@ -0,0 +1,99 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configtypes "github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+// setupTestProfiles points the local dir to a temporary directory and clears the per file overrides
+func setupTestProfiles(t *testing.T) string {
+	localDir := t.TempDir()
+	t.Setenv(EnvConfigDirKey, localDir)
+	t.Setenv(EnvConfigProfileKey, "")
+	for _, key := range []string{EnvConfigKey, EnvConfigNextGenKey, EnvConfigMetadataKey} {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+	return localDir
+}
+
+func TestProfiles(t *testing.T) {
+	localDir := setupTestProfiles(t)
+
+	profile, err := GetActiveProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultProfileName, profile)
+	path, err := ClientConfigNextGenPath()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(localDir, CfgNextGenName), path)
+
+	// Add a context to the default profile and copy it to a new profile
+	assert.NoError(t, SetContext(&configtypes.Context{Name: "lab", Target: configtypes.TargetK8s}, true))
+	assert.NoError(t, CopyProfile(DefaultProfileName, "customer-a"))
+	assert.NoError(t, CreateProfile("empty"))
+	assert.EqualError(t, CreateProfile("empty"), "profile \"empty\" already exists")
+	assert.EqualError(t, CreateProfile("../escape"), "invalid profile name \"../escape\", names must start with a letter or digit and only contain letters, digits, '.', '_' or '-'")
+
+	profiles, err := ListProfiles()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{DefaultProfileName, "customer-a", "empty"}, profiles)
+
+	// Switch to the copied profile and update it independently of the default profile
+	assert.NoError(t, SetActiveProfile("customer-a"))
+	profile, err = GetActiveProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, "customer-a", profile)
+	path, err = ClientConfigPath()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(localDir, ProfilesDirName, "customer-a", ConfigName), path)
+
+	ctx, err := GetContext("lab")
+	assert.NoError(t, err)
+	assert.Equal(t, "lab", ctx.Name)
+	assert.NoError(t, SetContext(&configtypes.Context{Name: "customer", Target: configtypes.TargetK8s}, true))
+	assert.EqualError(t, DeleteProfile("customer-a"), "profile \"customer-a\" is active, switch to another profile before deleting it")
+
+	assert.NoError(t, SetActiveProfile("empty"))
+	_, err = GetContext("lab")
+	assert.Error(t, err)
+
+	// The environment variable overrides the active profile
+	t.Setenv(EnvConfigProfileKey, DefaultProfileName)
+	_, err = GetContext("customer")
+	assert.Error(t, err)
+	_, err = GetContext("lab")
+	assert.NoError(t, err)
+	assert.NoError(t, os.Unsetenv(EnvConfigProfileKey))
+
+	assert.NoError(t, DeleteProfile("customer-a"))
+	assert.EqualError(t, DeleteProfile("customer-a"), "profile \"customer-a\" not found")
+	assert.EqualError(t, DeleteProfile(DefaultProfileName), "the default profile cannot be deleted")
+	assert.EqualError(t, SetActiveProfile("customer-a"), "profile \"customer-a\" not found")
+
+	assert.NoError(t, SetActiveProfile(DefaultProfileName))
+	profiles, err = ListProfiles()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{DefaultProfileName, "empty"}, profiles)
+
+	// Unknown profiles are rejected rather than created by updates
+	t.Setenv(EnvConfigProfileKey, "unknown")
+	_, err = GetActiveProfile()
+	assert.EqualError(t, err, "profile \"unknown\" not found")
+	err = SetContext(&configtypes.Context{Name: "unknown", Target: configtypes.TargetK8s}, true)
+	assert.ErrorContains(t, err, "profile \"unknown\" not found")
+	assert.NoDirExists(t, filepath.Join(localDir, ProfilesDirName, "unknown"))
+
+	// The lock files of the active profile are used
+	t.Setenv(EnvConfigProfileKey, "empty")
+	assert.NoError(t, SetContext(&configtypes.Context{Name: "empty", Target: configtypes.TargetK8s}, true))
+	assert.FileExists(t, filepath.Join(localDir, ProfilesDirName, "empty", LocalTanzuFileLock))
+}
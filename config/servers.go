@@ -17,12 +17,12 @@ import (
 //
 // Deprecated: This API is deprecated. Use GetContext instead.
 func GetServer(name string) (*configtypes.Server, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	return getServer(node, name)
+	return getServer(cfg, name)
 }
 
 // ServerExists checks if server by specified name is present in config
@@ -37,12 +37,12 @@ func ServerExists(name string) (bool, error) {
 //
 // Deprecated: This API is deprecated. Use GetCurrentContext instead.
 func GetCurrentServer() (*configtypes.Server, error) {
-	// Retrieve client config node
-	node, err := getClientConfigNode()
+	// Retrieve client config
+	cfg, err := getDecodedClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	return getCurrentServer(node)
+	return getCurrentServer(cfg)
 }
 
 // SetCurrentServer add or update current server
@@ -50,37 +50,34 @@ func GetCurrentServer() (*configtypes.Server, error) {
 // Deprecated: This API is deprecated. Use SetCurrentContext instead.
 func SetCurrentServer(name string) error {
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
-	s, err := getServer(node, name)
+	cfg, err := convertNodeToClientConfig(node)
 	if err != nil {
 		return err
 	}
-	persist, err := setCurrentServer(node, name)
+	s, err := getServer(cfg, name)
 	if err != nil {
 		return err
 	}
-	if persist {
-		err = persistConfig(node)
-		if err != nil {
-			return err
-		}
+	persist, err := setCurrentServer(node, name)
+	if err != nil {
+		return err
 	}
 	// Front fill CurrentContext
 	c := convertServerToContext(s)
-	persist, err = setCurrentContext(node, c)
+	persistContext, err := setCurrentContext(node, c)
 	if err != nil {
 		return err
 	}
-	if persist {
-		err = persistConfig(node)
-		if err != nil {
-			return err
-		}
+	if persist || persistContext {
+		return persistConfig(node, "SetCurrentServer")
 	}
 	return nil
 }
@@ -90,13 +87,19 @@ func SetCurrentServer(name string) error {
 // Deprecated: This API is deprecated. Use RemoveCurrentContext instead.
 func RemoveCurrentServer(name string) error {
 	// Retrieve client config node
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
-	_, err = getServer(node, name)
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return err
+	}
+	_, err = getServer(cfg, name)
 	if err != nil {
 		return err
 	}
@@ -106,7 +109,7 @@ func RemoveCurrentServer(name string) error {
 	}
 
 	// Front fill Context and CurrentContext
-	c, err := getContext(node, name)
+	c, err := getContext(cfg, name)
 	if err != nil {
 		return err
 	}
@@ -114,7 +117,7 @@ func RemoveCurrentServer(name string) error {
 	if err != nil {
 		return err
 	}
-	return persistConfig(node)
+	return persistConfig(node, "RemoveCurrentServer")
 }
 
 // PutServer add or update server and currentServer
@@ -136,7 +139,9 @@ func AddServer(s *configtypes.Server, setCurrent bool) error {
 // Deprecated: This API is deprecated. Use AddContext or SetContext instead.
 func SetServer(s *configtypes.Server, setCurrent bool) error {
 	// Acquire tanzu config lock
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
@@ -146,59 +151,39 @@ func SetServer(s *configtypes.Server, setCurrent bool) error {
 	if err != nil {
 		return err
 	}
-	if persist {
-		err = persistConfig(node)
-		if err != nil {
-			return err
-		}
-	}
 	if setCurrent && s.Type == configtypes.ManagementClusterServerType {
-		persist, err = setCurrentServer(node, s.Name)
+		persistCurrent, err := setCurrentServer(node, s.Name)
 		if err != nil {
 			return err
 		}
-		if persist {
-			err = persistConfig(node)
-			if err != nil {
-				return err
-			}
-		}
+		persist = persist || persistCurrent
 	}
 
-	err = frontFillContexts(s, setCurrent, node)
+	persistContexts, err := frontFillContexts(s, setCurrent, node)
 	if err != nil {
 		return err
 	}
-
+	if persist || persistContexts {
+		return persistConfig(node, "SetServer")
+	}
 	return nil
 }
 
-func frontFillContexts(s *configtypes.Server, setCurrent bool, node *yaml.Node) error {
+func frontFillContexts(s *configtypes.Server, setCurrent bool, node *yaml.Node) (persist bool, err error) {
 	// Front fill Context and CurrentContext
 	c := convertServerToContext(s)
-	persist, err := setContext(node, c)
+	persist, err = setContext(node, c)
 	if err != nil {
-		return err
-	}
-	if persist {
-		err = persistConfig(node)
-		if err != nil {
-			return err
-		}
+		return false, err
 	}
 	if setCurrent {
-		persist, err = setCurrentContext(node, c)
+		persistCurrent, err := setCurrentContext(node, c)
 		if err != nil {
-			return err
-		}
-		if persist {
-			err = persistConfig(node)
-			if err != nil {
-				return err
-			}
+			return false, err
 		}
+		persist = persist || persistCurrent
 	}
-	return nil
+	return persist, nil
 }
 
 // DeleteServer deletes the server specified by name
@@ -212,13 +197,19 @@ func DeleteServer(name string) error {
 //
 // Deprecated: This API is deprecated. Use DeleteContext instead.
 func RemoveServer(name string) error {
-	AcquireTanzuConfigLock()
+	if err := acquireTanzuConfigLock(); err != nil {
+		return err
+	}
 	defer ReleaseTanzuConfigLock()
 	node, err := getClientConfigNodeNoLock()
 	if err != nil {
 		return err
 	}
-	_, err = getServer(node, name)
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return err
+	}
+	_, err = getServer(cfg, name)
 	if err != nil {
 		return err
 	}
@@ -231,7 +222,7 @@ func RemoveServer(name string) error {
 		return err
 	}
 	// Front fill Context and CurrentContext
-	c, err := getContext(node, name)
+	c, err := getContext(cfg, name)
 	if err != nil {
 		return err
 	}
@@ -243,11 +234,15 @@ func RemoveServer(name string) error {
 	if err != nil {
 		return err
 	}
-	return persistConfig(node)
+	return persistConfig(node, "RemoveServer")
 }
 
 func setCurrentServer(node *yaml.Node, name string) (persist bool, err error) {
-	s, err := getServer(node, name)
+	cfg, err := convertNodeToClientConfig(node)
+	if err != nil {
+		return false, err
+	}
+	s, err := getServer(cfg, name)
 	if err != nil {
 		return false, err
 	}
@@ -271,16 +266,12 @@ func setCurrentServer(node *yaml.Node, name string) (persist bool, err error) {
 	return persist, err
 }
 
-func getServer(node *yaml.Node, name string) (*configtypes.Server, error) {
+func getServer(cfg *configtypes.ClientConfig, name string) (*configtypes.Server, error) {
 	// check if name is empty
 	if name == "" {
 		return nil, errors.New("name cannot be empty")
 	}
 
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
 	for _, server := range cfg.KnownServers {
 		if server.Name == name {
 			return server, nil
@@ -289,11 +280,7 @@ func getServer(node *yaml.Node, name string) (*configtypes.Server, error) {
 	return nil, fmt.Errorf("could not find server %q", name)
 }
 
-func getCurrentServer(node *yaml.Node) (s *configtypes.Server, err error) {
-	cfg, err := convertNodeToClientConfig(node)
-	if err != nil {
-		return nil, err
-	}
+func getCurrentServer(cfg *configtypes.ClientConfig) (s *configtypes.Server, err error) {
 	for _, server := range cfg.KnownServers {
 		if server.Name == cfg.CurrentServer {
 			return server, nil
@@ -364,11 +351,8 @@ func setServer(node *yaml.Node, s *configtypes.Server) (persist bool, err error)
 		return false, errors.New("server name cannot be empty")
 	}
 
-	// Get Patch Strategies
-	patchStrategies, err := GetConfigMetadataPatchStrategy()
-	if err != nil {
-		patchStrategies = make(map[string]string)
-	}
+	// Get Patch Strategies from config metadata
+	patchStrategies := getPatchStrategies()
 	var persistDiscoverySources bool
 
 	// convert server to node
@@ -385,42 +369,29 @@ func setServer(node *yaml.Node, s *configtypes.Server) (persist bool, err error)
 	if serversNode == nil {
 		return persist, nodeutils.ErrNodeNotFound
 	}
-	exists := false
-	var result []*yaml.Node
-	//nolint: dupl
-	for _, serverNode := range serversNode.Content {
-		if index := nodeutils.GetNodeIndex(serverNode.Content, "name"); index != -1 &&
-			serverNode.Content[index].Value == s.Name {
-			exists = true
-			_, err = nodeutils.DeleteNodes(newServerNode.Content[0], serverNode, nodeutils.WithPatchStrategyKey(KeyServers), nodeutils.WithPatchStrategies(patchStrategies))
-			if err != nil {
-				return false, err
-			}
-			persist, err = nodeutils.MergeNodes(newServerNode.Content[0], serverNode)
-			if err != nil {
-				return false, err
-			}
-			// add or update discovery sources of server
-			persistDiscoverySources, err = setDiscoverySources(serverNode, s.DiscoverySources, nodeutils.WithPatchStrategyKey(fmt.Sprintf("%v.%v", KeyServers, KeyDiscoverySources)), nodeutils.WithPatchStrategies(patchStrategies))
-			if err != nil {
-				return false, err
-			}
-			if persistDiscoverySources {
-				_, err = nodeutils.MergeNodes(newServerNode.Content[0], serverNode)
-				if err != nil {
-					return false, err
-				}
-			}
-			result = append(result, serverNode)
-			continue
-		}
-		result = append(result, serverNode)
+	// Upsert the server by name, replacing nodes as per patch strategy
+	serverNode := nodeutils.FindSequenceItem(serversNode, s.Name, nodeutils.DefaultSequenceKeys[KeyServers])
+	newServersNode := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{newServerNode.Content[0]}}
+	patchStrategyOpts := sequencePatchStrategyOpts(KeyServers, patchStrategies)
+	if _, err = nodeutils.DeleteNodes(newServersNode, serversNode, patchStrategyOpts...); err != nil {
+		return false, err
 	}
-	if !exists {
-		result = append(result, newServerNode.Content[0])
-		persist = true
+	persist, err = nodeutils.MergeNodes(newServersNode, serversNode, patchStrategyOpts...)
+	if err != nil || serverNode == nil {
+		return persist, err
+	}
+
+	// add or update discovery sources of the existing server
+	persistDiscoverySources, err = setDiscoverySources(serverNode, s.DiscoverySources, nodeutils.WithPatchStrategyKey(fmt.Sprintf("%v.%v", KeyServers, KeyDiscoverySources)), nodeutils.WithPatchStrategies(patchStrategies))
+	if err != nil {
+		return false, err
+	}
+	if persistDiscoverySources {
+		_, err = nodeutils.MergeNodes(newServerNode.Content[0], serverNode)
+		if err != nil {
+			return false, err
+		}
 	}
-	serversNode.Content = result
 	return persistDiscoverySources || persist, err
 }
 
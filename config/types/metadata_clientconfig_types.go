@@ -15,4 +15,16 @@ type ConfigMetadata struct {
 	PatchStrategy map[string]string `json:"patchStrategy,omitempty" yaml:"patchStrategy,omitempty" mapstructure:"patchStrategy,omitempty"`
 	// Settings related to config
 	Settings map[string]string `json:"settings,omitempty" yaml:"settings,omitempty" mapstructure:"settings,omitempty"`
+	// DefaultFeatureFlags records the default feature flags applied by each plugin
+	DefaultFeatureFlags map[string]*DefaultFeatureFlagsRecord `json:"defaultFeatureFlags,omitempty" yaml:"defaultFeatureFlags,omitempty" mapstructure:"defaultFeatureFlags,omitempty"`
+}
+
+// DefaultFeatureFlagsRecord records the default feature flags applied to the config by a plugin version
+type DefaultFeatureFlagsRecord struct {
+	// Version of the plugin that applied the default feature flags
+	Version string `json:"version,omitempty" yaml:"version,omitempty" mapstructure:"version,omitempty"`
+	// Flags are the default feature flags and values that were applied because they were missing
+	Flags map[string]string `json:"flags,omitempty" yaml:"flags,omitempty" mapstructure:"flags,omitempty"`
+	// Defaults are all the default feature flags and values of the plugin version
+	Defaults map[string]string `json:"defaults,omitempty" yaml:"defaults,omitempty" mapstructure:"defaults,omitempty"`
 }
@@ -0,0 +1,130 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// PluginAPIVersion is the version of the plugin API, which covers the config schema, spoken by this
+	// version of the runtime. Minor versions are backward compatible.
+	PluginAPIVersion = "v1.0"
+
+	// EnvCLIVersionKey is the environment variable through which the CLI advertises its version to plugins
+	EnvCLIVersionKey = "TANZU_CLI_VERSION"
+	// EnvCLIPluginAPIVersionKey is the environment variable through which the CLI advertises the plugin API
+	// version it speaks
+	EnvCLIPluginAPIVersionKey = "TANZU_CLI_PLUGIN_API_VERSION"
+)
+
+// compatibilityExemptCommands are the commands of the plugin that run even if the CLI is incompatible,
+// so that the CLI can still describe, diagnose and uninstall the plugin, and complete its command line
+var compatibilityExemptCommands = []string{"info", "version", "describe", "manifest", DoctorCommandName, PreUninstallHookName,
+	"help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd}
+
+// ErrIncompatibleCLI is returned when the CLI running the plugin is not compatible with the plugin
+var ErrIncompatibleCLI = errors.New("incompatible CLI")
+
+// CompatibilityError is returned when the CLI running the plugin is not compatible with the plugin
+type CompatibilityError struct {
+	Plugin string
+	Reason string
+}
+
+func (e *CompatibilityError) Error() string {
+	return fmt.Sprintf("plugin %q is not compatible with the CLI: %s", e.Plugin, e.Reason)
+}
+
+func (e *CompatibilityError) Unwrap() error {
+	return ErrIncompatibleCLI
+}
+
+// Compatibility declares the CLI the plugin works with. Versions are semantic versions e.g. v1.0, v1.2.0.
+type Compatibility struct {
+	// MinAPIVersion is the minimum plugin API version the plugin works with, defaults to PluginAPIVersion
+	MinAPIVersion string `json:"minAPIVersion,omitempty" yaml:"minAPIVersion,omitempty"`
+
+	// MaxAPIVersion is the maximum plugin API version the plugin works with, defaults to any version with
+	// the same major version as MinAPIVersion
+	MaxAPIVersion string `json:"maxAPIVersion,omitempty" yaml:"maxAPIVersion,omitempty"`
+
+	// MinCLIVersion is the minimum version of the CLI the plugin works with
+	MinCLIVersion string `json:"minCLIVersion,omitempty" yaml:"minCLIVersion,omitempty"`
+
+	// Degraded runs the plugin with a warning instead of failing when the CLI is not compatible.
+	// The plugin can check Plugin.CompatibilityError to turn off the features that need a compatible CLI.
+	Degraded bool `json:"degraded,omitempty" yaml:"degraded,omitempty"`
+}
+
+// validateCompatibility returns an error if the versions of the compatibility are not valid
+func validateCompatibility(c *Compatibility) error {
+	for _, version := range []string{c.MinAPIVersion, c.MaxAPIVersion, c.MinCLIVersion} {
+		if version != "" && !semver.IsValid(version) {
+			return errors.Errorf("compatibility version %q is not a valid semantic version", version)
+		}
+	}
+	if c.MinAPIVersion != "" && c.MaxAPIVersion != "" && semver.Compare(c.MinAPIVersion, c.MaxAPIVersion) > 0 {
+		return errors.Errorf("compatibility min API version %q is greater than the max API version %q", c.MinAPIVersion, c.MaxAPIVersion)
+	}
+	return nil
+}
+
+// checkCLICompatibility checks the CLI versions advertised through the environment against the compatibility
+// of the plugin. Versions the CLI does not advertise, as done by older CLIs or when the plugin is run directly,
+// are not checked.
+func checkCLICompatibility(desc *PluginDescriptor) error {
+	c := desc.Compatibility
+	if c == nil {
+		c = &Compatibility{}
+	}
+	if apiVersion := os.Getenv(EnvCLIPluginAPIVersionKey); apiVersion != "" {
+		if !semver.IsValid(apiVersion) {
+			return &CompatibilityError{Plugin: desc.Name, Reason: fmt.Sprintf("CLI plugin API version %q is not a valid semantic version", apiVersion)}
+		}
+		minVersion := c.MinAPIVersion
+		if minVersion == "" {
+			minVersion = PluginAPIVersion
+		}
+		if semver.Compare(apiVersion, minVersion) < 0 ||
+			(c.MaxAPIVersion == "" && semver.Major(apiVersion) != semver.Major(minVersion)) ||
+			(c.MaxAPIVersion != "" && semver.Compare(apiVersion, c.MaxAPIVersion) > 0) {
+			return &CompatibilityError{Plugin: desc.Name, Reason: fmt.Sprintf("CLI plugin API version %s is not in the supported range %s", apiVersion, apiVersionRange(minVersion, c.MaxAPIVersion))}
+		}
+	}
+	// Development builds of the CLI have no valid version and are not checked
+	if cliVersion := os.Getenv(EnvCLIVersionKey); c.MinCLIVersion != "" && semver.IsValid(cliVersion) &&
+		semver.Compare(cliVersion, c.MinCLIVersion) < 0 {
+		return &CompatibilityError{Plugin: desc.Name, Reason: fmt.Sprintf("CLI version %s is older than the minimum version %s, upgrade the CLI", cliVersion, c.MinCLIVersion)}
+	}
+	return nil
+}
+
+func apiVersionRange(minVersion, maxVersion string) string {
+	if maxVersion == "" {
+		return fmt.Sprintf("%s to %s.x", minVersion, semver.Major(minVersion))
+	}
+	return fmt.Sprintf("%s to %s", minVersion, maxVersion)
+}
+
+// isCompatibilityExempt returns true if the command of the root command runs even if the CLI is incompatible
+func isCompatibilityExempt(root, cmd *cobra.Command) bool {
+	if cmd == root || !cmd.HasParent() {
+		return false
+	}
+	for cmd.Parent() != root && cmd.HasParent() {
+		cmd = cmd.Parent()
+	}
+	for _, name := range compatibilityExemptCommands {
+		if cmd.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestCheckCLICompatibility(t *testing.T) {
+	tests := []struct {
+		name          string
+		compatibility *Compatibility
+		apiVersion    string
+		cliVersion    string
+		errContains   string
+	}{
+		{name: "nothing advertised", compatibility: &Compatibility{MinCLIVersion: "v1.0.0"}},
+		{name: "default range", apiVersion: "v1.3"},
+		{name: "default range older major", apiVersion: "v0.9", errContains: "CLI plugin API version v0.9 is not in the supported range v1.0 to v1.x"},
+		{name: "default range newer major", apiVersion: "v2.0", errContains: "CLI plugin API version v2.0 is not in the supported range v1.0 to v1.x"},
+		{name: "invalid api version", apiVersion: "latest", errContains: `CLI plugin API version "latest" is not a valid semantic version`},
+		{name: "in range", compatibility: &Compatibility{MinAPIVersion: "v1.1", MaxAPIVersion: "v2.0"}, apiVersion: "v2.0"},
+		{name: "below range", compatibility: &Compatibility{MinAPIVersion: "v1.1", MaxAPIVersion: "v2.0"}, apiVersion: "v1.0", errContains: "CLI plugin API version v1.0 is not in the supported range v1.1 to v2.0"},
+		{name: "above range", compatibility: &Compatibility{MinAPIVersion: "v1.1", MaxAPIVersion: "v2.0"}, apiVersion: "v2.1", errContains: "CLI plugin API version v2.1 is not in the supported range v1.1 to v2.0"},
+		{name: "min CLI version", compatibility: &Compatibility{MinCLIVersion: "v1.1.0"}, cliVersion: "v1.1.0"},
+		{name: "older CLI version", compatibility: &Compatibility{MinCLIVersion: "v1.1.0"}, cliVersion: "v1.0.3", errContains: "CLI version v1.0.3 is older than the minimum version v1.1.0"},
+		{name: "development CLI version", compatibility: &Compatibility{MinCLIVersion: "v1.1.0"}, cliVersion: "dev"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(EnvCLIPluginAPIVersionKey, tc.apiVersion)
+			t.Setenv(EnvCLIVersionKey, tc.cliVersion)
+			err := checkCLICompatibility(&PluginDescriptor{Name: "test-plugin", Compatibility: tc.compatibility})
+			if tc.errContains == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, `plugin "test-plugin" is not compatible with the CLI: `+tc.errContains)
+			assert.True(t, errors.Is(err, ErrIncompatibleCLI))
+		})
+	}
+}
+
+func TestValidatePluginCompatibility(t *testing.T) {
+	assert := assert.New(t)
+
+	descriptor := PluginDescriptor{
+		Name:          "test-plugin",
+		Target:        types.TargetGlobal,
+		Description:   "Description of the plugin",
+		Version:       "v1.2.3",
+		Group:         "TestGroup",
+		Compatibility: &Compatibility{MinCLIVersion: "1.0"},
+	}
+	assert.ErrorContains(ValidatePlugin(&descriptor), `compatibility version "1.0" is not a valid semantic version`)
+
+	descriptor.Compatibility = &Compatibility{MinAPIVersion: "v1.2", MaxAPIVersion: "v1.1"}
+	assert.ErrorContains(ValidatePlugin(&descriptor), `compatibility min API version "v1.2" is greater than the max API version "v1.1"`)
+
+	descriptor.Compatibility = &Compatibility{MinAPIVersion: "v1.0", MaxAPIVersion: "v1.1", MinCLIVersion: "v1.0.0"}
+	assert.NoError(ValidatePlugin(&descriptor))
+}
+
+func TestExecuteIncompatibleCLI(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv(EnvCLIPluginAPIVersionKey, "v2.0")
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	ran := false
+	newTestPlugin := func(degraded bool) *Plugin {
+		p, err := NewPlugin(&PluginDescriptor{
+			Name:                    "test-plugin",
+			Target:                  types.TargetGlobal,
+			Description:             "Description of the plugin",
+			Version:                 "v1.2.3",
+			Group:                   "TestGroup",
+			Compatibility:           &Compatibility{Degraded: degraded},
+			SkipDefaultFeatureFlags: true,
+		})
+		assert.NoError(err)
+		p.AddCommands(&cobra.Command{Use: "run", RunE: func(cmd *cobra.Command, args []string) error {
+			ran = true
+			return nil
+		}})
+		p.Cmd.SetOut(&bytes.Buffer{})
+		return p
+	}
+
+	// Commands fail when the CLI is not compatible
+	p := newTestPlugin(false)
+	assert.True(errors.Is(p.CompatibilityError(), ErrIncompatibleCLI))
+	os.Args = []string{"test-plugin", "run"}
+	err := p.Execute()
+	assert.ErrorContains(err, `plugin "test-plugin" is not compatible with the CLI`)
+	assert.False(ran)
+
+	// Commands describing or uninstalling the plugin still run
+	os.Args = []string{"test-plugin", "version"}
+	assert.NoError(p.Execute())
+	os.Args = []string{"test-plugin", PreUninstallHookName}
+	assert.NoError(p.Execute())
+
+	// The arguments set on the command are checked rather than the ones of the process
+	p.Cmd.SetArgs([]string{"run"})
+	assert.ErrorIs(p.Execute(), ErrIncompatibleCLI)
+	assert.False(ran)
+	p.Cmd.SetArgs([]string{"run", "--help"})
+	assert.NoError(p.Execute())
+	for _, completeCmd := range []string{cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd} {
+		p.Cmd.SetArgs([]string{completeCmd, "ru"})
+		assert.NoError(p.Execute())
+	}
+	p.Cmd.SetArgs([]string{"version"})
+	assert.NoError(p.Execute())
+
+	// Commands run in degraded mode
+	p = newTestPlugin(true)
+	os.Args = []string{"test-plugin", "run"}
+	assert.NoError(p.Execute())
+	assert.True(ran)
+	assert.Error(p.CompatibilityError())
+
+	// Commands run when the CLI is compatible
+	t.Setenv(EnvCLIPluginAPIVersionKey, PluginAPIVersion)
+	ran = false
+	p = newTestPlugin(false)
+	assert.NoError(p.CompatibilityError())
+	assert.NoError(p.Execute())
+	assert.True(ran)
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
+)
+
+// CompletionFunc completes the arguments or the flag values of a command, to be set as the ValidArgsFunction
+// of the command or registered with RegisterFlagCompletionFunc
+type CompletionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// validateCompletion returns an error if the dynamic completion settings of the descriptor are not valid.
+// Settings that do not match the native or static completion type are left over by older descriptors and
+// ignored by the plugin, so they are only warned about.
+func validateCompletion(p *PluginDescriptor) error {
+	switch p.CompletionType {
+	case NativePluginCompletion:
+		if len(p.CompletionArgs) > 0 || p.CompletionCommand != "" {
+			log.Warningf("plugin %q: completion args and completion command are ignored for native completion", p.Name)
+		}
+	case StaticPluginCompletion:
+		if len(p.CompletionArgs) == 0 {
+			log.Warningf("plugin %q: completion args are empty for static completion", p.Name)
+		}
+		if p.CompletionCommand != "" {
+			log.Warningf("plugin %q: completion command is ignored for static completion", p.Name)
+		}
+	case DynamicPluginCompletion:
+		if p.CompletionCommand == "" {
+			return fmt.Errorf("completion command cannot be empty for dynamic completion")
+		}
+		if strings.ContainsAny(p.CompletionCommand, " \t\n") {
+			return fmt.Errorf("completion command %q must be a single word", p.CompletionCommand)
+		}
+		if len(p.CompletionArgs) > 0 {
+			return fmt.Errorf("completion args cannot be set for dynamic completion")
+		}
+	default:
+		log.Warningf("plugin %q: completion type %d is not valid, falling back to native completion", p.Name, p.CompletionType)
+	}
+	return nil
+}
+
+// newCompletionCmd creates the hidden command the CLI calls to retrieve the completion nouns of the plugin,
+// which are the names of its visible commands starting with the optional argument
+func newCompletionCmd(desc *PluginDescriptor) *cobra.Command {
+	return &cobra.Command{
+		Use:    desc.CompletionCommand,
+		Short:  "Plugin completion nouns",
+		Hidden: true,
+		Args:   cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prefix := ""
+			if len(args) > 0 {
+				prefix = args[0]
+			}
+			for _, c := range cmd.Root().Commands() {
+				if c.IsAvailableCommand() && strings.HasPrefix(c.Name(), prefix) {
+					fmt.Fprintln(cmd.OutOrStdout(), c.Name())
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// CompleteContextNames returns a completion function of the names of the contexts of the target,
+// or of all the contexts if the target is unknown
+func CompleteContextNames(target types.Target) CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cfg, err := config.GetClientConfig()
+		if err != nil || cfg == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var completions []string
+		for _, ctx := range cfg.KnownContexts {
+			if ctx == nil || (target != types.TargetUnknown && ctx.Target != target) {
+				continue
+			}
+			if strings.HasPrefix(ctx.Name, toComplete) {
+				completions = append(completions, fmt.Sprintf("%s\t%s", ctx.Name, ctx.Target))
+			}
+		}
+		sort.Strings(completions)
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// CompleteTargets completes the supported targets
+func CompleteTargets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, target := range types.SupportedTargets {
+		if strings.HasPrefix(string(target), toComplete) {
+			completions = append(completions, string(target))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteDiscoverySourceNames completes the names of the CLI discovery sources
+func CompleteDiscoverySourceNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names, err := config.GetCLIDiscoverySourceNames()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, name := range names {
+		if strings.HasPrefix(name.Name, toComplete) {
+			completions = append(completions, fmt.Sprintf("%s\t%s", name.Name, name.Type))
+		}
+	}
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
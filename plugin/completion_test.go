@@ -0,0 +1,155 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
+)
+
+func TestValidatePluginCompletion(t *testing.T) {
+	tests := []struct {
+		name              string
+		completionType    PluginCompletionType
+		completionArgs    []string
+		completionCommand string
+		errContains       string
+		warnContains      string
+	}{
+		{name: "native", completionType: NativePluginCompletion},
+		{name: "native with args", completionType: NativePluginCompletion, completionArgs: []string{"cluster"}, warnContains: "completion args and completion command are ignored for native completion"},
+		{name: "static", completionType: StaticPluginCompletion, completionArgs: []string{"cluster"}},
+		{name: "static without args", completionType: StaticPluginCompletion, warnContains: "completion args are empty for static completion"},
+		{name: "static with command", completionType: StaticPluginCompletion, completionArgs: []string{"cluster"}, completionCommand: "nouns", warnContains: "completion command is ignored for static completion"},
+		{name: "dynamic", completionType: DynamicPluginCompletion, completionCommand: "nouns"},
+		{name: "dynamic without command", completionType: DynamicPluginCompletion, errContains: "completion command cannot be empty for dynamic completion"},
+		{name: "dynamic with spaces", completionType: DynamicPluginCompletion, completionCommand: "list nouns", errContains: `completion command "list nouns" must be a single word`},
+		{name: "dynamic with args", completionType: DynamicPluginCompletion, completionCommand: "nouns", completionArgs: []string{"cluster"}, errContains: "completion args cannot be set for dynamic completion"},
+		{name: "unknown", completionType: PluginCompletionType(5), warnContains: "completion type 5 is not valid, falling back to native completion"},
+	}
+	var stderr bytes.Buffer
+	log.SetStderr(&stderr)
+	defer log.SetStderr(nil)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stderr.Reset()
+			err := ValidatePlugin(&PluginDescriptor{
+				Name:              "test-plugin",
+				Target:            types.TargetGlobal,
+				Description:       "Description of the plugin",
+				Version:           "v1.2.3",
+				Group:             "TestGroup",
+				CompletionType:    tc.completionType,
+				CompletionArgs:    tc.completionArgs,
+				CompletionCommand: tc.completionCommand,
+			})
+			if tc.errContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, `plugin "test-plugin": `+tc.errContains)
+			}
+			if tc.warnContains == "" {
+				assert.Empty(t, stderr.String())
+			} else {
+				assert.Contains(t, stderr.String(), `plugin "test-plugin": `+tc.warnContains)
+			}
+		})
+	}
+}
+
+func TestPluginCompletion(t *testing.T) {
+	assert := assert.New(t)
+
+	newTestPlugin := func(descriptor *PluginDescriptor) (*Plugin, *bytes.Buffer) {
+		descriptor.Name = "test-plugin"
+		descriptor.Target = types.TargetGlobal
+		descriptor.Description = "Description of the plugin"
+		descriptor.Version = "v1.2.3"
+		descriptor.Group = "TestGroup"
+		descriptor.SkipDefaultFeatureFlags = true
+		p, err := NewPlugin(descriptor)
+		assert.NoError(err)
+		p.AddCommands(
+			&cobra.Command{Use: "cluster", Run: func(cmd *cobra.Command, args []string) {}},
+			&cobra.Command{Use: "clusterclass", Run: func(cmd *cobra.Command, args []string) {}},
+			&cobra.Command{Use: "secret", Hidden: true, Run: func(cmd *cobra.Command, args []string) {}},
+		)
+		var stdout bytes.Buffer
+		p.Cmd.SetOut(&stdout)
+		return p, &stdout
+	}
+
+	// The completion command lists the visible commands
+	p, stdout := newTestPlugin(&PluginDescriptor{CompletionType: DynamicPluginCompletion, CompletionCommand: "nouns"})
+	p.Cmd.SetArgs([]string{"nouns", "clus"})
+	assert.NoError(p.Execute())
+	assert.Equal("cluster\nclusterclass\n", stdout.String())
+
+	stdout.Reset()
+	p.Cmd.SetArgs([]string{"nouns"})
+	assert.NoError(p.Execute())
+	assert.Contains(stdout.String(), "cluster\n")
+	assert.NotContains(stdout.String(), "secret")
+	assert.NotContains(stdout.String(), "nouns")
+
+	// The completion args are completed
+	p, stdout = newTestPlugin(&PluginDescriptor{CompletionType: StaticPluginCompletion, CompletionArgs: []string{"workload", "management"}})
+	assert.Equal([]string{"workload", "management"}, p.Cmd.ValidArgs)
+	p.Cmd.SetArgs([]string{cobra.ShellCompNoDescRequestCmd, "w"})
+	assert.NoError(p.Execute())
+	assert.Contains(stdout.String(), "workload\n")
+}
+
+func TestCompletionFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	t.Setenv(config.EnvConfigKey, filepath.Join(dir, "config.yaml"))
+	t.Setenv(config.EnvConfigNextGenKey, filepath.Join(dir, "config-ng.yaml"))
+	t.Setenv(config.EnvConfigMetadataKey, filepath.Join(dir, "config-metadata.yaml"))
+	t.Setenv(config.EnvConfigAuditFileKey, filepath.Join(dir, config.AuditFileName))
+
+	cmd := &cobra.Command{Use: "test"}
+
+	// Nothing is completed without config
+	completions, directive := CompleteContextNames(types.TargetUnknown)(cmd, nil, "")
+	assert.Empty(completions)
+	assert.Equal(cobra.ShellCompDirectiveNoFileComp, directive)
+	completions, _ = CompleteDiscoverySourceNames(cmd, nil, "")
+	assert.Empty(completions)
+
+	for _, ctx := range []*types.Context{
+		{Name: "test-mc", Target: types.TargetK8s, ClusterOpts: &types.ClusterServer{Endpoint: "test-endpoint", Path: "test-path", Context: "test-context"}},
+		{Name: "test-tmc", Target: types.TargetTMC, GlobalOpts: &types.GlobalServer{Endpoint: "test-endpoint"}},
+		{Name: "other-mc", Target: types.TargetK8s, ClusterOpts: &types.ClusterServer{Endpoint: "test-endpoint", Path: "test-path", Context: "test-context"}},
+	} {
+		assert.NoError(config.SetContext(ctx, false))
+	}
+	assert.NoError(config.SetCLIDiscoverySources([]types.PluginDiscovery{
+		{OCI: &types.OCIDiscovery{Name: "default", Image: "image"}},
+		{Local: &types.LocalDiscovery{Name: "dev", Path: "path"}},
+	}))
+
+	completions, _ = CompleteContextNames(types.TargetUnknown)(cmd, nil, "test")
+	assert.Equal([]string{"test-mc\tkubernetes", "test-tmc\tmission-control"}, completions)
+	completions, _ = CompleteContextNames(types.TargetK8s)(cmd, nil, "")
+	assert.Equal([]string{"other-mc\tkubernetes", "test-mc\tkubernetes"}, completions)
+
+	completions, _ = CompleteTargets(cmd, nil, "")
+	assert.Equal([]string{"kubernetes", "mission-control"}, completions)
+	completions, _ = CompleteTargets(cmd, nil, "k")
+	assert.Equal([]string{"kubernetes"}, completions)
+
+	completions, _ = CompleteDiscoverySourceNames(cmd, nil, "")
+	assert.Equal([]string{"default\toci", "dev\tlocal"}, completions)
+}
@@ -0,0 +1,297 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/component"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+const (
+	// DoctorCommandName is the hidden command verifying that the plugin is correctly installed and configured
+	DoctorCommandName = "doctor"
+	// DoctorCommandAlias is the alias of the doctor command
+	DoctorCommandAlias = "self-test"
+)
+
+// CertExpiryWarningPeriod is the period before the expiry of a CA certificate during which the certs check warns
+var CertExpiryWarningPeriod = 30 * 24 * time.Hour
+
+// DoctorStatus is the outcome of a doctor check
+type DoctorStatus string
+
+const (
+	DoctorStatusPass DoctorStatus = "pass"
+	// DoctorStatusWarn means the plugin works but may not behave as expected, it does not fail the doctor command
+	DoctorStatusWarn DoctorStatus = "warn"
+	DoctorStatusFail DoctorStatus = "fail"
+)
+
+// DoctorCheck is a check run by the doctor command of the plugin
+type DoctorCheck struct {
+	Name string
+	// Run returns the status of the check and a message explaining it
+	Run func() (DoctorStatus, string)
+}
+
+// DoctorResult is the result of a doctor check printed by the doctor command
+type DoctorResult struct {
+	Check   string       `json:"check" yaml:"check"`
+	Status  DoctorStatus `json:"status" yaml:"status"`
+	Message string       `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// validateDoctorChecks returns an error if a doctor check has no name or nothing to run
+func validateDoctorChecks(checks []DoctorCheck) error {
+	for i := range checks {
+		if checks[i].Name == "" {
+			return fmt.Errorf("doctor check %d: name cannot be empty", i)
+		}
+		if checks[i].Run == nil {
+			return fmt.Errorf("doctor check %q: run cannot be empty", checks[i].Name)
+		}
+	}
+	return nil
+}
+
+// newDoctorCmd creates the hidden command running the compatibility check and the doctor checks of the plugin.
+// It fails if any check fails.
+func newDoctorCmd(desc *PluginDescriptor) *cobra.Command {
+	outputType := outputTypeValue(component.TableOutputType)
+	cmd := &cobra.Command{
+		Use:          DoctorCommandName,
+		Aliases:      []string{DoctorCommandAlias},
+		Short:        "Verify the plugin is correctly installed and configured",
+		Hidden:       true,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := runDoctorChecks(append([]DoctorCheck{compatibilityDoctorCheck(desc)}, desc.DoctorChecks...))
+			writer := NewOutputWriter(cmd, "Check", "Status", "Message")
+			failed := 0
+			for _, result := range results {
+				writer.AddRow(result.Check, result.Status, result.Message)
+				if result.Status == DoctorStatusFail {
+					failed++
+				}
+			}
+			writer.Render()
+			if failed > 0 {
+				return errors.Errorf("%d of %d doctor checks failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().VarP(&outputType, OutputFlagName, OutputFlagShorthand, "output format: "+strings.Join(supportedOutputTypeNames(), "|"))
+	return cmd
+}
+
+// runDoctorChecks runs the checks in order, a check that panics fails
+func runDoctorChecks(checks []DoctorCheck) []DoctorResult {
+	results := make([]DoctorResult, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, runDoctorCheck(check))
+	}
+	return results
+}
+
+func runDoctorCheck(check DoctorCheck) (result DoctorResult) {
+	result.Check = check.Name
+	defer func() {
+		if r := recover(); r != nil {
+			result.Status = DoctorStatusFail
+			result.Message = fmt.Sprintf("check panicked: %v", r)
+		}
+	}()
+	result.Status, result.Message = check.Run()
+	return result
+}
+
+// compatibilityDoctorCheck checks the plugin is compatible with the CLI running it
+func compatibilityDoctorCheck(desc *PluginDescriptor) DoctorCheck {
+	return DoctorCheck{
+		Name: "cli compatibility",
+		Run: func() (DoctorStatus, string) {
+			err := checkCLICompatibility(desc)
+			if err == nil {
+				return DoctorStatusPass, "the CLI is compatible with the plugin"
+			}
+			if desc.Compatibility != nil && desc.Compatibility.Degraded {
+				return DoctorStatusWarn, fmt.Sprintf("%v, some features may not be available", err)
+			}
+			return DoctorStatusFail, err.Error()
+		},
+	}
+}
+
+// RequireContextTarget checks there is a current context for the target
+func RequireContextTarget(target types.Target) DoctorCheck {
+	return DoctorCheck{
+		Name: fmt.Sprintf("%s context", target),
+		Run: func() (DoctorStatus, string) {
+			ctx, err := config.GetCurrentContext(target)
+			if err != nil || ctx == nil {
+				return DoctorStatusFail, fmt.Sprintf("no current context for target %s, create or use a context first", target)
+			}
+			return DoctorStatusPass, fmt.Sprintf("the current context is '%s'", ctx.Name)
+		},
+	}
+}
+
+// RequireFeatures checks the features of the plugin are enabled
+func RequireFeatures(plugin string, keys ...string) DoctorCheck {
+	return DoctorCheck{
+		Name: "features",
+		Run: func() (DoctorStatus, string) {
+			var disabled []string
+			for _, key := range keys {
+				if enabled, err := config.IsFeatureEnabled(plugin, key); err != nil || !enabled {
+					disabled = append(disabled, key)
+				}
+			}
+			if len(disabled) > 0 {
+				return DoctorStatusFail, fmt.Sprintf("features %s of plugin %q are not enabled", strings.Join(disabled, ", "), plugin)
+			}
+			return DoctorStatusPass, "the required features are enabled"
+		},
+	}
+}
+
+// RequireEnvVars checks the environment variables are set, in the environment or in the env of the config
+func RequireEnvVars(keys ...string) DoctorCheck {
+	return DoctorCheck{
+		Name: "environment variables",
+		Run: func() (DoctorStatus, string) {
+			var missing []string
+			for _, key := range keys {
+				if _, ok := os.LookupEnv(key); ok {
+					continue
+				}
+				if _, err := config.GetEnv(key); err != nil {
+					missing = append(missing, key)
+				}
+			}
+			if len(missing) > 0 {
+				return DoctorStatusFail, fmt.Sprintf("environment variables %s are not set", strings.Join(missing, ", "))
+			}
+			return DoctorStatusPass, "the required environment variables are set"
+		},
+	}
+}
+
+// RequireValidCerts checks the CA certificates configured for the hosts are valid, or the CA certificates of all the
+// configured hosts if none are given. Certificates that expire within CertExpiryWarningPeriod, and hosts skipping
+// the verification of certificates, are reported as warnings.
+func RequireValidCerts(hosts ...string) DoctorCheck {
+	return DoctorCheck{
+		Name: "certificates",
+		Run: func() (DoctorStatus, string) {
+			certs, err := doctorCerts(hosts)
+			if err != nil {
+				return DoctorStatusFail, err.Error()
+			}
+			status := DoctorStatusPass
+			var messages []string
+			for _, cert := range certs {
+				certStatus, message := checkCert(cert)
+				if certStatus == DoctorStatusFail || (certStatus == DoctorStatusWarn && status == DoctorStatusPass) {
+					status = certStatus
+				}
+				if message != "" {
+					messages = append(messages, message)
+				}
+			}
+			if len(messages) == 0 {
+				return status, "the certificates are valid"
+			}
+			return status, strings.Join(messages, "; ")
+		},
+	}
+}
+
+// doctorCerts returns the certificate configurations of the hosts, or all of them if no hosts are given
+func doctorCerts(hosts []string) ([]*types.Cert, error) {
+	if len(hosts) == 0 {
+		// No certificate configuration is valid
+		certs, _ := config.GetCerts()
+		return certs, nil
+	}
+	certs := make([]*types.Cert, 0, len(hosts))
+	for _, host := range hosts {
+		cert, err := config.GetCert(host)
+		if err != nil {
+			return nil, errors.Errorf("no certificate configuration for host '%s'", host)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// checkCert checks the CA certificate data of the configuration can be parsed and has not expired
+func checkCert(cert *types.Cert) (DoctorStatus, string) {
+	if skip, _ := strconv.ParseBool(cert.SkipCertVerify); skip {
+		return DoctorStatusWarn, fmt.Sprintf("host '%s' skips certificate verification", cert.Host)
+	}
+	if insecure, _ := strconv.ParseBool(cert.Insecure); insecure {
+		return DoctorStatusWarn, fmt.Sprintf("host '%s' allows insecure connections", cert.Host)
+	}
+	if cert.CACertData == "" {
+		return DoctorStatusPass, ""
+	}
+	certs, err := parseCACertData(cert.CACertData)
+	if err != nil {
+		return DoctorStatusFail, fmt.Sprintf("invalid CA certificate for host '%s': %v", cert.Host, err)
+	}
+	now := time.Now()
+	for _, c := range certs {
+		if now.After(c.NotAfter) {
+			return DoctorStatusFail, fmt.Sprintf("CA certificate for host '%s' expired on %s", cert.Host, c.NotAfter.Format(time.RFC3339))
+		}
+		if now.Add(CertExpiryWarningPeriod).After(c.NotAfter) {
+			return DoctorStatusWarn, fmt.Sprintf("CA certificate for host '%s' expires on %s", cert.Host, c.NotAfter.Format(time.RFC3339))
+		}
+	}
+	return DoctorStatusPass, ""
+}
+
+// parseCACertData parses the PEM certificates of the CA certificate data, which is base64 encoded PEM or PEM
+func parseCACertData(data string) ([]*x509.Certificate, error) {
+	pemData := []byte(data)
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data)); err == nil {
+		pemData = decoded
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return certs, nil
+}
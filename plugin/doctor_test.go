@@ -0,0 +1,174 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func setupDoctorTestConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.EnvConfigKey, filepath.Join(dir, "config.yaml"))
+	t.Setenv(config.EnvConfigNextGenKey, filepath.Join(dir, "config-ng.yaml"))
+	t.Setenv(config.EnvConfigMetadataKey, filepath.Join(dir, "config-metadata.yaml"))
+	t.Setenv(config.EnvConfigAuditFileKey, filepath.Join(dir, config.AuditFileName))
+	t.Setenv(EnvCLIPluginAPIVersionKey, "")
+	t.Setenv(EnvCLIVersionKey, "")
+}
+
+func newTestCACertData(t *testing.T, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestDoctorChecks(t *testing.T) {
+	assert := assert.New(t)
+	setupDoctorTestConfig(t)
+
+	runCheck := func(check DoctorCheck) DoctorResult {
+		return runDoctorCheck(check)
+	}
+
+	// Context target
+	result := runCheck(RequireContextTarget(types.TargetK8s))
+	assert.Equal(DoctorResult{Check: "kubernetes context", Status: DoctorStatusFail, Message: "no current context for target kubernetes, create or use a context first"}, result)
+	assert.NoError(config.SetContext(&types.Context{Name: "test-mc", Target: types.TargetK8s, ClusterOpts: &types.ClusterServer{Endpoint: "test-endpoint", Path: "test-path", Context: "test-context"}}, true))
+	result = runCheck(RequireContextTarget(types.TargetK8s))
+	assert.Equal(DoctorStatusPass, result.Status)
+	assert.Equal("the current context is 'test-mc'", result.Message)
+
+	// Features
+	assert.NoError(config.SetFeature("test-plugin", "alpha", "true"))
+	assert.NoError(config.SetFeature("test-plugin", "beta", "false"))
+	result = runCheck(RequireFeatures("test-plugin", "alpha", "beta", "gamma"))
+	assert.Equal(DoctorStatusFail, result.Status)
+	assert.Equal(`features beta, gamma of plugin "test-plugin" are not enabled`, result.Message)
+	assert.Equal(DoctorStatusPass, runCheck(RequireFeatures("test-plugin", "alpha")).Status)
+
+	// Environment variables are set in the environment or in the config
+	t.Setenv("TEST_DOCTOR_ENV", "value")
+	assert.NoError(config.SetEnv("TEST_DOCTOR_CONFIG_ENV", "value"))
+	result = runCheck(RequireEnvVars("TEST_DOCTOR_ENV", "TEST_DOCTOR_CONFIG_ENV", "TEST_DOCTOR_MISSING_ENV"))
+	assert.Equal(DoctorStatusFail, result.Status)
+	assert.Equal("environment variables TEST_DOCTOR_MISSING_ENV are not set", result.Message)
+	assert.Equal(DoctorStatusPass, runCheck(RequireEnvVars("TEST_DOCTOR_ENV", "TEST_DOCTOR_CONFIG_ENV")).Status)
+
+	// Certificates
+	assert.Equal(DoctorResult{Check: "certificates", Status: DoctorStatusPass, Message: "the certificates are valid"}, runCheck(RequireValidCerts()))
+	assert.NoError(config.SetCert(&types.Cert{Host: "valid.example.com", CACertData: newTestCACertData(t, time.Now().Add(365*24*time.Hour))}))
+	assert.Equal(DoctorStatusPass, runCheck(RequireValidCerts()).Status)
+	assert.NoError(config.SetCert(&types.Cert{Host: "expiring.example.com", CACertData: newTestCACertData(t, time.Now().Add(24*time.Hour))}))
+	assert.NoError(config.SetCert(&types.Cert{Host: "insecure.example.com", SkipCertVerify: "true"}))
+	result = runCheck(RequireValidCerts())
+	assert.Equal(DoctorStatusWarn, result.Status)
+	assert.Contains(result.Message, "CA certificate for host 'expiring.example.com' expires on")
+	assert.Contains(result.Message, "host 'insecure.example.com' skips certificate verification")
+	assert.NoError(config.SetCert(&types.Cert{Host: "expired.example.com", CACertData: newTestCACertData(t, time.Now().Add(-time.Hour))}))
+	assert.NoError(config.SetCert(&types.Cert{Host: "invalid.example.com", CACertData: "invalid"}))
+	result = runCheck(RequireValidCerts())
+	assert.Equal(DoctorStatusFail, result.Status)
+	assert.Contains(result.Message, "CA certificate for host 'expired.example.com' expired on")
+	assert.Contains(result.Message, "invalid CA certificate for host 'invalid.example.com': no PEM certificate found")
+	assert.Equal(DoctorStatusPass, runCheck(RequireValidCerts("valid.example.com")).Status)
+	result = runCheck(RequireValidCerts("unknown.example.com"))
+	assert.Equal(DoctorResult{Check: "certificates", Status: DoctorStatusFail, Message: "no certificate configuration for host 'unknown.example.com'"}, result)
+
+	// Checks that panic fail
+	result = runCheck(DoctorCheck{Name: "panic", Run: func() (DoctorStatus, string) { panic("boom") }})
+	assert.Equal(DoctorResult{Check: "panic", Status: DoctorStatusFail, Message: "check panicked: boom"}, result)
+}
+
+func TestDoctorCmd(t *testing.T) {
+	assert := assert.New(t)
+	setupDoctorTestConfig(t)
+
+	newTestPlugin := func(checks ...DoctorCheck) (*Plugin, *bytes.Buffer) {
+		p, err := NewPlugin(&PluginDescriptor{
+			Name:                    "test-plugin",
+			Target:                  types.TargetGlobal,
+			Description:             "Description of the plugin",
+			Version:                 "v1.2.3",
+			Group:                   "TestGroup",
+			SkipDefaultFeatureFlags: true,
+			DoctorChecks:            checks,
+		})
+		assert.NoError(err)
+		var stdout bytes.Buffer
+		p.Cmd.SetOut(&stdout)
+		p.Cmd.SetErr(&bytes.Buffer{})
+		return p, &stdout
+	}
+	warnCheck := DoctorCheck{Name: "warn", Run: func() (DoctorStatus, string) { return DoctorStatusWarn, "something is odd" }}
+	failCheck := DoctorCheck{Name: "fail", Run: func() (DoctorStatus, string) { return DoctorStatusFail, "something is wrong" }}
+
+	// Warnings do not fail the command
+	p, stdout := newTestPlugin(warnCheck)
+	p.Cmd.SetArgs([]string{DoctorCommandName})
+	assert.NoError(p.Execute())
+	assert.Contains(stdout.String(), "cli compatibility")
+	assert.Contains(stdout.String(), "something is odd")
+
+	p, stdout = newTestPlugin(warnCheck, failCheck)
+	p.Cmd.SetArgs([]string{DoctorCommandAlias, "-o", "json"})
+	err := p.Execute()
+	assert.ErrorContains(err, "1 of 3 doctor checks failed")
+	var results []DoctorResult
+	assert.NoError(json.Unmarshal(stdout.Bytes(), &results))
+	assert.Equal([]DoctorResult{
+		{Check: "cli compatibility", Status: DoctorStatusPass, Message: "the CLI is compatible with the plugin"},
+		{Check: "warn", Status: DoctorStatusWarn, Message: "something is odd"},
+		{Check: "fail", Status: DoctorStatusFail, Message: "something is wrong"},
+	}, results)
+
+	// The command reports the incompatibility of the CLI instead of failing
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"test-plugin", DoctorCommandName, "-o", "yaml"}
+	t.Setenv(EnvCLIPluginAPIVersionKey, "v2.0")
+	p, stdout = newTestPlugin()
+	p.Cmd.SetArgs(os.Args[1:])
+	assert.ErrorContains(p.Execute(), "1 of 1 doctor checks failed")
+	assert.Contains(stdout.String(), "status: fail")
+	assert.Contains(stdout.String(), "CLI plugin API version v2.0 is not in the supported range")
+
+	// Doctor checks must have a name and something to run
+	_, err = NewPlugin(&PluginDescriptor{
+		Name:         "test-plugin",
+		Target:       types.TargetGlobal,
+		Description:  "Description of the plugin",
+		Version:      "v1.2.3",
+		Group:        "TestGroup",
+		DoctorChecks: []DoctorCheck{{Name: "empty"}},
+	})
+	assert.ErrorContains(err, `plugin "test-plugin": doctor check "empty": run cannot be empty`)
+}
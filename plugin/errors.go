@@ -0,0 +1,213 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/component"
+)
+
+// ErrorCategory tells the CLI what kind of failure a plugin error is
+type ErrorCategory string
+
+const (
+	// ErrorCategoryUsage is an error in the command line e.g. an unknown flag or a missing argument
+	ErrorCategoryUsage ErrorCategory = "usage"
+	// ErrorCategoryAuth is an authentication or authorization failure
+	ErrorCategoryAuth ErrorCategory = "auth"
+	// ErrorCategoryNotFound is a resource that does not exist
+	ErrorCategoryNotFound ErrorCategory = "not-found"
+	// ErrorCategoryConflict is a resource that already exists or was changed concurrently
+	ErrorCategoryConflict ErrorCategory = "conflict"
+	// ErrorCategoryTransient is a failure that may succeed if retried e.g. a timeout
+	ErrorCategoryTransient ErrorCategory = "transient"
+	// ErrorCategoryInternal is any other failure, and the category of errors that are not plugin errors
+	ErrorCategoryInternal ErrorCategory = "internal"
+)
+
+const (
+	// ExitCodeSuccess is the exit code of a plugin command that succeeded
+	ExitCodeSuccess = 0
+	// ExitCodeError is the exit code of a plugin command that failed with an internal error
+	ExitCodeError = 1
+	// ExitCodeUsage is the exit code of a plugin command that failed with a usage error
+	ExitCodeUsage = 2
+	// ExitCodeAuth is the exit code of a plugin command that failed with an auth error
+	ExitCodeAuth = 3
+	// ExitCodeNotFound is the exit code of a plugin command that failed with a not-found error
+	ExitCodeNotFound = 4
+	// ExitCodeConflict is the exit code of a plugin command that failed with a conflict error
+	ExitCodeConflict = 5
+	// ExitCodeTransient is the exit code of a plugin command that failed with a transient error
+	ExitCodeTransient = 6
+)
+
+// usageErrorArgsAnnotation marks the commands whose argument validation returns usage errors
+const usageErrorArgsAnnotation = "tanzu-cli.usage-error-args"
+
+// errorCategoryExitCodes maps the error categories to the exit codes of the plugin
+var errorCategoryExitCodes = map[ErrorCategory]int{
+	ErrorCategoryUsage:     ExitCodeUsage,
+	ErrorCategoryAuth:      ExitCodeAuth,
+	ErrorCategoryNotFound:  ExitCodeNotFound,
+	ErrorCategoryConflict:  ExitCodeConflict,
+	ErrorCategoryTransient: ExitCodeTransient,
+	ErrorCategoryInternal:  ExitCodeError,
+}
+
+// Error is an error returned by a plugin command, which tells the CLI the category of the failure and the user
+// what to do about it
+type Error struct {
+	Category ErrorCategory
+	// Hint tells the user how to fix the error e.g. run 'tanzu login' first
+	Hint string
+	Err  error
+}
+
+type ErrorOpts func(e *Error)
+
+// WithErrorHint sets the hint telling the user how to fix the error
+func WithErrorHint(hint string) ErrorOpts {
+	return func(e *Error) {
+		e.Hint = hint
+	}
+}
+
+// NewError returns a plugin error of the category for the error
+func NewError(category ErrorCategory, err error, opts ...ErrorOpts) *Error {
+	e := &Error{Category: category, Err: err}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return string(e.Category)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorOutput is the structured rendering of an error, printed when the command output is JSON or YAML
+type ErrorOutput struct {
+	Category ErrorCategory `json:"category" yaml:"category"`
+	Message  string        `json:"message" yaml:"message"`
+	Hint     string        `json:"hint,omitempty" yaml:"hint,omitempty"`
+	ExitCode int           `json:"exitCode" yaml:"exitCode"`
+}
+
+// GetErrorCategory returns the category of the plugin error wrapped by the error, internal for other errors
+func GetErrorCategory(err error) ErrorCategory {
+	var pluginErr *Error
+	if errors.As(err, &pluginErr) && pluginErr.Category != "" {
+		return pluginErr.Category
+	}
+	return ErrorCategoryInternal
+}
+
+// ExitCode returns the exit code of the plugin for the error returned by Execute or ExecuteContext.
+// Plugins interrupted by a signal exit with 128 plus the signal number e.g. 130 for SIGINT, and other
+// failures exit with the code of the category of the error.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var interruptedErr *InterruptedError
+	if errors.As(err, &interruptedErr) {
+		return signalExitCode(interruptedErr.Signal)
+	}
+	if code, ok := errorCategoryExitCodes[GetErrorCategory(err)]; ok {
+		return code
+	}
+	return ExitCodeError
+}
+
+// newErrorOutput returns the structured rendering of the error
+func newErrorOutput(err error) *ErrorOutput {
+	output := &ErrorOutput{Category: GetErrorCategory(err), Message: err.Error(), ExitCode: ExitCode(err)}
+	var pluginErr *Error
+	if errors.As(err, &pluginErr) {
+		output.Hint = pluginErr.Hint
+	}
+	return output
+}
+
+// printError prints the error of the command, as JSON or YAML if the output flag of the command requests it
+func printError(cmd *cobra.Command, err error) {
+	output := newErrorOutput(err)
+	switch commandOutputType(cmd) {
+	case component.JSONOutputType:
+		writeErrorOutput(cmd.ErrOrStderr(), output, json.Marshal)
+	case component.YAMLOutputType:
+		writeErrorOutput(cmd.ErrOrStderr(), output, yaml.Marshal)
+	default:
+		cmd.PrintErrln("Error:", output.Message)
+		if output.Hint != "" {
+			cmd.PrintErrln("Hint:", output.Hint)
+		}
+	}
+}
+
+func writeErrorOutput(w io.Writer, output *ErrorOutput, marshal func(interface{}) ([]byte, error)) {
+	data, err := marshal(struct {
+		Error *ErrorOutput `json:"error" yaml:"error"`
+	}{output})
+	if err != nil {
+		fmt.Fprintln(w, "Error:", output.Message)
+		return
+	}
+	fmt.Fprintln(w, strings.TrimSuffix(string(data), "\n"))
+}
+
+// commandOutputType returns the value of the output flag of the command, if any
+func commandOutputType(cmd *cobra.Command) component.OutputType {
+	if cmd == nil {
+		return ""
+	}
+	flag := cmd.Flags().Lookup(OutputFlagName)
+	if flag == nil {
+		flag = cmd.InheritedFlags().Lookup(OutputFlagName)
+	}
+	if flag == nil {
+		return ""
+	}
+	return component.OutputType(strings.ToLower(flag.Value.String()))
+}
+
+// usageError returns a usage error for the errors of cobra parsing the command line, which are not plugin errors
+func usageError(err error) error {
+	var pluginErr *Error
+	if err == nil || errors.As(err, &pluginErr) {
+		return err
+	}
+	return NewError(ErrorCategoryUsage, err, WithErrorHint("run the command with --help for its usage"))
+}
+
+// isUnknownCommandError returns true if the error is returned by cobra for an unknown command, given the command
+// returned along with the error. Cobra only reports unknown commands of a root command with subcommands and without
+// argument validation, while finding the command to run, so before the flags of the root command are parsed.
+// A root command that is not runnable fails otherwise only on invalid flags, which are usage errors already.
+func isUnknownCommandError(cmd *cobra.Command, err error) bool {
+	if err == nil || cmd == nil || cmd.HasParent() || !cmd.HasSubCommands() || cmd.Args != nil {
+		return false
+	}
+	var pluginErr *Error
+	if errors.As(err, &pluginErr) {
+		return false
+	}
+	return !cmd.Runnable() || !cmd.Flags().Parsed()
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestExitCodeOfErrorCategories(t *testing.T) {
+	assert := assert.New(t)
+
+	for category, code := range map[ErrorCategory]int{
+		ErrorCategoryUsage:     ExitCodeUsage,
+		ErrorCategoryAuth:      ExitCodeAuth,
+		ErrorCategoryNotFound:  ExitCodeNotFound,
+		ErrorCategoryConflict:  ExitCodeConflict,
+		ErrorCategoryTransient: ExitCodeTransient,
+		ErrorCategoryInternal:  ExitCodeError,
+		ErrorCategory("other"): ExitCodeError,
+	} {
+		err := errors.Wrap(NewError(category, errors.New("failed")), "wrapped")
+		assert.Equal(category, GetErrorCategory(err))
+		assert.Equal(code, ExitCode(err), category)
+	}
+	assert.Equal(ErrorCategoryInternal, GetErrorCategory(errors.New("failed")))
+
+	err := NewError(ErrorCategoryAuth, errors.New("token expired"), WithErrorHint("run 'tanzu login'"))
+	assert.EqualError(err, "token expired")
+	assert.Equal("run 'tanzu login'", err.Hint)
+	assert.EqualError(errors.Cause(err), "token expired")
+}
+
+func TestExecuteErrors(t *testing.T) {
+	newTestPlugin := func(runErr error) (*Plugin, *bytes.Buffer) {
+		p, err := NewPlugin(&PluginDescriptor{
+			Name:                    "test-plugin",
+			Target:                  types.TargetGlobal,
+			Description:             "Description of the plugin",
+			Version:                 "v1.2.3",
+			Group:                   "TestGroup",
+			SkipDefaultFeatureFlags: true,
+		})
+		assert.NoError(t, err)
+		getCmd := &cobra.Command{
+			Use:          "get NAME",
+			Args:         cobra.ExactArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runErr
+			},
+		}
+		getCmd.Flags().StringP("output", "o", "", "output format: json|yaml")
+		p.AddCommands(getCmd)
+		var stderr bytes.Buffer
+		p.Cmd.SetOut(&bytes.Buffer{})
+		p.Cmd.SetErr(&stderr)
+		return p, &stderr
+	}
+
+	notFoundErr := NewError(ErrorCategoryNotFound, errors.New(`cluster "test" not found`), WithErrorHint("list the clusters with 'tanzu cluster list'"))
+	tests := []struct {
+		name     string
+		args     []string
+		runErr   error
+		category ErrorCategory
+		exitCode int
+		stderr   string
+	}{
+		{
+			name:     "succeeded",
+			args:     []string{"get", "test"},
+			exitCode: ExitCodeSuccess,
+		},
+		{
+			name:     "plugin error",
+			args:     []string{"get", "test"},
+			runErr:   notFoundErr,
+			category: ErrorCategoryNotFound,
+			exitCode: ExitCodeNotFound,
+			stderr:   "Error: cluster \"test\" not found\nHint: list the clusters with 'tanzu cluster list'\n",
+		},
+		{
+			name:     "other error",
+			args:     []string{"get", "test"},
+			runErr:   errors.New("failed"),
+			category: ErrorCategoryInternal,
+			exitCode: ExitCodeError,
+			stderr:   "Error: failed\n",
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"get", "test", "--unknown"},
+			category: ErrorCategoryUsage,
+			exitCode: ExitCodeUsage,
+			stderr:   "Error: unknown flag: --unknown\nHint: run the command with --help for its usage\n",
+		},
+		{
+			name:     "invalid arguments",
+			args:     []string{"get"},
+			category: ErrorCategoryUsage,
+			exitCode: ExitCodeUsage,
+			stderr:   "Error: accepts 1 arg(s), received 0\nHint: run the command with --help for its usage\n",
+		},
+		{
+			name:     "error message of an unknown command",
+			args:     []string{"get", "test"},
+			runErr:   errors.New("unknown command \"test\""),
+			category: ErrorCategoryInternal,
+			exitCode: ExitCodeError,
+			stderr:   "Error: unknown command \"test\"\n",
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"unknown"},
+			category: ErrorCategoryUsage,
+			exitCode: ExitCodeUsage,
+			stderr:   "Error: unknown command \"unknown\" for \"test-plugin\"\nHint: run the command with --help for its usage\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, stderr := newTestPlugin(tc.runErr)
+			p.Cmd.SetArgs(tc.args)
+			err := p.Execute()
+			assert.Equal(t, tc.exitCode, ExitCode(err))
+			if tc.category != "" {
+				assert.Equal(t, tc.category, GetErrorCategory(err))
+			}
+			assert.Contains(t, stderr.String(), tc.stderr)
+		})
+	}
+
+	// Unknown commands of a runnable root command are usage errors too
+	p, _ := newTestPlugin(nil)
+	p.Cmd.RunE = func(cmd *cobra.Command, args []string) error { return nil }
+	p.Cmd.SetArgs([]string{"unknown"})
+	assert.Equal(t, ErrorCategoryUsage, GetErrorCategory(p.Execute()))
+
+	// Errors are rendered as JSON or YAML when requested by the output flag of the command
+	p, stderr := newTestPlugin(notFoundErr)
+	p.Cmd.SetArgs([]string{"get", "test", "-o", "json"})
+	assert.Error(t, p.Execute())
+	var output struct {
+		Error ErrorOutput `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(stderr.Bytes(), &output))
+	assert.Equal(t, ErrorOutput{
+		Category: ErrorCategoryNotFound,
+		Message:  `cluster "test" not found`,
+		Hint:     "list the clusters with 'tanzu cluster list'",
+		ExitCode: ExitCodeNotFound,
+	}, output.Error)
+
+	p, stderr = newTestPlugin(notFoundErr)
+	p.Cmd.SetArgs([]string{"get", "test", "--output", "yaml"})
+	assert.Error(t, p.Execute())
+	assert.Equal(t, "error:\n    category: not-found\n    message: cluster \"test\" not found\n    hint: list the clusters with 'tanzu cluster list'\n    exitCode: 4\n", stderr.String())
+
+	// Errors are not printed when silenced
+	p, stderr = newTestPlugin(notFoundErr)
+	p.Cmd.SilenceErrors = true
+	p.Cmd.SetArgs([]string{"get", "test"})
+	assert.Error(t, p.Execute())
+	assert.Empty(t, stderr.String())
+	assert.True(t, p.Cmd.SilenceErrors)
+}
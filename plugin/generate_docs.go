@@ -5,55 +5,373 @@
 package plugin
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
 )
 
 // DefaultDocsDir is the base docs directory
 const DefaultDocsDir = "docs/cli/commands"
 const ErrorDocsOutputFolderNotExists = "error reading docs output directory '%v', make sure directory exists or provide docs output directory as input value to '--docs-dir' flag"
 
-var (
-	docsDir string
+const (
+	// DocsFormatMarkdown generates a Markdown page per command
+	DocsFormatMarkdown = "markdown"
+	// DocsFormatMan generates a man page per command
+	DocsFormatMan = "man"
+	// DocsFormatReST generates a reStructuredText page per command
+	DocsFormatReST = "rst"
+	// DocsFormatYAML generates a YAML command reference, which is the plugin manifest
+	DocsFormatYAML = "yaml"
+	// DocsFormatJSON generates a JSON command reference, which is the plugin manifest
+	DocsFormatJSON = "json"
 )
 
-func init() {
-	genDocsCmd.Flags().StringVarP(&docsDir, "docs-dir", "d", DefaultDocsDir, "destination for docs output")
+// docsFormats are the formats supported by the generate-docs command
+var docsFormats = []string{DocsFormatMarkdown, DocsFormatMan, DocsFormatReST, DocsFormatYAML, DocsFormatJSON}
+
+type genDocsOptions struct {
+	docsDir     string
+	format      string
+	singlePage  bool
+	frontMatter string
+	check       bool
+	annotate    bool
+}
+
+// docsPageData is the data of the front matter template of a docs page
+type docsPageData struct {
+	// Title is the path of the command e.g. tanzu cluster list
+	Title string
+	// Name is the name of the command e.g. list
+	Name string
+	// Filename is the name of the page without extension e.g. tanzu_cluster_list
+	Filename string
+	// Deprecated is the deprecation message of the command, if any
+	Deprecated string
+	// Plugin is the descriptor of the plugin
+	Plugin *PluginDescriptor
+}
+
+func newGenDocsCmd(desc *PluginDescriptor) *cobra.Command {
+	opts := &genDocsOptions{}
+	cmd := &cobra.Command{
+		Use:    "generate-docs",
+		Short:  "Generate Cobra CLI docs for all subcommands",
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.docsDir == "" {
+				opts.docsDir = DefaultDocsDir
+			}
+			if dir, err := os.Stat(opts.docsDir); err != nil || !dir.IsDir() {
+				if err == nil {
+					err = errors.Errorf("'%v' is not a directory", opts.docsDir)
+				}
+				return errors.Wrap(err, fmt.Sprintf(ErrorDocsOutputFolderNotExists, opts.docsDir))
+			}
+
+			root := cmd.Parent()
+			descriptor := desc
+			if descriptor == nil {
+				descriptor = &PluginDescriptor{Name: root.Name(), Description: root.Short}
+			}
+			files, err := genDocs(root, descriptor, opts)
+			if err != nil {
+				return fmt.Errorf("error generating docs %q", err)
+			}
+			if opts.check {
+				return checkDocs(opts.docsDir, files, docsFilePrefixes(root, opts.format), docsFileExt(opts.format))
+			}
+			return writeDocs(opts.docsDir, files)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.docsDir, "docs-dir", "d", DefaultDocsDir, "destination for docs output")
+	cmd.Flags().StringVar(&opts.format, "format", DocsFormatMarkdown, "format of the docs: "+strings.Join(docsFormats, "|"))
+	cmd.Flags().BoolVar(&opts.singlePage, "single-page", false, "generate a single Markdown page for all the commands")
+	cmd.Flags().StringVar(&opts.frontMatter, "front-matter", "", "path of a Go template prepended to each Markdown or reStructuredText page, e.g. the front matter of a doc site")
+	cmd.Flags().BoolVar(&opts.check, "check", false, "fail if the docs in the docs directory are not up to date instead of writing them")
+	cmd.Flags().BoolVar(&opts.annotate, "annotate", false, "add the plugin details, the deprecation notices and the pages of deprecated commands, and leave out the generation date")
+	return cmd
 }
 
-var genDocsCmd = &cobra.Command{
-	Use:    "generate-docs",
-	Short:  "Generate Cobra CLI docs for all subcommands",
-	Hidden: true,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if docsDir == "" {
-			docsDir = DefaultDocsDir
+// genDocs returns the content of the docs files of the plugin by file name
+func genDocs(root *cobra.Command, desc *PluginDescriptor, opts *genDocsOptions) (map[string][]byte, error) {
+	if opts.singlePage && opts.format != DocsFormatMarkdown {
+		return nil, errors.Errorf("single page docs are only supported for the %s format", DocsFormatMarkdown)
+	}
+	switch opts.format {
+	case DocsFormatJSON, DocsFormatYAML:
+		return genDocsReference(root, desc, opts.format)
+	case DocsFormatMarkdown, DocsFormatMan, DocsFormatReST:
+	default:
+		return nil, errors.Errorf("docs format %q is not supported, supported formats are %s", opts.format, strings.Join(docsFormats, "|"))
+	}
+
+	var frontMatter *template.Template
+	if opts.frontMatter != "" {
+		if opts.format == DocsFormatMan {
+			return nil, errors.Errorf("front matter is not supported for the %s format", DocsFormatMan)
+		}
+		var err error
+		if frontMatter, err = template.ParseFiles(opts.frontMatter); err != nil {
+			return nil, errors.Wrap(err, "failed to parse the front matter template")
+		}
+	}
+
+	// Necessary to generate correct output
+	tanzuCmd := &cobra.Command{Use: "tanzu"}
+	tanzuCmd.AddCommand(root)
+	defer tanzuCmd.RemoveCommand(root)
+
+	files := make(map[string][]byte)
+	var singlePage bytes.Buffer
+	if opts.singlePage && frontMatter != nil {
+		if err := frontMatter.Execute(&singlePage, newDocsPageData(root, desc)); err != nil {
+			return nil, errors.Wrap(err, "failed to render the front matter")
 		}
-		if dir, err := os.Stat(docsDir); err != nil || !dir.IsDir() {
-			return errors.Wrap(err, fmt.Sprintf(ErrorDocsOutputFolderNotExists, docsDir))
+	}
+	err := walkDocsCommands(root, opts.annotate, func(cmd *cobra.Command) error {
+		if opts.annotate {
+			restore := addDocsNotes(cmd, root, desc)
+			defer restore()
 		}
-		identity := func(s string) string {
-			if !strings.HasPrefix(s, "tanzu") {
-				return fmt.Sprintf("tanzu_%s", s)
+
+		var page bytes.Buffer
+		if frontMatter != nil && !opts.singlePage {
+			if err := frontMatter.Execute(&page, newDocsPageData(cmd, desc)); err != nil {
+				return errors.Wrap(err, "failed to render the front matter")
+			}
+		}
+		switch {
+		case opts.singlePage:
+			return doc.GenMarkdownCustom(cmd, &singlePage, docsAnchorLink)
+		case opts.format == DocsFormatMarkdown:
+			if err := doc.GenMarkdownCustom(cmd, &page, docsLink); err != nil {
+				return err
+			}
+			files[docsBaseName(cmd)+docsFileExt(opts.format)] = page.Bytes()
+		case opts.format == DocsFormatReST:
+			if err := doc.GenReST(cmd, &page); err != nil {
+				return err
+			}
+			files[docsBaseName(cmd)+docsFileExt(opts.format)] = page.Bytes()
+		case opts.format == DocsFormatMan:
+			header := &doc.GenManHeader{
+				Section: "1",
+				Manual:  "Tanzu CLI",
+				Source:  strings.TrimSpace(fmt.Sprintf("Tanzu CLI %s %s", desc.Name, desc.Version)),
 			}
-			return s
+			if err := doc.GenMan(cmd, header, &page); err != nil {
+				return err
+			}
+			files[strings.ReplaceAll(cmd.CommandPath(), " ", "-")+docsFileExt(opts.format)] = page.Bytes()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.singlePage {
+		files[docsBaseName(root)+docsFileExt(opts.format)] = singlePage.Bytes()
+	}
+	return files, nil
+}
+
+// genDocsReference returns the command reference of the plugin, which is its manifest
+func genDocsReference(root *cobra.Command, desc *PluginDescriptor, format string) (map[string][]byte, error) {
+	manifest := newPluginManifest(desc, root)
+	var data []byte
+	var err error
+	if format == DocsFormatJSON {
+		data, err = json.MarshalIndent(manifest, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(manifest)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the command reference")
+	}
+	return map[string][]byte{docsBaseName(root) + docsFileExt(format): data}, nil
+}
+
+// walkDocsCommands calls fn for the command and its documented sub-commands sorted by name, which are the
+// available commands as documented by cobra, and the deprecated commands if includeDeprecated is set
+func walkDocsCommands(cmd *cobra.Command, includeDeprecated bool, fn func(cmd *cobra.Command) error) error {
+	if err := fn(cmd); err != nil {
+		return err
+	}
+	children := cmd.Commands()
+	sort.Slice(children, func(i, j int) bool { return children[i].Name() < children[j].Name() })
+	for _, child := range children {
+		deprecated := includeDeprecated && child.Deprecated != "" && !child.Hidden
+		if (!child.IsAvailableCommand() && !deprecated) || child.IsAdditionalHelpTopicCommand() {
+			continue
 		}
-		emptyStr := func(s string) string { return "" }
+		if err := walkDocsCommands(child, includeDeprecated, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		tanzuCmd := cobra.Command{
-			Use: "tanzu",
+// addDocsNotes adds the deprecation notices of the command and its flags, and the plugin details to the page
+// of the plugin command, to the long description of the command. It returns the function restoring the command.
+func addDocsNotes(cmd, root *cobra.Command, desc *PluginDescriptor) func() {
+	long, disableAutoGenTag := cmd.Long, cmd.DisableAutoGenTag
+	var notes []string
+	if cmd.Deprecated != "" {
+		notes = append(notes, "Deprecated: "+cmd.Deprecated)
+	}
+	if long != "" {
+		notes = append(notes, long)
+	}
+	if cmd == root {
+		var details []string
+		for _, detail := range [][2]string{
+			{"Group", string(desc.Group)},
+			{"Target", string(desc.Target)},
+			{"Version", desc.Version},
+			{"Documentation", desc.DocURL},
+		} {
+			if detail[1] != "" {
+				details = append(details, fmt.Sprintf("* %s: %s", detail[0], detail[1]))
+			}
+		}
+		if len(details) > 0 {
+			notes = append(notes, "Plugin details:\n\n"+strings.Join(details, "\n"))
 		}
-		// Necessary to generate correct output
-		tanzuCmd.AddCommand(cmd.Parent())
-		if err := doc.GenMarkdownTreeCustom(cmd.Parent(), docsDir, emptyStr, identity); err != nil {
-			return fmt.Errorf("error generating docs %q", err)
+	}
+	var deprecatedFlags []string
+	cmd.NonInheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Deprecated != "" {
+			deprecatedFlags = append(deprecatedFlags, fmt.Sprintf("* --%s: %s", flag.Name, flag.Deprecated))
+		}
+	})
+	if len(deprecatedFlags) > 0 {
+		notes = append(notes, "Deprecated flags:\n\n"+strings.Join(deprecatedFlags, "\n"))
+	}
+
+	cmd.Long = strings.Join(notes, "\n\n")
+	// Generated docs have no date so that they only change with the commands
+	cmd.DisableAutoGenTag = true
+	return func() {
+		cmd.Long, cmd.DisableAutoGenTag = long, disableAutoGenTag
+	}
+}
+
+func newDocsPageData(cmd *cobra.Command, desc *PluginDescriptor) *docsPageData {
+	return &docsPageData{
+		Title:      cmd.CommandPath(),
+		Name:       cmd.Name(),
+		Filename:   docsBaseName(cmd),
+		Deprecated: cmd.Deprecated,
+		Plugin:     desc,
+	}
+}
+
+// docsBaseName returns the name of the page of the command without extension e.g. tanzu_cluster_list
+func docsBaseName(cmd *cobra.Command) string {
+	return docsLink(strings.ReplaceAll(cmd.CommandPath(), " ", "_"))
+}
+
+// docsLink returns the link to the page of a command, which is prefixed with tanzu
+func docsLink(s string) string {
+	if !strings.HasPrefix(s, "tanzu") {
+		return fmt.Sprintf("tanzu_%s", s)
+	}
+	return s
+}
+
+// docsAnchorLink returns the link to the section of a command in the single page docs
+func docsAnchorLink(s string) string {
+	return "#" + strings.ReplaceAll(strings.TrimSuffix(docsLink(s), ".md"), "_", "-")
+}
+
+// docsFileExt returns the extension of the docs files of the format
+func docsFileExt(format string) string {
+	switch format {
+	case DocsFormatMarkdown:
+		return ".md"
+	case DocsFormatMan:
+		return ".1"
+	default:
+		return "." + format
+	}
+}
+
+// docsFilePrefixes returns the prefixes of the docs files of the plugin, used to find the files of commands
+// that were removed
+func docsFilePrefixes(root *cobra.Command, format string) []string {
+	switch format {
+	case DocsFormatMan:
+		return []string{"tanzu-" + root.Name() + ".", "tanzu-" + root.Name() + "-"}
+	case DocsFormatJSON, DocsFormatYAML:
+		return []string{"tanzu_" + root.Name() + "."}
+	default:
+		return []string{"tanzu_" + root.Name() + ".", "tanzu_" + root.Name() + "_"}
+	}
+}
+
+func writeDocs(dir string, files map[string][]byte) error {
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			return errors.Wrap(err, "failed to write the docs")
 		}
+	}
+	return nil
+}
 
+// checkDocs returns an error listing the docs files in the directory that are missing, different from the
+// generated files, or that are not generated anymore
+func checkDocs(dir string, files map[string][]byte, prefixes []string, ext string) error {
+	var stale []string
+	for name, content := range files {
+		existing, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || !bytes.Equal(normalizeDocs(existing), normalizeDocs(content)) {
+			stale = append(stale, name)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the docs directory")
+	}
+	for _, entry := range entries {
+		if _, ok := files[entry.Name()]; ok || entry.IsDir() {
+			continue
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(entry.Name(), prefix) && filepath.Ext(entry.Name()) == ext {
+				stale = append(stale, entry.Name())
+				break
+			}
+		}
+	}
+	if len(stale) == 0 {
 		return nil
-	},
+	}
+	sort.Strings(stale)
+	return errors.Errorf("docs in '%s' are not up to date, run generate-docs to update them: %s", dir, strings.Join(stale, ", "))
+}
+
+// normalizeDocs drops the header of man pages and the auto generated tag, which have the date of the generation
+func normalizeDocs(content []byte) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+	normalized := lines[:0]
+	for _, line := range lines {
+		if !bytes.HasPrefix(line, []byte(".TH ")) && !bytes.Contains(line, []byte("Auto generated by spf13/cobra")) {
+			normalized = append(normalized, line)
+		}
+	}
+	return bytes.Join(normalized, []byte("\n"))
 }
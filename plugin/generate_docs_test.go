@@ -4,15 +4,22 @@
 package plugin
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/logrusorgru/aurora"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/command"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
 )
 
 func TestGenDocsCmd(t *testing.T) {
@@ -43,7 +50,7 @@ func TestGenDocsCmd(t *testing.T) {
 	os.Stdout = w
 	os.Stderr = w
 
-	docsCmd := genDocsCmd
+	docsCmd := newGenDocsCmd(nil)
 	cmd.AddCommand(docsCmd)
 	args := []string{}
 	args = append(args, "generate-docs")
@@ -78,3 +85,145 @@ func checkDirectoryHasMDFiles(dirName string) error {
 	}
 	return errors.Errorf("directory is expected to have MD files")
 }
+
+func newDocsTestPlugin(t *testing.T) *Plugin {
+	p, err := NewPlugin(&PluginDescriptor{
+		Name:                    "test-plugin",
+		Target:                  types.TargetK8s,
+		Description:             "Description of the plugin",
+		Version:                 "v1.2.3",
+		Group:                   RunCmdGroup,
+		DocURL:                  "https://docs.example.com",
+		SkipDefaultFeatureFlags: true,
+	})
+	assert.NoError(t, err)
+
+	listCmd := &cobra.Command{Use: "list", Short: "List clusters", Run: func(cmd *cobra.Command, args []string) {}}
+	listCmd.Flags().Bool("all", false, "list all the clusters")
+	listCmd.Flags().Bool("include-deleted", false, "list the deleted clusters")
+	command.DeprecateFlagWithAlternative(listCmd, "include-deleted", "v2.0.0", "--all")
+	getCmd := &cobra.Command{Use: "get", Short: "Get a cluster", Run: func(cmd *cobra.Command, args []string) {}}
+	command.DeprecateCommandWithAlternative(getCmd, "v2.0.0", "describe")
+	clusterCmd := &cobra.Command{Use: "cluster", Short: "Manage clusters"}
+	clusterCmd.AddCommand(listCmd, getCmd)
+	p.AddCommands(clusterCmd)
+	p.Cmd.SetOut(&bytes.Buffer{})
+	p.Cmd.SetErr(&bytes.Buffer{})
+	return p
+}
+
+func genTestDocs(t *testing.T, p *Plugin, args ...string) error {
+	p.Cmd.SetArgs(append([]string{"generate-docs"}, args...))
+	return p.Execute()
+}
+
+func TestGenDocsFormats(t *testing.T) {
+	assert := assert.New(t)
+
+	// Markdown pages are the ones generated by cobra by default
+	docsDir := t.TempDir()
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir))
+	cobraDocsDir := t.TempDir()
+	p := newDocsTestPlugin(t)
+	tanzuCmd := &cobra.Command{Use: "tanzu"}
+	tanzuCmd.AddCommand(p.Cmd)
+	assert.NoError(doc.GenMarkdownTreeCustom(p.Cmd, cobraDocsDir, func(string) string { return "" }, docsLink))
+	cobraFiles, err := os.ReadDir(cobraDocsDir)
+	assert.NoError(err)
+	files, err := os.ReadDir(docsDir)
+	assert.NoError(err)
+	assert.Len(files, len(cobraFiles))
+	for _, file := range cobraFiles {
+		expected, err := os.ReadFile(filepath.Join(cobraDocsDir, file.Name()))
+		assert.NoError(err)
+		actual, err := os.ReadFile(filepath.Join(docsDir, file.Name()))
+		assert.NoError(err)
+		assert.Equal(string(expected), string(actual))
+	}
+	assert.NoFileExists(filepath.Join(docsDir, "tanzu_test-plugin_cluster_get.md"))
+
+	// Annotated Markdown pages include the plugin details and the deprecation notices
+	docsDir = t.TempDir()
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--annotate"))
+	root, err := os.ReadFile(filepath.Join(docsDir, "tanzu_test-plugin.md"))
+	assert.NoError(err)
+	assert.Contains(string(root), "Plugin details:\n\n* Group: Run\n* Target: kubernetes\n* Version: v1.2.3\n* Documentation: https://docs.example.com")
+	list, err := os.ReadFile(filepath.Join(docsDir, "tanzu_test-plugin_cluster_list.md"))
+	assert.NoError(err)
+	assert.Contains(string(list), "Deprecated flags:\n\n* --include-deleted: will be removed in version \"v2.0.0\". Use \"--all\" instead.")
+	assert.NotContains(string(list), "Auto generated")
+	get, err := os.ReadFile(filepath.Join(docsDir, "tanzu_test-plugin_cluster_get.md"))
+	assert.NoError(err)
+	assert.Contains(string(get), "Deprecated: will be removed in version \"v2.0.0\". Use \"describe\" instead.")
+	// Hidden commands are not documented
+	assert.NoFileExists(filepath.Join(docsDir, "tanzu_test-plugin_info.md"))
+
+	// Pages are prefixed with the front matter
+	docsDir = t.TempDir()
+	frontMatter := filepath.Join(t.TempDir(), "front-matter.tmpl")
+	assert.NoError(os.WriteFile(frontMatter, []byte("---\ntitle: {{ .Title }}\nslug: {{ .Filename }}\nversion: {{ .Plugin.Version }}\n---\n\n"), 0o600))
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--front-matter", frontMatter))
+	list, err = os.ReadFile(filepath.Join(docsDir, "tanzu_test-plugin_cluster_list.md"))
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(string(list), "---\ntitle: tanzu test-plugin cluster list\nslug: tanzu_test-plugin_cluster_list\nversion: v1.2.3\n---\n\n## tanzu test-plugin cluster list\n"))
+
+	// A single page links to the sections of the commands
+	docsDir = t.TempDir()
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--single-page"))
+	files, err = os.ReadDir(docsDir)
+	assert.NoError(err)
+	assert.Len(files, 1)
+	single, err := os.ReadFile(filepath.Join(docsDir, "tanzu_test-plugin.md"))
+	assert.NoError(err)
+	assert.Contains(string(single), "## tanzu test-plugin cluster list\n")
+	assert.Contains(string(single), "* [tanzu test-plugin cluster list](#tanzu-test-plugin-cluster-list)")
+
+	// Man and reStructuredText pages
+	docsDir = t.TempDir()
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", DocsFormatMan))
+	man, err := os.ReadFile(filepath.Join(docsDir, "tanzu-test-plugin-cluster-list.1"))
+	assert.NoError(err)
+	assert.Contains(string(man), "Tanzu CLI test-plugin v1.2.3")
+	docsDir = t.TempDir()
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", DocsFormatReST))
+	assert.FileExists(filepath.Join(docsDir, "tanzu_test-plugin_cluster_list.rst"))
+
+	// The JSON and YAML command references are the plugin manifest
+	docsDir = t.TempDir()
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", DocsFormatJSON))
+	data, err := os.ReadFile(filepath.Join(docsDir, "tanzu_test-plugin.json"))
+	assert.NoError(err)
+	manifest := &PluginManifest{}
+	assert.NoError(json.Unmarshal(data, manifest))
+	assert.Equal("test-plugin", manifest.Plugin.Name)
+	assert.NotNil(findCommandManifest(manifest.Command.Commands, "cluster"))
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", DocsFormatYAML))
+	assert.FileExists(filepath.Join(docsDir, "tanzu_test-plugin.yaml"))
+
+	// Unsupported options
+	assert.ErrorContains(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", "pdf"), "is not supported, supported formats are markdown|man|rst|yaml|json")
+	assert.ErrorContains(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", DocsFormatMan, "--single-page"), "single page docs are only supported for the markdown format")
+}
+
+func TestGenDocsCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, format := range []string{DocsFormatMarkdown, DocsFormatMan} {
+		docsDir := t.TempDir()
+		assert.ErrorContains(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", format, "--check"), "are not up to date")
+		assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", format))
+		assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir, "--format", format, "--check"))
+	}
+
+	docsDir := t.TempDir()
+	assert.NoError(genTestDocs(t, newDocsTestPlugin(t), "--docs-dir", docsDir))
+
+	// Changed commands, and commands that were removed, are stale
+	p := newDocsTestPlugin(t)
+	p.Cmd.Commands()[0].Short = "Manage workload clusters"
+	assert.NoError(os.WriteFile(filepath.Join(docsDir, "tanzu_test-plugin_cluster_delete.md"), []byte("## tanzu test-plugin cluster delete\n"), 0o600))
+	assert.NoError(os.WriteFile(filepath.Join(docsDir, "tanzu_other.md"), []byte("## tanzu other\n"), 0o600))
+	err := genTestDocs(t, p, "--docs-dir", docsDir, "--check")
+	assert.ErrorContains(err, "run generate-docs to update them: tanzu_test-plugin.md, tanzu_test-plugin_cluster.md, tanzu_test-plugin_cluster_delete.md")
+	assert.NotContains(err.Error(), "tanzu_other.md")
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+const (
+	// PreUninstallHookName is the hidden command run by the CLI before uninstalling the plugin
+	PreUninstallHookName = "pre-uninstall"
+	// PostUpgradeHookName is the hidden command run by the CLI after upgrading the plugin
+	PostUpgradeHookName = "post-upgrade"
+	// ContextChangedHookName is the hidden command run by the CLI after the current context of the plugin target changed
+	ContextChangedHookName = "context-changed"
+	// FirstRunHookName is the hidden command run by the CLI before the plugin is used for the first time
+	FirstRunHookName = "first-run"
+)
+
+// HookStatus is the outcome of a lifecycle hook
+type HookStatus string
+
+const (
+	HookStatusSucceeded HookStatus = "succeeded"
+	HookStatusFailed    HookStatus = "failed"
+	// HookStatusSkipped means the plugin does not implement the hook
+	HookStatusSkipped HookStatus = "skipped"
+)
+
+// HookResult is the machine-readable result printed by the lifecycle hook commands
+type HookResult struct {
+	Hook   string     `json:"hook" yaml:"hook"`
+	Status HookStatus `json:"status" yaml:"status"`
+	Error  string     `json:"error,omitempty" yaml:"error,omitempty"`
+	// Abort tells the CLI to abort the operation that triggered the hook e.g. the uninstall for pre-uninstall
+	Abort bool `json:"abort,omitempty" yaml:"abort,omitempty"`
+}
+
+// abortError is returned by a hook that requests the CLI to abort the operation that triggered it
+type abortError struct {
+	err error
+}
+
+func (e *abortError) Error() string {
+	return e.err.Error()
+}
+
+func (e *abortError) Unwrap() error {
+	return e.err
+}
+
+// AbortOperation wraps the error returned by a lifecycle hook to request the CLI to abort the operation that
+// triggered the hook. Other hook errors are reported by the CLI without aborting.
+func AbortOperation(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &abortError{err: err}
+}
+
+// newLifecycleCmds creates the hidden commands running the lifecycle hooks of the plugin
+func newLifecycleCmds(desc *PluginDescriptor) []*cobra.Command {
+	return []*cobra.Command{
+		newPreUninstallCmd(desc),
+		newPostUpgradeCmd(desc),
+		newContextChangedCmd(desc),
+		newFirstRunCmd(desc),
+	}
+}
+
+func newPreUninstallCmd(desc *PluginDescriptor) *cobra.Command {
+	return newHookCmd(PreUninstallHookName, "Run pre uninstall cleanup for a plugin", func(cmd *cobra.Command) (bool, error) {
+		if desc.PreUninstallHook == nil {
+			return false, nil
+		}
+		return true, desc.PreUninstallHook()
+	})
+}
+
+func newPostUpgradeCmd(desc *PluginDescriptor) *cobra.Command {
+	cmd := newHookCmd(PostUpgradeHookName, "Run post upgrade migration for a plugin", func(cmd *cobra.Command) (bool, error) {
+		if desc.PostUpgradeHook == nil {
+			return false, nil
+		}
+		// The flags are read on each run so that no value outlives the run, the new version defaults to the
+		// version of the plugin
+		oldVersion, _ := cmd.Flags().GetString("old-version")
+		newVersion, _ := cmd.Flags().GetString("new-version")
+		if newVersion == "" {
+			newVersion = desc.Version
+		}
+		return true, desc.PostUpgradeHook(oldVersion, newVersion)
+	}, "old-version")
+	cmd.Flags().String("old-version", "", "version of the plugin before the upgrade")
+	cmd.Flags().String("new-version", "", "version of the plugin after the upgrade, defaults to the plugin version")
+	return cmd
+}
+
+func newContextChangedCmd(desc *PluginDescriptor) *cobra.Command {
+	cmd := newHookCmd(ContextChangedHookName, "Run context change handling for a plugin", func(cmd *cobra.Command) (bool, error) {
+		if desc.ContextChangedHook == nil {
+			return false, nil
+		}
+		target, _ := cmd.Flags().GetString("target")
+		contextName, _ := cmd.Flags().GetString("context")
+		return true, desc.ContextChangedHook(types.StringToTarget(target), contextName)
+	}, "target")
+	cmd.Flags().String("target", "", "target of the context that changed")
+	cmd.Flags().String("context", "", "name of the new current context, empty if there is none")
+	return cmd
+}
+
+func newFirstRunCmd(desc *PluginDescriptor) *cobra.Command {
+	return newHookCmd(FirstRunHookName, "Run first run initialization for a plugin", func(cmd *cobra.Command) (bool, error) {
+		if desc.FirstRunHook == nil {
+			return false, nil
+		}
+		return true, desc.FirstRunHook()
+	})
+}
+
+// newHookCmd creates a hidden command running the hook and printing its HookResult as JSON.
+// The hook reads the flags of the command it is run by, and returns false if the plugin does not implement it.
+// The arguments and the required flags are validated by the command rather than by cobra, so that invalid
+// invocations are also reported as a failed HookResult.
+func newHookCmd(name, description string, hook func(cmd *cobra.Command) (bool, error), requiredFlags ...string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          name,
+		Short:        description,
+		Long:         description,
+		Hidden:       true,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			implemented, err := true, cobra.NoArgs(cmd, args)
+			if err == nil {
+				err = validateHookFlags(cmd, requiredFlags)
+			}
+			if err == nil {
+				implemented, err = hook(cmd)
+			}
+			return printHookResult(cmd, name, implemented, err)
+		},
+	}
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return printHookResult(cmd, name, true, err)
+	})
+	return cmd
+}
+
+// validateHookFlags returns an error in the format of cobra if any of the required flags is not set
+func validateHookFlags(cmd *cobra.Command, requiredFlags []string) error {
+	var missing []string
+	for _, flagName := range requiredFlags {
+		if !cmd.Flags().Changed(flagName) {
+			missing = append(missing, flagName)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf(`required flag(s) "%s" not set`, strings.Join(missing, `", "`))
+	}
+	return nil
+}
+
+// printHookResult prints the HookResult of the hook as JSON, and returns the error of the hook
+func printHookResult(cmd *cobra.Command, name string, implemented bool, err error) error {
+	result := HookResult{Hook: name, Status: HookStatusSucceeded}
+	var abortErr *abortError
+	switch {
+	case err != nil:
+		result.Status = HookStatusFailed
+		result.Error = err.Error()
+		result.Abort = errors.As(err, &abortErr)
+	case !implemented:
+		result.Status = HookStatusSkipped
+	}
+
+	data, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	return err
+}
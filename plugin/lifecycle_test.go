@@ -0,0 +1,129 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func runHookCmd(t *testing.T, descriptor *PluginDescriptor, args ...string) (HookResult, error) {
+	p, err := NewPlugin(descriptor)
+	assert.NoError(t, err)
+	var stdout, stderr bytes.Buffer
+	p.Cmd.SetOut(&stdout)
+	p.Cmd.SetErr(&stderr)
+	p.Cmd.SetArgs(args)
+	err = p.Execute()
+
+	var result HookResult
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+	return result, err
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	t.Setenv(config.EnvConfigKey, filepath.Join(dir, "config.yaml"))
+	t.Setenv(config.EnvConfigNextGenKey, filepath.Join(dir, "config-ng.yaml"))
+	t.Setenv(config.EnvConfigMetadataKey, filepath.Join(dir, "config-metadata.yaml"))
+	t.Setenv(config.EnvConfigAuditFileKey, filepath.Join(dir, config.AuditFileName))
+
+	descriptor := &PluginDescriptor{
+		Name:        "test-plugin",
+		Target:      types.TargetK8s,
+		Description: "Description of the plugin",
+		Version:     "v1.2.3",
+		Group:       "TestGroup",
+	}
+
+	// Hooks that are not implemented are skipped
+	for _, args := range [][]string{
+		{PreUninstallHookName},
+		{PostUpgradeHookName, "--old-version", "v1.0.0"},
+		{ContextChangedHookName, "--target", "kubernetes"},
+		{FirstRunHookName},
+	} {
+		result, err := runHookCmd(t, descriptor, args...)
+		assert.NoError(err)
+		assert.Equal(HookResult{Hook: args[0], Status: HookStatusSkipped}, result)
+	}
+
+	var upgradedFrom, upgradedTo, changedContext string
+	var changedTarget types.Target
+	descriptor.PostUpgradeHook = func(oldVersion, newVersion string) error {
+		upgradedFrom, upgradedTo = oldVersion, newVersion
+		return nil
+	}
+	descriptor.ContextChangedHook = func(target types.Target, contextName string) error {
+		changedTarget, changedContext = target, contextName
+		return nil
+	}
+	descriptor.DefaultFeatureFlags = map[string]bool{"new-feature": true}
+
+	result, err := runHookCmd(t, descriptor, PostUpgradeHookName, "--old-version", "v1.0.0")
+	assert.NoError(err)
+	assert.Equal(HookResult{Hook: PostUpgradeHookName, Status: HookStatusSucceeded}, result)
+	assert.Equal("v1.0.0", upgradedFrom)
+	assert.Equal("v1.2.3", upgradedTo)
+	enabled, err := config.IsFeatureEnabled("test-plugin", "new-feature")
+	assert.NoError(err)
+	assert.True(enabled)
+
+	// The default of the new version is not kept across runs of the same command
+	p, err := NewPlugin(descriptor)
+	assert.NoError(err)
+	p.Cmd.SetOut(&bytes.Buffer{})
+	p.Cmd.SetArgs([]string{PostUpgradeHookName, "--old-version", "v1.0.0"})
+	assert.NoError(p.Execute())
+	descriptor.Version = "v1.3.0"
+	assert.NoError(p.Execute())
+	assert.Equal("v1.3.0", upgradedTo)
+	descriptor.Version = "v1.2.3"
+
+	result, err = runHookCmd(t, descriptor, ContextChangedHookName, "--target", "k8s", "--context", "test-ctx")
+	assert.NoError(err)
+	assert.Equal(HookStatusSucceeded, result.Status)
+	assert.Equal(types.TargetK8s, changedTarget)
+	assert.Equal("test-ctx", changedContext)
+
+	// Failures are reported, and only abort the operation if the hook requests it
+	descriptor.FirstRunHook = func() error { return errors.New("login required") }
+	result, err = runHookCmd(t, descriptor, FirstRunHookName)
+	assert.EqualError(err, "login required")
+	assert.Equal(HookResult{Hook: FirstRunHookName, Status: HookStatusFailed, Error: "login required"}, result)
+
+	descriptor.PreUninstallHook = func() error {
+		return AbortOperation(errors.New("resources still in use"))
+	}
+	result, err = runHookCmd(t, descriptor, PreUninstallHookName)
+	assert.EqualError(err, "resources still in use")
+	assert.Equal(HookResult{Hook: PreUninstallHookName, Status: HookStatusFailed, Error: "resources still in use", Abort: true}, result)
+	assert.Nil(AbortOperation(nil))
+
+	// Invalid invocations are reported as failures, whether the hook is implemented or not
+	descriptor.ContextChangedHook = nil
+	for _, tc := range []struct {
+		args   []string
+		errStr string
+	}{
+		{[]string{PostUpgradeHookName}, `required flag(s) "old-version" not set`},
+		{[]string{ContextChangedHookName}, `required flag(s) "target" not set`},
+		{[]string{FirstRunHookName, "--unknown"}, "unknown flag: --unknown"},
+		{[]string{PreUninstallHookName, "extra"}, `unknown command "extra" for "test-plugin pre-uninstall"`},
+	} {
+		result, err = runHookCmd(t, descriptor, tc.args...)
+		assert.EqualError(err, tc.errStr)
+		assert.Equal(HookResult{Hook: tc.args[0], Status: HookStatusFailed, Error: tc.errStr}, result)
+	}
+}
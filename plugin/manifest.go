@@ -0,0 +1,196 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+)
+
+const (
+	// PluginManifestAPIVersion is the version of the manifest schema, bumped on incompatible changes
+	PluginManifestAPIVersion = "cli.tanzu.vmware.com/v1alpha1"
+	// PluginManifestKind is the kind of the manifest
+	PluginManifestKind = "PluginManifest"
+)
+
+// CommandCompletionType is how the arguments of a command are completed
+type CommandCompletionType string
+
+const (
+	// CommandCompletionNone means the command does not complete its arguments
+	CommandCompletionNone CommandCompletionType = "none"
+	// CommandCompletionStatic means the arguments are completed from ValidArgs
+	CommandCompletionStatic CommandCompletionType = "static"
+	// CommandCompletionDynamic means the arguments are completed by running the plugin
+	CommandCompletionDynamic CommandCompletionType = "dynamic"
+)
+
+// PluginManifest describes the capabilities of a plugin so that the CLI can build help and
+// completion without running each plugin command
+type PluginManifest struct {
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+	// Plugin is the plugin information returned by the info command
+	Plugin pluginInfo `json:"plugin" yaml:"plugin"`
+	// Command is the root command of the plugin along with its sub-commands
+	Command CommandManifest `json:"command" yaml:"command"`
+	// Dependencies are the config keys, feature flags and environment variables used by the plugin
+	Dependencies PluginManifestDependencies `json:"dependencies" yaml:"dependencies"`
+}
+
+// CommandManifest describes a command of the plugin
+type CommandManifest struct {
+	Name string `json:"name" yaml:"name"`
+	// Path is the command path without the plugin name e.g. cluster list, empty for the root command
+	Path       string                `json:"path" yaml:"path"`
+	Use        string                `json:"use" yaml:"use"`
+	Short      string                `json:"short,omitempty" yaml:"short,omitempty"`
+	Aliases    []string              `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Hidden     bool                  `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	Deprecated string                `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Completion CommandCompletionType `json:"completion" yaml:"completion"`
+	ValidArgs  []string              `json:"validArgs,omitempty" yaml:"validArgs,omitempty"`
+	Flags      []FlagManifest        `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Commands   []CommandManifest     `json:"commands,omitempty" yaml:"commands,omitempty"`
+}
+
+// FlagManifest describes a flag of a command
+type FlagManifest struct {
+	Name       string `json:"name" yaml:"name"`
+	Shorthand  string `json:"shorthand,omitempty" yaml:"shorthand,omitempty"`
+	Type       string `json:"type" yaml:"type"`
+	Default    string `json:"default,omitempty" yaml:"default,omitempty"`
+	Usage      string `json:"usage,omitempty" yaml:"usage,omitempty"`
+	Required   bool   `json:"required,omitempty" yaml:"required,omitempty"`
+	Persistent bool   `json:"persistent,omitempty" yaml:"persistent,omitempty"`
+	Hidden     bool   `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	Deprecated string `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// PluginManifestDependencies lists the config the plugin depends on
+type PluginManifestDependencies struct {
+	ConfigKeys   []string             `json:"configKeys,omitempty" yaml:"configKeys,omitempty"`
+	FeatureFlags []config.FeatureFlag `json:"featureFlags,omitempty" yaml:"featureFlags,omitempty"`
+	EnvVars      []string             `json:"envVars,omitempty" yaml:"envVars,omitempty"`
+}
+
+func newManifestCmd(desc *PluginDescriptor) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "manifest",
+		Short:  "Plugin capability manifest",
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := json.Marshal(newPluginManifest(desc, cmd.Root()))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(b))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newPluginManifest builds the manifest of the plugin from its descriptor and its command tree
+func newPluginManifest(desc *PluginDescriptor, root *cobra.Command) *PluginManifest {
+	return &PluginManifest{
+		APIVersion: PluginManifestAPIVersion,
+		Kind:       PluginManifestKind,
+		Plugin: pluginInfo{
+			PluginDescriptor:     *desc,
+			PluginRuntimeVersion: getPluginRuntimeVersion(),
+		},
+		Command: newCommandManifest(root),
+		Dependencies: PluginManifestDependencies{
+			ConfigKeys:   desc.ConfigKeys,
+			FeatureFlags: pluginFeatureFlags(desc),
+			EnvVars:      desc.EnvVars,
+		},
+	}
+}
+
+func newCommandManifest(cmd *cobra.Command) CommandManifest {
+	manifest := CommandManifest{
+		Name:       cmd.Name(),
+		Path:       strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " "),
+		Use:        cmd.Use,
+		Short:      cmd.Short,
+		Aliases:    cmd.Aliases,
+		Hidden:     cmd.Hidden,
+		Deprecated: cmd.Deprecated,
+		Completion: CommandCompletionNone,
+		ValidArgs:  cmd.ValidArgs,
+	}
+	switch {
+	case cmd.ValidArgsFunction != nil:
+		manifest.Completion = CommandCompletionDynamic
+	case len(cmd.ValidArgs) > 0:
+		manifest.Completion = CommandCompletionStatic
+	}
+
+	persistent := cmd.PersistentFlags()
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		manifest.Flags = append(manifest.Flags, newFlagManifest(flag, persistent.Lookup(flag.Name) != nil))
+	})
+	for _, sub := range cmd.Commands() {
+		manifest.Commands = append(manifest.Commands, newCommandManifest(sub))
+	}
+	return manifest
+}
+
+func newFlagManifest(flag *pflag.Flag, persistent bool) FlagManifest {
+	manifest := FlagManifest{
+		Name:       flag.Name,
+		Shorthand:  flag.Shorthand,
+		Type:       flag.Value.Type(),
+		Default:    flag.DefValue,
+		Usage:      flag.Usage,
+		Persistent: persistent,
+		Hidden:     flag.Hidden,
+		Deprecated: flag.Deprecated,
+	}
+	if required, ok := flag.Annotations[cobra.BashCompOneRequiredFlag]; ok && len(required) > 0 {
+		manifest.Required = required[0] == "true"
+	}
+	return manifest
+}
+
+// pluginFeatureFlags returns the feature flags registered for the plugin along with its default feature flags
+func pluginFeatureFlags(desc *PluginDescriptor) []config.FeatureFlag {
+	var flags []config.FeatureFlag
+	registered := make(map[string]bool)
+	for _, flag := range config.GetRegisteredFeatureFlags() {
+		if flag.Plugin == desc.Name {
+			flags = append(flags, flag)
+			registered[flag.Name] = true
+		}
+	}
+
+	keys := make([]string, 0, len(desc.DefaultFeatureFlags))
+	for key := range desc.DefaultFeatureFlags {
+		if !registered[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		flags = append(flags, config.FeatureFlag{
+			Plugin:  desc.Name,
+			Name:    key,
+			Type:    config.FeatureFlagTypeBool,
+			Default: strconv.FormatBool(desc.DefaultFeatureFlags[key]),
+		})
+	}
+	return flags
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func findCommandManifest(manifests []CommandManifest, name string) *CommandManifest {
+	for i := range manifests {
+		if manifests[i].Name == name {
+			return &manifests[i]
+		}
+	}
+	return nil
+}
+
+func findFlagManifest(manifests []FlagManifest, name string) *FlagManifest {
+	for i := range manifests {
+		if manifests[i].Name == name {
+			return &manifests[i]
+		}
+	}
+	return nil
+}
+
+func TestManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	descriptor := &PluginDescriptor{
+		Name:        "test-plugin",
+		Target:      types.TargetK8s,
+		Description: "Description of the plugin",
+		Version:     "v1.2.3",
+		Group:       "TestGroup",
+		DefaultFeatureFlags: map[string]bool{
+			"features.test-plugin.zeta":  true,
+			"features.test-plugin.alpha": false,
+		},
+		ConfigKeys: []string{"contexts", "clientOptions.cli.bomRepo"},
+		EnvVars:    []string{"TEST_PLUGIN_TIMEOUT"},
+	}
+	assert.NoError(config.RegisterFeatureFlag(config.FeatureFlag{
+		Plugin:      "test-plugin",
+		Name:        "features.test-plugin.registered",
+		Description: "A registered feature",
+		Stage:       config.FeatureFlagStageBeta,
+	}))
+	defer config.UnregisterFeatureFlag("test-plugin", "features.test-plugin.registered")
+
+	p, err := NewPlugin(descriptor)
+	assert.NoError(err)
+
+	clusterCmd := &cobra.Command{Use: "cluster", Aliases: []string{"cl"}, Short: "Manage clusters"}
+	listCmd := &cobra.Command{
+		Use:       "list [kind]",
+		Short:     "List clusters",
+		ValidArgs: []string{"workload", "management"},
+		RunE:      func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	listCmd.Flags().StringP("namespace", "n", "default", "namespace of the clusters")
+	_ = listCmd.MarkFlagRequired("namespace")
+	getCmd := &cobra.Command{
+		Use:        "get NAME",
+		Deprecated: "use describe instead",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	clusterCmd.PersistentFlags().Bool("all", false, "all the clusters")
+	clusterCmd.AddCommand(listCmd, getCmd)
+	p.AddCommands(clusterCmd)
+
+	var stdout bytes.Buffer
+	p.Cmd.SetOut(&stdout)
+	p.Cmd.SetArgs([]string{"manifest"})
+	assert.NoError(p.Cmd.Execute())
+
+	manifest := &PluginManifest{}
+	assert.NoError(json.Unmarshal(stdout.Bytes(), manifest))
+	assert.Equal(PluginManifestAPIVersion, manifest.APIVersion)
+	assert.Equal(PluginManifestKind, manifest.Kind)
+	assert.Equal(descriptor.Name, manifest.Plugin.Name)
+	assert.Equal(descriptor.Version, manifest.Plugin.Version)
+	assert.Equal(descriptor.Target, manifest.Plugin.Target)
+
+	// The command tree is walked from the root command
+	assert.Equal("test-plugin", manifest.Command.Name)
+	assert.Equal("", manifest.Command.Path)
+	cluster := findCommandManifest(manifest.Command.Commands, "cluster")
+	if assert.NotNil(cluster) {
+		assert.Equal("cluster", cluster.Path)
+		assert.Equal([]string{"cl"}, cluster.Aliases)
+		assert.Equal("Manage clusters", cluster.Short)
+		assert.Equal(CommandCompletionNone, cluster.Completion)
+		all := findFlagManifest(cluster.Flags, "all")
+		if assert.NotNil(all) {
+			assert.True(all.Persistent)
+			assert.Equal("bool", all.Type)
+			assert.Equal("false", all.Default)
+		}
+
+		list := findCommandManifest(cluster.Commands, "list")
+		if assert.NotNil(list) {
+			assert.Equal("cluster list", list.Path)
+			assert.Equal(CommandCompletionStatic, list.Completion)
+			assert.Equal([]string{"workload", "management"}, list.ValidArgs)
+			namespace := findFlagManifest(list.Flags, "namespace")
+			if assert.NotNil(namespace) {
+				assert.Equal(FlagManifest{
+					Name:      "namespace",
+					Shorthand: "n",
+					Type:      "string",
+					Default:   "default",
+					Usage:     "namespace of the clusters",
+					Required:  true,
+				}, *namespace)
+			}
+			// Inherited flags are only listed on the command defining them
+			assert.Nil(findFlagManifest(list.Flags, "all"))
+		}
+
+		get := findCommandManifest(cluster.Commands, "get")
+		if assert.NotNil(get) {
+			assert.Equal(CommandCompletionDynamic, get.Completion)
+			assert.Equal("use describe instead", get.Deprecated)
+		}
+	}
+
+	// Hidden commands are listed as such
+	manifestCmd := findCommandManifest(manifest.Command.Commands, "manifest")
+	if assert.NotNil(manifestCmd) {
+		assert.True(manifestCmd.Hidden)
+	}
+
+	// Dependencies list the declared config keys and environment variables, the registered feature flags
+	// of the plugin and then its default feature flags
+	assert.Equal(descriptor.ConfigKeys, manifest.Dependencies.ConfigKeys)
+	assert.Equal(descriptor.EnvVars, manifest.Dependencies.EnvVars)
+	if assert.Len(manifest.Dependencies.FeatureFlags, 3) {
+		assert.Equal("features.test-plugin.registered", manifest.Dependencies.FeatureFlags[0].Name)
+		assert.Equal(config.FeatureFlagStageBeta, manifest.Dependencies.FeatureFlags[0].Stage)
+		assert.Equal(config.FeatureFlag{
+			Plugin:  "test-plugin",
+			Name:    "features.test-plugin.alpha",
+			Type:    config.FeatureFlagTypeBool,
+			Default: "false",
+		}, manifest.Dependencies.FeatureFlags[1])
+		assert.Equal("features.test-plugin.zeta", manifest.Dependencies.FeatureFlags[2].Name)
+		assert.Equal("true", manifest.Dependencies.FeatureFlags[2].Default)
+	}
+}
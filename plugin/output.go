@@ -0,0 +1,88 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/component"
+)
+
+const (
+	// OutputFlagName is the name of the output flag of the plugin commands
+	OutputFlagName = "output"
+	// OutputFlagShorthand is the shorthand of the output flag of the plugin commands
+	OutputFlagShorthand = "o"
+)
+
+// SupportedOutputTypes are the values of the output flag added by AddOutputFlag
+var SupportedOutputTypes = []component.OutputType{
+	component.TableOutputType,
+	component.JSONOutputType,
+	component.YAMLOutputType,
+	component.ListTableOutputType,
+}
+
+// outputTypeValue is the value of the output flag, which only accepts the supported output types
+type outputTypeValue component.OutputType
+
+func (v *outputTypeValue) String() string {
+	return string(*v)
+}
+
+func (v *outputTypeValue) Set(value string) error {
+	outputType := component.OutputType(strings.ToLower(value))
+	for _, supported := range SupportedOutputTypes {
+		if outputType == supported {
+			*v = outputTypeValue(outputType)
+			return nil
+		}
+	}
+	return fmt.Errorf("output format %q is not supported, supported formats are %s", value, strings.Join(supportedOutputTypeNames(), "|"))
+}
+
+func (v *outputTypeValue) Type() string {
+	return "string"
+}
+
+func supportedOutputTypeNames() []string {
+	names := make([]string, 0, len(SupportedOutputTypes))
+	for _, outputType := range SupportedOutputTypes {
+		names = append(names, string(outputType))
+	}
+	return names
+}
+
+// AddOutputFlag adds the persistent -o/--output flag to all the commands of the plugin, with shell completion of
+// the supported output types. Commands get their output writer with NewOutputWriter. Commands that declare their
+// own output flag keep it, but must not use the -o shorthand for another flag.
+func (p *Plugin) AddOutputFlag() {
+	value := outputTypeValue(component.TableOutputType)
+	p.Cmd.PersistentFlags().VarP(&value, OutputFlagName, OutputFlagShorthand, "output format: "+strings.Join(supportedOutputTypeNames(), "|"))
+	_ = p.Cmd.RegisterFlagCompletionFunc(OutputFlagName, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return supportedOutputTypeNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// GetOutputType returns the output type requested by the output flag of the command, table if it has none
+func GetOutputType(cmd *cobra.Command) component.OutputType {
+	if outputType := commandOutputType(cmd); outputType != "" {
+		return outputType
+	}
+	return component.TableOutputType
+}
+
+// NewOutputWriter returns an output writer to the output of the command in the format requested by its output flag
+func NewOutputWriter(cmd *cobra.Command, headers ...string) component.OutputWriter {
+	return component.NewOutputWriter(cmd.OutOrStdout(), string(GetOutputType(cmd)), headers...)
+}
+
+// NewOutputWriterWithSpinner returns an output writer with a spinner to the output of the command in the format
+// requested by its output flag. The spinner is only shown for the table formats.
+func NewOutputWriterWithSpinner(cmd *cobra.Command, spinnerText string, startSpinner bool, headers ...string) (component.OutputWriterSpinner, error) {
+	return component.NewOutputWriterWithSpinner(cmd.OutOrStdout(), string(GetOutputType(cmd)), spinnerText, startSpinner, headers...)
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/component"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+func TestOutputFlag(t *testing.T) {
+	newTestPlugin := func() (*Plugin, *bytes.Buffer, *bytes.Buffer) {
+		p, err := NewPlugin(&PluginDescriptor{
+			Name:                    "test-plugin",
+			Target:                  types.TargetGlobal,
+			Description:             "Description of the plugin",
+			Version:                 "v1.2.3",
+			Group:                   "TestGroup",
+			SkipDefaultFeatureFlags: true,
+		})
+		assert.NoError(t, err)
+		p.AddOutputFlag()
+
+		listCmd := &cobra.Command{
+			Use: "list",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				writer := NewOutputWriter(cmd, "Name", "Status")
+				writer.AddRow("test", "running")
+				writer.Render()
+				return nil
+			},
+		}
+		clusterCmd := &cobra.Command{Use: "cluster"}
+		clusterCmd.AddCommand(listCmd)
+		// Commands declaring their own output flag keep it
+		legacyCmd := &cobra.Command{
+			Use: "legacy",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cmd.Print(GetOutputType(cmd))
+				return nil
+			},
+		}
+		legacyCmd.Flags().StringP("output", "o", "custom", "output format")
+		p.AddCommands(clusterCmd, legacyCmd)
+
+		var stdout, stderr bytes.Buffer
+		p.Cmd.SetOut(&stdout)
+		p.Cmd.SetErr(&stderr)
+		return p, &stdout, &stderr
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdout string
+	}{
+		{name: "default table", args: []string{"cluster", "list"}, stdout: "  NAME  STATUS   \n  test  running  \n"},
+		{name: "json", args: []string{"cluster", "list", "-o", "json"}, stdout: "[\n  {\n    \"name\": \"test\",\n    \"status\": \"running\"\n  }\n]"},
+		{name: "yaml", args: []string{"cluster", "list", "--output", "YAML"}, stdout: "- name: test\n  status: running\n"},
+		{name: "flag before sub-command", args: []string{"-o", "yaml", "cluster", "list"}, stdout: "- name: test\n  status: running\n"},
+		{name: "command output flag", args: []string{"legacy"}, stdout: "custom"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, stdout, _ := newTestPlugin()
+			p.Cmd.SetArgs(tc.args)
+			assert.NoError(t, p.Execute())
+			assert.Equal(t, tc.stdout, stdout.String())
+		})
+	}
+
+	// Unsupported formats are usage errors
+	p, _, stderr := newTestPlugin()
+	p.Cmd.SetArgs([]string{"cluster", "list", "-o", "xml"})
+	err := p.Execute()
+	assert.Equal(t, ExitCodeUsage, ExitCode(err))
+	assert.Contains(t, stderr.String(), `output format "xml" is not supported, supported formats are table|json|yaml|listtable`)
+
+	// The supported formats are completed
+	p, stdout, _ := newTestPlugin()
+	p.Cmd.SetArgs([]string{cobra.ShellCompRequestCmd, "cluster", "list", "-o", ""})
+	assert.NoError(t, p.Execute())
+	assert.Equal(t, "table\njson\nyaml\nlisttable\n:4\n", stdout.String())
+
+	// Commands of plugins without the output flag render tables
+	assert.Equal(t, component.TableOutputType, GetOutputType(&cobra.Command{Use: "test"}))
+}
@@ -4,19 +4,28 @@
 package plugin
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"go.uber.org/multierr"
 	"golang.org/x/mod/semver"
 
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
 	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
 )
 
 // Plugin is a Tanzu CLI plugin.
 type Plugin struct {
-	Cmd *cobra.Command
+	Cmd        *cobra.Command
+	descriptor *PluginDescriptor
+	// compatibilityErr is the error of the compatibility check against the CLI running the plugin
+	compatibilityErr error
+	// telemetrySink records the invocations of the commands, telemetry is disabled if nil
+	telemetrySink TelemetrySink
 }
 
 // NewPlugin creates an instance of Plugin.
@@ -26,12 +35,30 @@ func NewPlugin(descriptor *PluginDescriptor) (*Plugin, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid PluginDescriptor specified")
 	}
+	// Config changes made by the plugin are recorded in the audit trail under its name
+	config.SetAuditPluginName(descriptor.Name)
+	// Conditional features are evaluated against the version of the plugin
+	config.SetFeaturePluginVersion(descriptor.Version)
 	p := &Plugin{
-		Cmd: newRootCmd(descriptor),
+		Cmd:              newRootCmd(descriptor),
+		descriptor:       descriptor,
+		compatibilityErr: checkCLICompatibility(descriptor),
 	}
 	p.Cmd.AddCommand(lintCmd)
-	p.Cmd.AddCommand(genDocsCmd)
+	p.Cmd.AddCommand(newGenDocsCmd(descriptor))
 	p.Cmd.AddCommand(newPostInstallCmd(descriptor))
+	p.Cmd.AddCommand(newLifecycleCmds(descriptor)...)
+	p.Cmd.AddCommand(newManifestCmd(descriptor))
+	p.Cmd.AddCommand(newDoctorCmd(descriptor))
+	switch descriptor.CompletionType {
+	case StaticPluginCompletion:
+		p.Cmd.ValidArgs = append(p.Cmd.ValidArgs, descriptor.CompletionArgs...)
+	case DynamicPluginCompletion:
+		p.Cmd.AddCommand(newCompletionCmd(descriptor))
+	}
+	p.Cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err)
+	})
 	return p, nil
 }
 
@@ -40,9 +67,106 @@ func (p *Plugin) AddCommands(commands ...*cobra.Command) {
 	p.Cmd.AddCommand(commands...)
 }
 
+// CompatibilityError returns the error of the compatibility check against the CLI running the plugin, or nil
+// if the CLI is compatible. The plugin runs in degraded mode when the error is not nil and Compatibility.Degraded
+// is set.
+func (p *Plugin) CompatibilityError() error {
+	return p.compatibilityErr
+}
+
 // Execute executes the plugin.
+// It fails if the CLI is not compatible with the plugin, unless the plugin runs in degraded mode or the
+// command describes or uninstalls the plugin. Signals are not handled, plugins whose commands return once
+// their context is cancelled use ExecuteContext instead.
+// Errors are printed with their hint, as JSON or YAML if the output flag of the command requests it, and the
+// plugin should exit with ExitCode of the returned error.
 func (p *Plugin) Execute() error {
-	return p.Cmd.Execute()
+	start := time.Now()
+	cmd, err := p.execute(context.Background())
+	p.recordTelemetry(cmd, start, err)
+	return err
+}
+
+// execute configures the default feature flags and runs the command, returning the command that ran
+func (p *Plugin) execute(ctx context.Context) (*cobra.Command, error) {
+	if p.descriptor != nil {
+		// Failing to configure the default feature flags should not prevent the plugin from running
+		if err := applyDefaultFeatureFlags(p.descriptor); err != nil {
+			log.V(6).Infof("unable to configure default feature flags for plugin %q: %v", p.descriptor.Name, err)
+		}
+	}
+	return p.executeCommand(ctx)
+}
+
+// executeCommand runs the command, printing its error as requested by the output flag of the command unless
+// errors are silenced, and returns the command that ran. Errors in the command line are returned as usage errors.
+// The compatibility of the CLI is checked once the command to run is found from the arguments.
+func (p *Plugin) executeCommand(ctx context.Context) (*cobra.Command, error) {
+	wrapArgsValidation(p.Cmd, p.checkCompatibility)
+	silenceErrors := p.Cmd.SilenceErrors
+	p.Cmd.SilenceErrors = true
+	defer func() {
+		p.Cmd.SilenceErrors = silenceErrors
+	}()
+
+	cmd, err := p.Cmd.ExecuteContextC(ctx)
+	if cmd == nil {
+		cmd = p.Cmd
+	}
+	if isUnknownCommandError(cmd, err) {
+		err = usageError(err)
+	}
+	if err != nil && !silenceErrors && (cmd == p.Cmd || !cmd.SilenceErrors) {
+		printError(cmd, err)
+	}
+	return cmd, err
+}
+
+// checkCompatibility returns the compatibility error of the CLI unless the command is exempt from the check or
+// the plugin runs in degraded mode
+func (p *Plugin) checkCompatibility(cmd *cobra.Command) error {
+	if p.compatibilityErr == nil || isCompatibilityExempt(p.Cmd, cmd) {
+		return nil
+	}
+	if p.descriptor == nil || p.descriptor.Compatibility == nil || !p.descriptor.Compatibility.Degraded {
+		return p.compatibilityErr
+	}
+	log.Warningf("%v, some features may not be available", p.compatibilityErr)
+	return nil
+}
+
+// wrapArgsValidation makes the argument validation of the commands check the compatibility of the CLI, as the
+// validation runs once the command to run is found and before any of its hooks, and return usage errors.
+// The root command is left as is since cobra reports unknown commands only if it has no argument validation,
+// and it is not runnable.
+func wrapArgsValidation(cmd *cobra.Command, checkCompatibility func(cmd *cobra.Command) error) {
+	if (cmd.Args != nil || cmd.HasParent()) && cmd.Annotations[usageErrorArgsAnnotation] == "" {
+		args := cmd.Args
+		if args == nil {
+			args = cobra.ArbitraryArgs
+		}
+		cmd.Args = func(cmd *cobra.Command, a []string) error {
+			if err := checkCompatibility(cmd); err != nil {
+				return err
+			}
+			return usageError(args(cmd, a))
+		}
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[usageErrorArgsAnnotation] = "true"
+	}
+	for _, sub := range cmd.Commands() {
+		wrapArgsValidation(sub, checkCompatibility)
+	}
+}
+
+// applyDefaultFeatureFlags configures the missing DefaultFeatureFlags of the plugin unless opted out
+func applyDefaultFeatureFlags(descriptor *PluginDescriptor) error {
+	if descriptor.SkipDefaultFeatureFlags {
+		return nil
+	}
+	return config.ApplyDefaultFeatureFlags(descriptor.Name, descriptor.Version, descriptor.DefaultFeatureFlags)
 }
 
 // ApplyDefaultConfig applies default configurations to plugin descriptor.
@@ -78,5 +202,16 @@ func ValidatePlugin(p *PluginDescriptor) (err error) {
 	if p.Group == "" {
 		err = multierr.Append(err, fmt.Errorf("plugin %q: group cannot be empty", p.Name))
 	}
+	if completionErr := validateCompletion(p); completionErr != nil {
+		err = multierr.Append(err, fmt.Errorf("plugin %q: %v", p.Name, completionErr))
+	}
+	if doctorErr := validateDoctorChecks(p.DoctorChecks); doctorErr != nil {
+		err = multierr.Append(err, fmt.Errorf("plugin %q: %v", p.Name, doctorErr))
+	}
+	if p.Compatibility != nil {
+		if compatibilityErr := validateCompatibility(p.Compatibility); compatibilityErr != nil {
+			err = multierr.Append(err, fmt.Errorf("plugin %q: %v", p.Name, compatibilityErr))
+		}
+	}
 	return
 }
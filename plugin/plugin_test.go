@@ -4,14 +4,36 @@
 package plugin
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
 	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
 )
 
+// TestMain runs the tests against an empty home directory so that executing the plugins does not read nor write
+// the config of the user
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "plugin-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	_ = os.Setenv("HOME", home)
+	for _, key := range []string{config.EnvConfigDirKey, config.EnvConfigKey, config.EnvConfigNextGenKey,
+		config.EnvConfigMetadataKey, config.EnvConfigAuditFileKey, EnvTelemetryFileKey} {
+		_ = os.Unsetenv(key)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
+}
+
 func TestValidatePlugin(t *testing.T) {
 	assert := assert.New(t)
 
@@ -83,8 +105,9 @@ func TestAddCommands(t *testing.T) {
 	}
 	cmd.AddCommands(subCmd)
 
-	// Plugin gets 6 commands by default (describe, info, version, lint, post-install, generate-docs), ours should make 7.
-	assert.Equal(7, len(cmd.Cmd.Commands()))
+	// Plugin gets 12 commands by default (describe, info, version, lint, post-install, pre-uninstall, post-upgrade,
+	// context-changed, first-run, manifest, doctor, generate-docs), ours should make 13.
+	assert.Equal(13, len(cmd.Cmd.Commands()))
 }
 
 func TestExecute(t *testing.T) {
@@ -108,3 +131,47 @@ func TestExecute(t *testing.T) {
 
 	assert.Nil(cmd.Execute())
 }
+
+func TestExecuteConfiguresDefaultFeatureFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	t.Setenv(config.EnvConfigKey, filepath.Join(dir, "config.yaml"))
+	t.Setenv(config.EnvConfigNextGenKey, filepath.Join(dir, "config-ng.yaml"))
+	t.Setenv(config.EnvConfigMetadataKey, filepath.Join(dir, "config-metadata.yaml"))
+	t.Setenv(config.EnvConfigAuditFileKey, filepath.Join(dir, config.AuditFileName))
+
+	descriptor := PluginDescriptor{
+		Name:        "test-plugin",
+		Target:      types.TargetGlobal,
+		Description: "Description of the plugin",
+		Version:     "v1.2.3",
+		Group:       "TestGroup",
+		DefaultFeatureFlags: map[string]bool{
+			"sample-feature": true,
+		},
+	}
+
+	p, err := NewPlugin(&descriptor)
+	assert.Nil(err)
+	p.Cmd.SetArgs([]string{"version"})
+	assert.Nil(p.Execute())
+
+	ok, err := config.IsFeatureEnabled("test-plugin", "sample-feature")
+	assert.Nil(err)
+	assert.True(ok)
+
+	record, err := config.GetDefaultFeatureFlagsRecord("test-plugin")
+	assert.Nil(err)
+	assert.Equal("v1.2.3", record.Version)
+
+	descriptor.Name = "skipped-plugin"
+	descriptor.SkipDefaultFeatureFlags = true
+	p, err = NewPlugin(&descriptor)
+	assert.Nil(err)
+	p.Cmd.SetArgs([]string{"version"})
+	assert.Nil(p.Execute())
+
+	_, err = config.IsFeatureEnabled("skipped-plugin", "sample-feature")
+	assert.NotNil(err)
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/component"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
+)
+
+// exitCodeSignalBase is added to the signal number in the exit code of an interrupted plugin, as done by shells
+const exitCodeSignalBase = 128
+
+// ShutdownGracePeriod is how long an interrupted plugin command has to return after its context is cancelled.
+// The plugin exits when the period elapses or on a second signal.
+var ShutdownGracePeriod = 10 * time.Second
+
+// shutdownSignals are the signals cancelling the context of the plugin command
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// osExit exits the process when the interrupted command does not return, replaced by tests
+var osExit = os.Exit
+
+// InterruptedError is returned when the plugin command was interrupted by a signal
+type InterruptedError struct {
+	Signal os.Signal
+	// Err is the error returned by the command after its context was cancelled, if any
+	Err error
+}
+
+func (e *InterruptedError) Error() string {
+	if e.Err != nil && !errors.Is(e.Err, context.Canceled) {
+		return fmt.Sprintf("interrupted by signal %v: %v", e.Signal, e.Err)
+	}
+	return fmt.Sprintf("interrupted by signal %v", e.Signal)
+}
+
+func (e *InterruptedError) Unwrap() error {
+	if e.Err != nil {
+		return e.Err
+	}
+	return context.Canceled
+}
+
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return exitCodeSignalBase + int(s)
+	}
+	return ExitCodeError
+}
+
+// ExecuteContext executes the plugin as Execute does, with a context that is cancelled on SIGINT or SIGTERM.
+// Commands get the context with cmd.Context() and should return once it is cancelled, releasing the config locks
+// they hold as usual. Commands that do not return within ShutdownGracePeriod, or by a second signal, are stopped
+// by exiting the process after stopping the spinners. The config locks are never released while a command may be
+// writing the config, the operating system releases the locks still held when the process exits.
+func (p *Plugin) ExecuteContext(ctx context.Context) error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, shutdownSignals...)
+	defer signal.Stop(signals)
+	return p.executeWithSignals(ctx, signals)
+}
+
+func (p *Plugin) executeWithSignals(ctx context.Context, signals <-chan os.Signal) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	handled := make(chan struct{})
+	interrupted := make(chan os.Signal, 1)
+	go func() {
+		defer close(handled)
+		handleShutdownSignals(signals, cancel, ShutdownGracePeriod, done, interrupted)
+	}()
+
+	start := time.Now()
+	cmd, err := p.execute(ctx)
+	close(done)
+	<-handled
+	select {
+	case sig := <-interrupted:
+		cleanupOnShutdown()
+		err = &InterruptedError{Signal: sig, Err: err}
+	default:
+	}
+	// The telemetry event is recorded with the exit code the plugin exits with
+	p.recordTelemetry(cmd, start, err)
+	return err
+}
+
+// handleShutdownSignals cancels the context on the first signal, and exits if the command does not return
+// within the grace period or on a second signal
+func handleShutdownSignals(signals <-chan os.Signal, cancel context.CancelFunc, gracePeriod time.Duration, done <-chan struct{}, interrupted chan<- os.Signal) {
+	var sig os.Signal
+	select {
+	case sig = <-signals:
+	case <-done:
+		return
+	}
+	interrupted <- sig
+	cancel()
+	log.Infof("Received signal %v, waiting for the command to stop. Send the signal again to exit immediately.", sig)
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return
+	case <-signals:
+	case <-timer.C:
+	}
+	cleanupOnShutdown()
+	osExit(signalExitCode(sig))
+}
+
+// cleanupOnShutdown leaves the terminal in a clean state before the plugin exits on a signal
+func cleanupOnShutdown() {
+	component.StopActiveSpinners()
+}
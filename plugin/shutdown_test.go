@@ -0,0 +1,147 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/juju/fslock"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
+)
+
+func newShutdownTestPlugin(t *testing.T, run func(cmd *cobra.Command) error) *Plugin {
+	p, err := NewPlugin(&PluginDescriptor{
+		Name:                    "test-plugin",
+		Target:                  types.TargetGlobal,
+		Description:             "Description of the plugin",
+		Version:                 "v1.2.3",
+		Group:                   "TestGroup",
+		SkipDefaultFeatureFlags: true,
+	})
+	assert.NoError(t, err)
+	p.AddCommands(&cobra.Command{Use: "run", RunE: func(cmd *cobra.Command, args []string) error {
+		return run(cmd)
+	}})
+	p.Cmd.SetOut(&bytes.Buffer{})
+	p.Cmd.SetArgs([]string{"run"})
+	return p
+}
+
+func TestExitCode(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(ExitCodeSuccess, ExitCode(nil))
+	assert.Equal(ExitCodeError, ExitCode(errors.New("failed")))
+	assert.Equal(130, ExitCode(&InterruptedError{Signal: os.Interrupt}))
+	assert.Equal(143, ExitCode(errors.Wrap(&InterruptedError{Signal: syscall.SIGTERM}, "wrapped")))
+}
+
+func TestExecuteContextCancelledOnSignal(t *testing.T) {
+	assert := assert.New(t)
+
+	signals := make(chan os.Signal, 2)
+	p := newShutdownTestPlugin(t, func(cmd *cobra.Command) error {
+		signals <- os.Interrupt
+		<-cmd.Context().Done()
+		return cmd.Context().Err()
+	})
+
+	var stderr bytes.Buffer
+	log.SetStderr(&stderr)
+	defer log.SetStderr(nil)
+	err := p.executeWithSignals(context.Background(), signals)
+	assert.EqualError(err, "interrupted by signal interrupt")
+	assert.True(errors.Is(err, context.Canceled))
+	assert.Equal(130, ExitCode(err))
+	// Users are told that a second signal exits immediately
+	assert.Contains(stderr.String(), "Send the signal again to exit immediately")
+
+	// The error of the command is kept
+	p = newShutdownTestPlugin(t, func(cmd *cobra.Command) error {
+		signals <- syscall.SIGTERM
+		<-cmd.Context().Done()
+		return errors.New("cleanup failed")
+	})
+	err = p.executeWithSignals(context.Background(), signals)
+	assert.EqualError(err, "interrupted by signal terminated: cleanup failed")
+	assert.Equal(143, ExitCode(err))
+
+	// Commands that are not interrupted return their error
+	p = newShutdownTestPlugin(t, func(cmd *cobra.Command) error {
+		return errors.New("failed")
+	})
+	err = p.executeWithSignals(context.Background(), signals)
+	assert.EqualError(err, "failed")
+	assert.Equal(ExitCodeError, ExitCode(err))
+}
+
+func TestExecuteContextExitsOnShutdown(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	t.Setenv(config.EnvConfigKey, filepath.Join(dir, "config.yaml"))
+	t.Setenv(config.EnvConfigNextGenKey, filepath.Join(dir, "config-ng.yaml"))
+	t.Setenv(config.EnvConfigMetadataKey, filepath.Join(dir, "config-metadata.yaml"))
+	t.Setenv(config.EnvConfigAuditFileKey, filepath.Join(dir, config.AuditFileName))
+
+	gracePeriod := ShutdownGracePeriod
+	defer func() {
+		ShutdownGracePeriod = gracePeriod
+		osExit = os.Exit
+	}()
+
+	for _, tc := range []struct {
+		name        string
+		gracePeriod time.Duration
+		signals     []os.Signal
+	}{
+		{name: "grace period elapsed", gracePeriod: 10 * time.Millisecond, signals: []os.Signal{os.Interrupt}},
+		{name: "second signal", gracePeriod: time.Hour, signals: []os.Signal{syscall.SIGTERM, os.Interrupt}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ShutdownGracePeriod = tc.gracePeriod
+			exited := make(chan int, 1)
+			var lockErr error
+			osExit = func(code int) {
+				// The config lock is still held by the command when the process exits
+				lockErr = fslock.New(filepath.Join(dir, config.LocalTanzuFileLock)).TryLock()
+				exited <- code
+			}
+
+			signals := make(chan os.Signal, 2)
+			var exitCode int
+			p := newShutdownTestPlugin(t, func(cmd *cobra.Command) error {
+				// The command holds the config lock and ignores the cancellation of its context
+				config.AcquireTanzuConfigLock()
+				defer config.ReleaseTanzuConfigLock()
+				for _, sig := range tc.signals {
+					signals <- sig
+				}
+				exitCode = <-exited
+				return nil
+			})
+
+			err := p.executeWithSignals(context.Background(), signals)
+			assert.Equal(signalExitCode(tc.signals[0]), exitCode)
+			assert.Equal(exitCode, ExitCode(err))
+			assert.Error(lockErr)
+
+			// The config lock was released by the command
+			config.AcquireTanzuConfigLock()
+			config.ReleaseTanzuConfigLock()
+		})
+	}
+}
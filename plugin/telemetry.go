@@ -0,0 +1,163 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/jsonlutils"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/log"
+)
+
+const (
+	// EnvTelemetryFileKey is the environment variable that overrides the path of the telemetry file
+	EnvTelemetryFileKey = "TANZU_CLI_PLUGIN_TELEMETRY_FILE"
+	// TelemetryFileName is the name of the telemetry file stored under the local cache directory of the CLI
+	TelemetryFileName = "telemetry.jsonl"
+)
+
+var (
+	// TelemetryMaxFileSize is the size in bytes after which the telemetry file is rotated
+	TelemetryMaxFileSize int64 = 1 << 20
+	// TelemetryMaxBackups is the number of rotated telemetry files that are kept e.g. telemetry.jsonl.1
+	TelemetryMaxBackups = 1
+)
+
+// TelemetryEvent records an invocation of a plugin command.
+// Only the names of the flags are recorded, never their values nor the arguments of the command.
+type TelemetryEvent struct {
+	Timestamp     time.Time `json:"timestamp" yaml:"timestamp"`
+	Plugin        string    `json:"plugin" yaml:"plugin"`
+	PluginVersion string    `json:"pluginVersion" yaml:"pluginVersion"`
+	Target        string    `json:"target,omitempty" yaml:"target,omitempty"`
+	// CommandPath is the path of the command under the plugin e.g. cluster list
+	CommandPath string   `json:"commandPath" yaml:"commandPath"`
+	Flags       []string `json:"flags,omitempty" yaml:"flags,omitempty"`
+	DurationMs  int64    `json:"durationMs" yaml:"durationMs"`
+	ExitCode    int      `json:"exitCode" yaml:"exitCode"`
+	// ErrorCategory is the category of the error of the command, empty if it succeeded
+	ErrorCategory ErrorCategory `json:"errorCategory,omitempty" yaml:"errorCategory,omitempty"`
+}
+
+// TelemetrySink stores the telemetry events of the plugin
+type TelemetrySink interface {
+	Record(event *TelemetryEvent) error
+}
+
+// FileTelemetrySink appends the telemetry events to a JSON lines file, which the CLI collects.
+// The file is rotated once it reaches TelemetryMaxFileSize so that it does not grow while events are not collected.
+type FileTelemetrySink struct {
+	// Path of the file, TelemetryFilePath if empty
+	Path string
+}
+
+// Record appends the event to the file
+func (s *FileTelemetrySink) Record(event *TelemetryEvent) error {
+	path := s.Path
+	if path == "" {
+		var err error
+		if path, err = TelemetryFilePath(); err != nil {
+			return err
+		}
+	}
+	return newTelemetryFile(path).Append(event)
+}
+
+// newTelemetryFile returns the telemetry file at the path, rotated as per TelemetryMaxFileSize and TelemetryMaxBackups
+func newTelemetryFile(path string) *jsonlutils.RotatingFile {
+	return &jsonlutils.RotatingFile{Path: path, MaxFileSize: TelemetryMaxFileSize, MaxBackups: TelemetryMaxBackups, Name: "telemetry"}
+}
+
+// TelemetryFilePath returns the path of the telemetry file, checking for environment overrides
+func TelemetryFilePath() (string, error) {
+	if path, ok := os.LookupEnv(EnvTelemetryFileKey); ok {
+		return path, nil
+	}
+	cacheDir, err := config.LocalCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, TelemetryFileName), nil
+}
+
+// ReadTelemetryEvents returns the telemetry events of the file and of its rotated files, oldest first.
+// Events that were partially written are skipped.
+func ReadTelemetryEvents(path string) ([]TelemetryEvent, error) {
+	var events []TelemetryEvent
+	err := newTelemetryFile(path).Read(func(line []byte) {
+		var event TelemetryEvent
+		if err := json.Unmarshal(line, &event); err == nil {
+			events = append(events, event)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+type TelemetryOpts func(p *Plugin)
+
+// WithTelemetrySink records the telemetry events with the sink instead of the telemetry file
+func WithTelemetrySink(sink TelemetrySink) TelemetryOpts {
+	return func(p *Plugin) {
+		p.telemetrySink = sink
+	}
+}
+
+// EnableTelemetry records a telemetry event for each invocation of a visible command of the plugin, if the user
+// opted in the Customer Experience Improvement Program. Events are appended to the telemetry file by default.
+// Failing to record an event does not fail the command.
+func (p *Plugin) EnableTelemetry(opts ...TelemetryOpts) {
+	p.telemetrySink = &FileTelemetrySink{}
+	for _, opt := range opts {
+		opt(p)
+	}
+}
+
+// isCEIPOptedIn returns true if the user opted in the Customer Experience Improvement Program
+func isCEIPOptedIn() bool {
+	optIn, err := config.GetCEIPOptIn()
+	if err != nil {
+		return false
+	}
+	optedIn, _ := strconv.ParseBool(optIn)
+	return optedIn
+}
+
+// recordTelemetry records the telemetry event of the invocation of the command, if telemetry is enabled
+func (p *Plugin) recordTelemetry(cmd *cobra.Command, start time.Time, err error) {
+	if p.telemetrySink == nil || cmd.Hidden || !isCEIPOptedIn() {
+		return
+	}
+	event := &TelemetryEvent{
+		Timestamp:   start.UTC(),
+		CommandPath: strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " "),
+		DurationMs:  time.Since(start).Milliseconds(),
+		ExitCode:    ExitCode(err),
+	}
+	if p.descriptor != nil {
+		event.Plugin = p.descriptor.Name
+		event.PluginVersion = p.descriptor.Version
+		event.Target = string(p.descriptor.Target)
+	}
+	if err != nil {
+		event.ErrorCategory = GetErrorCategory(err)
+	}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		event.Flags = append(event.Flags, flag.Name)
+	})
+	if recordErr := p.telemetrySink.Record(event); recordErr != nil {
+		log.V(6).Infof("unable to record the telemetry event: %v", recordErr)
+	}
+}
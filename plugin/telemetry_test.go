@@ -0,0 +1,202 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config"
+	"github.com/vmware-tanzu/tanzu-plugin-runtime/config/types"
+)
+
+type testTelemetrySink struct {
+	events []*TelemetryEvent
+	err    error
+}
+
+func (s *testTelemetrySink) Record(event *TelemetryEvent) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func setupTelemetryTestConfig(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv(config.EnvConfigKey, filepath.Join(dir, "config.yaml"))
+	t.Setenv(config.EnvConfigNextGenKey, filepath.Join(dir, "config-ng.yaml"))
+	t.Setenv(config.EnvConfigMetadataKey, filepath.Join(dir, "config-metadata.yaml"))
+	t.Setenv(config.EnvConfigAuditFileKey, filepath.Join(dir, config.AuditFileName))
+	t.Setenv(EnvTelemetryFileKey, filepath.Join(dir, TelemetryFileName))
+	return filepath.Join(dir, TelemetryFileName)
+}
+
+func newTelemetryTestPlugin(t *testing.T, opts ...TelemetryOpts) *Plugin {
+	p, err := NewPlugin(&PluginDescriptor{
+		Name:                    "test-plugin",
+		Target:                  types.TargetK8s,
+		Description:             "Description of the plugin",
+		Version:                 "v1.2.3",
+		Group:                   "TestGroup",
+		SkipDefaultFeatureFlags: true,
+	})
+	assert.NoError(t, err)
+	p.EnableTelemetry(opts...)
+
+	listCmd := &cobra.Command{Use: "list", Run: func(cmd *cobra.Command, args []string) {}}
+	listCmd.Flags().Bool("all", false, "list all the clusters")
+	listCmd.Flags().String("name", "", "name of the cluster")
+	getCmd := &cobra.Command{
+		Use: "get",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return NewError(ErrorCategoryNotFound, errors.New("cluster not found"))
+		},
+	}
+	clusterCmd := &cobra.Command{Use: "cluster"}
+	clusterCmd.AddCommand(listCmd, getCmd)
+	p.AddCommands(clusterCmd)
+	p.Cmd.SetOut(&bytes.Buffer{})
+	p.Cmd.SetErr(&bytes.Buffer{})
+	return p
+}
+
+func TestTelemetry(t *testing.T) {
+	assert := assert.New(t)
+	setupTelemetryTestConfig(t)
+	sink := &testTelemetrySink{}
+
+	// Nothing is recorded unless the user opted in CEIP
+	p := newTelemetryTestPlugin(t, WithTelemetrySink(sink))
+	p.Cmd.SetArgs([]string{"cluster", "list"})
+	assert.NoError(p.Execute())
+	assert.NoError(config.SetCEIPOptIn("false"))
+	assert.NoError(p.Execute())
+	assert.Empty(sink.events)
+
+	assert.NoError(config.SetCEIPOptIn("true"))
+	p.Cmd.SetArgs([]string{"cluster", "list", "--name", "secret-cluster", "--all"})
+	assert.NoError(p.Execute())
+	assert.Len(sink.events, 1)
+	event := sink.events[0]
+	assert.Equal("test-plugin", event.Plugin)
+	assert.Equal("v1.2.3", event.PluginVersion)
+	assert.Equal("kubernetes", event.Target)
+	assert.Equal("cluster list", event.CommandPath)
+	assert.Equal([]string{"all", "name"}, event.Flags)
+	assert.Equal(ExitCodeSuccess, event.ExitCode)
+	assert.Empty(event.ErrorCategory)
+	assert.False(event.Timestamp.IsZero())
+
+	// Failures are recorded with their exit code and category
+	p = newTelemetryTestPlugin(t, WithTelemetrySink(sink))
+	p.Cmd.SetArgs([]string{"cluster", "get"})
+	assert.Error(p.Execute())
+	assert.Len(sink.events, 2)
+	assert.Equal("cluster get", sink.events[1].CommandPath)
+	assert.Equal(ExitCodeNotFound, sink.events[1].ExitCode)
+	assert.Equal(ErrorCategoryNotFound, sink.events[1].ErrorCategory)
+
+	// Interrupted commands are recorded with the exit code of the signal
+	signals := make(chan os.Signal, 2)
+	p = newTelemetryTestPlugin(t, WithTelemetrySink(sink))
+	p.AddCommands(&cobra.Command{Use: "wait", RunE: func(cmd *cobra.Command, args []string) error {
+		signals <- os.Interrupt
+		<-cmd.Context().Done()
+		return cmd.Context().Err()
+	}})
+	p.Cmd.SetArgs([]string{"wait"})
+	assert.Error(p.executeWithSignals(context.Background(), signals))
+	assert.Len(sink.events, 3)
+	assert.Equal("wait", sink.events[2].CommandPath)
+	assert.Equal(130, sink.events[2].ExitCode)
+
+	// Hidden commands are not recorded, and failing to record does not fail the command
+	sink.err = errors.New("sink failure")
+	p = newTelemetryTestPlugin(t, WithTelemetrySink(sink))
+	p.Cmd.SetArgs([]string{"info"})
+	assert.NoError(p.Execute())
+	assert.Len(sink.events, 3)
+	p.Cmd.SetArgs([]string{"cluster", "list"})
+	assert.NoError(p.Execute())
+	assert.Len(sink.events, 4)
+
+	// Plugins that did not enable telemetry record nothing
+	p = newTelemetryTestPlugin(t, WithTelemetrySink(sink))
+	p.telemetrySink = nil
+	p.Cmd.SetArgs([]string{"cluster", "list"})
+	assert.NoError(p.Execute())
+	assert.Len(sink.events, 4)
+}
+
+func TestFileTelemetrySink(t *testing.T) {
+	assert := assert.New(t)
+	path := setupTelemetryTestConfig(t)
+	assert.NoError(config.SetCEIPOptIn("true"))
+
+	events, err := ReadTelemetryEvents(path)
+	assert.NoError(err)
+	assert.Empty(events)
+
+	// Events are appended to the telemetry file by default
+	p := newTelemetryTestPlugin(t)
+	p.Cmd.SetArgs([]string{"cluster", "list", "--all"})
+	assert.NoError(p.Execute())
+	p.Cmd.SetArgs([]string{"cluster", "get"})
+	assert.Error(p.Execute())
+
+	events, err = ReadTelemetryEvents(path)
+	assert.NoError(err)
+	assert.Len(events, 2)
+	assert.Equal("cluster list", events[0].CommandPath)
+	assert.Equal([]string{"all"}, events[0].Flags)
+	assert.Equal("cluster get", events[1].CommandPath)
+	assert.Equal(ExitCodeNotFound, events[1].ExitCode)
+
+	// Partially written events are skipped
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	assert.NoError(err)
+	_, err = file.WriteString(`{"plugin": "test-pl`)
+	assert.NoError(err)
+	assert.NoError(file.Close())
+	events, err = ReadTelemetryEvents(path)
+	assert.NoError(err)
+	assert.Len(events, 2)
+
+	// The sink creates the directory of its file
+	sink := &FileTelemetrySink{Path: filepath.Join(t.TempDir(), "telemetry", "events.jsonl")}
+	assert.NoError(sink.Record(&TelemetryEvent{Plugin: "test-plugin", CommandPath: "cluster"}))
+	events, err = ReadTelemetryEvents(sink.Path)
+	assert.NoError(err)
+	assert.Equal([]TelemetryEvent{{Plugin: "test-plugin", CommandPath: "cluster"}}, events)
+
+	// The file is rotated once it reaches the maximum size, and the oldest events are dropped
+	maxFileSize := TelemetryMaxFileSize
+	defer func() { TelemetryMaxFileSize = maxFileSize }()
+	TelemetryMaxFileSize = 150
+	sink = &FileTelemetrySink{Path: filepath.Join(t.TempDir(), "events.jsonl")}
+	for _, commandPath := range []string{"cluster list", "cluster get", "cluster delete"} {
+		assert.NoError(sink.Record(&TelemetryEvent{Plugin: "test-plugin", CommandPath: commandPath}))
+	}
+	assert.FileExists(sink.Path + ".1")
+	assert.NoFileExists(sink.Path + ".2")
+	events, err = ReadTelemetryEvents(sink.Path)
+	assert.NoError(err)
+	assert.Len(events, 2)
+	assert.Equal("cluster get", events[0].CommandPath)
+	assert.Equal("cluster delete", events[1].CommandPath)
+
+	// The telemetry file is stored in the local state directory by default
+	dir := t.TempDir()
+	t.Setenv(config.EnvConfigDirKey, dir)
+	assert.NoError(os.Unsetenv(EnvTelemetryFileKey))
+	path, err = TelemetryFilePath()
+	assert.NoError(err)
+	assert.Equal(filepath.Join(dir, "cache", TelemetryFileName), path)
+}
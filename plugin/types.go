@@ -14,6 +14,13 @@ type PluginCompletionType int
 // Hook is the mechanism used to define function for plugin hooks
 type Hook func() error
 
+// UpgradeHook is the function run after upgrading a plugin from the old version to the new version
+type UpgradeHook func(oldVersion, newVersion string) error
+
+// ContextChangedHook is the function run after the current context of the target changed.
+// The context name is empty if the target has no current context anymore.
+type ContextChangedHook func(target types.Target, contextName string) error
+
 const (
 	// NativePluginCompletion indicates command line completion is determined using the built in
 	// cobra.Command __complete mechanism.
@@ -102,6 +109,36 @@ type PluginDescriptor struct {
 	// PostInstallHook is function to be run post install of a plugin.
 	PostInstallHook Hook `json:"-" yaml:"-"`
 
+	// PreUninstallHook is function to be run before uninstalling a plugin.
+	PreUninstallHook Hook `json:"-" yaml:"-"`
+
+	// PostUpgradeHook is function to be run after upgrading a plugin.
+	PostUpgradeHook UpgradeHook `json:"-" yaml:"-"`
+
+	// ContextChangedHook is function to be run after the current context of a target changed.
+	ContextChangedHook ContextChangedHook `json:"-" yaml:"-"`
+
+	// FirstRunHook is function to be run before a plugin is used for the first time.
+	FirstRunHook Hook `json:"-" yaml:"-"`
+
+	// DoctorChecks are the checks run by the doctor command to verify the plugin is correctly installed and
+	// configured, after the compatibility check with the CLI.
+	DoctorChecks []DoctorCheck `json:"-" yaml:"-"`
+
+	// Compatibility declares the plugin API versions and the minimum CLI version the plugin works with.
+	// It is checked against the versions advertised by the CLI when the plugin is executed.
+	Compatibility *Compatibility `json:"compatibility,omitempty" yaml:"compatibility,omitempty"`
+
 	// DefaultFeatureFlags is default featureflags to be configured if missing when invoking plugin
 	DefaultFeatureFlags map[string]bool `json:"defaultFeatureFlags,omitempty" yaml:"defaultFeatureFlags,omitempty"`
+
+	// SkipDefaultFeatureFlags disables configuring DefaultFeatureFlags when the plugin is invoked or post-installed.
+	SkipDefaultFeatureFlags bool `json:"-" yaml:"-"`
+
+	// ConfigKeys are the config keys the plugin reads, as paths e.g. contexts, clientOptions.cli.bomRepo.
+	// They are listed by the manifest command.
+	ConfigKeys []string `json:"-" yaml:"-"`
+
+	// EnvVars are the environment variables the plugin reads. They are listed by the manifest command.
+	EnvVars []string `json:"-" yaml:"-"`
 }